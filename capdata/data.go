@@ -16,13 +16,46 @@
 package capdata
 
 import (
+	"sort"
+	"time"
+
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 )
 
 // Fabric ACI data of switch id and pod id
 type Fabric struct {
 	SwitchData []string
-	PodID      string
+	// PodID is the pod the fabric was discovered in, it is the pod of the switches stored before SwitchPods
+	PodID string
+	// SwitchPods holds the pod of each switch of the fabric
+	SwitchPods map[string]string `json:",omitempty"`
+	// AbsentSwitches holds the switches whose node is no longer in the fabric along with the time it was found missing
+	AbsentSwitches map[string]time.Time `json:",omitempty"`
+}
+
+// SwitchPod returns the pod of the switch
+func (f *Fabric) SwitchPod(switchID string) string {
+	if podID, ok := f.SwitchPods[switchID]; ok {
+		return podID
+	}
+	return f.PodID
+}
+
+// Pods returns the pods the switches of the fabric are in
+func (f *Fabric) Pods() []string {
+	podSet := make(map[string]bool)
+	for _, switchID := range f.SwitchData {
+		podSet[f.SwitchPod(switchID)] = true
+	}
+	if len(podSet) == 0 && f.PodID != "" {
+		podSet[f.PodID] = true
+	}
+	pods := make([]string, 0, len(podSet))
+	for podID := range podSet {
+		pods = append(pods, podID)
+	}
+	sort.Strings(pods)
+	return pods
 }
 
 // EndpointData hold the EndpointData data
@@ -31,6 +64,13 @@ type EndpointData struct {
 	ACIPolicyGroupData *ACIPolicyGroupData
 }
 
+// Controller holds the manager of an APIC controller along with the node it is in the fabric
+type Controller struct {
+	PodID   string
+	NodeID  string
+	Manager *model.Manager
+}
+
 // ACIPolicyGroupData holds info regarding the ACI policy profile
 type ACIPolicyGroupData struct {
 	PolicyGroupDN             string
@@ -32,7 +32,7 @@ import (
 
 // GetAddressPoolCollection fetches the addresspool which are linked to that fabric
 func GetAddressPoolCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	fabricID := ctx.Params().Get("id")
 	// get all switches which are store under that fabric
 
@@ -58,8 +58,9 @@ func GetAddressPoolCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(addressPoolCollectionResponse)
+	writeCollection(ctx, addressPoolCollectionResponse, func(oid string) (interface{}, error) {
+		return capmodel.GetAddressPool(fabricID, oid)
+	})
 }
 
 // GetAddressPoolInfo fetches the addresspool info for given addresspool id
@@ -73,13 +74,14 @@ func GetAddressPoolInfo(ctx iris.Context) {
 		return
 	}
 	// Get the addresspool data from the memory
-	addressPool, err := capmodel.GetAddressPool(fabricID, uri)
+	addressPool, etag, err := capmodel.GetAddressPoolWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch AddressPool data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"AddressPool", uri})
 		return
 	}
 
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(addressPool)
 }
@@ -220,12 +222,15 @@ func DeleteAddressPoolInfo(ctx iris.Context) {
 		return
 	}
 
-	addresspoolData, err := capmodel.GetAddressPool(fabricID, uri)
+	addresspoolData, etag, err := capmodel.GetAddressPoolWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch AddressPool data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"AddressPool", fabricID})
 		return
 	}
+	if !checkIfMatch(ctx, uri, etag) {
+		return
+	}
 	if addresspoolData.Links != nil && len(addresspoolData.Links.Zones) > 0 {
 		errMsg := fmt.Sprintf("AddressPool cannot be deleted as there are dependent Zone  still tied to it")
 		log.Error(errMsg)
@@ -254,30 +259,24 @@ func getAddressPoolData(fabricID, addresspoolOID string) (*model.AddressPool, in
 }
 
 func updateAddressPoolData(fabricID, zoneOID, addresspoolOID, operation string) error {
-	addresspoolData, err := capmodel.GetAddressPool(fabricID, addresspoolOID)
-	if err != nil {
-		return err
-	}
-	if addresspoolData.Links == nil {
-		addresspoolData.Links = &model.AddressPoolLinks{}
-	}
-	if operation == "Add" {
-		addresspoolData.Links.Zones = []model.Link{
-			model.Link{
-				Oid: zoneOID,
-			},
+	return capmodel.ModifyAddressPool(fabricID, addresspoolOID, func(addresspoolData *model.AddressPool) {
+		if addresspoolData.Links == nil {
+			addresspoolData.Links = &model.AddressPoolLinks{}
 		}
-		addresspoolData.Links.ZonesCount = len(addresspoolData.Links.Zones)
-	} else {
-		addresspoolData.Links.Zones = []model.Link{}
-		if len(addresspoolData.Links.Endpoints) == 0 {
-			addresspoolData.Links = nil
+		if operation == "Add" {
+			addresspoolData.Links.Zones = []model.Link{
+				model.Link{
+					Oid: zoneOID,
+				},
+			}
+			addresspoolData.Links.ZonesCount = len(addresspoolData.Links.Zones)
+		} else {
+			addresspoolData.Links.Zones = []model.Link{}
+			if len(addresspoolData.Links.Endpoints) == 0 {
+				addresspoolData.Links = nil
+			}
 		}
-	}
-	if err := capmodel.UpdateAddressPool(fabricID, addresspoolOID, &addresspoolData); err != nil {
-		return err
-	}
-	return nil
+	})
 }
 
 func validateVLANIdentifierAddressRange(lowerValue int, upperValue int, nativeVLANflag bool) (interface{}, int) {
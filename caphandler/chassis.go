@@ -18,28 +18,34 @@ package caphandler
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/ODIM/lib-utilities/response"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
 	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
 
 	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
 )
 
 // GetChassisCollection collects all the chassis details which are managed by plugin
 func GetChassisCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
-	var members []*model.Link
+	uri := ctx.Request().URL.Path
+	var members = []*model.Link{}
 	chassisData, err := capmodel.GetAllSwitchChassis("")
 	if err != nil {
 		capresponse.SetErrorResponse(ctx, http.StatusInternalServerError, response.InternalError, err.Error(), nil)
 		return
 	}
+	chassisByOid := make(map[string]model.Chassis)
 	for _, chassis := range chassisData {
 		members = append(members, &model.Link{
 			Oid: chassis.Oid,
 		})
+		chassisByOid[chassis.Oid] = chassis
 	}
 	chassisCollection := model.Collection{
 		ODataContext: "/ODIM/v1/$metadata#ChassisCollection.ChassisCollection",
@@ -50,9 +56,10 @@ func GetChassisCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(chassisCollection)
-
+	policy := getCachePolicy(ctx)
+	writeCollection(ctx, chassisCollection, func(oid string) (interface{}, error) {
+		return getChassisResponse(chassisByOid[oid], policy), nil
+	})
 }
 
 // GetChassis collects retrives the specific  chassis details which is managed by plugin
@@ -66,10 +73,65 @@ func GetChassis(ctx iris.Context) {
 		return
 	}
 	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(data)
+	ctx.JSON(getChassisResponse(data, getCachePolicy(ctx)))
 	return
 }
 
+// chassisWithStatus is the chassis response along with the conditions affecting its health
+type chassisWithStatus struct {
+	model.Chassis
+	Status *capmodel.Status `json:"Status,omitempty"`
+}
+
+// getChassisResponse builds the chassis response with the health read from APIC, the chassis of an absent switch
+// keeps its stored status
+func getChassisResponse(chassis model.Chassis, policy caputilities.CachePolicy) *chassisWithStatus {
+	response := &chassisWithStatus{Chassis: chassis}
+	if chassis.Status != nil {
+		response.Status = &capmodel.Status{State: chassis.Status.State, Health: chassis.Status.Health, HealthRollup: chassis.Status.HealthRollup}
+	}
+	if chassis.Status != nil && chassis.Status.State == constants.AbsentState {
+		return response
+	}
+	response.Status = getChassisHealthData(&chassis, policy)
+	response.Status.State = "Enabled"
+	return response
+}
+
+// getChassisHealthData evaluates the health of the chassis of the switch the chassis is linked to
+func getChassisHealthData(chassis *model.Chassis, policy caputilities.CachePolicy) *capmodel.Status {
+	if chassis.Links == nil || len(chassis.Links.Switches) == 0 {
+		log.Error("Unable to get Health of chassis " + chassis.ID + ": it is not linked to a switch")
+		return &capmodel.Status{}
+	}
+	// the switch is linked as /ODIM/v1/Fabrics/{fabricID}/Switches/{switchID}
+	switchURI := strings.Split(chassis.Links.Switches[0].Oid, "/")
+	if len(switchURI) != 7 {
+		log.Error("Unable to get Health of chassis " + chassis.ID + ": invalid switch link " + chassis.Links.Switches[0].Oid)
+		return &capmodel.Status{}
+	}
+	fabricID, switchID := switchURI[4], switchURI[6]
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		log.Error("Unable to get Health of chassis " + chassis.ID + ": " + err.Error())
+		return &capmodel.Status{}
+	}
+	podID := fabricData.SwitchPod(switchID)
+	nodeID := switchID[strings.LastIndex(switchID, ":")+1:]
+	healthData, err := caputilities.GetSwitchChassisHealth(podID, nodeID, policy)
+	if err != nil {
+		log.Error("Unable to get Health of chassis " + err.Error())
+		return &capmodel.Status{}
+	}
+	chassisDN := fmt.Sprintf("topology/pod-%s/node-%s/sys/ch", podID, nodeID)
+	status, err := caputilities.EvaluateHealth(caputilities.HealthResourceChassis, &healthData.Attributes, chassisDN)
+	if err != nil {
+		log.Error("Unable to evaluate Health of chassis " + err.Error())
+		return &capmodel.Status{}
+	}
+	return status
+}
+
 // ChassisMethodNotAllowed holds builds reponse for the unallowed http operation on Chassis URLs and returns 405 error.
 func ChassisMethodNotAllowed(ctx iris.Context) {
 	ctx.ResponseWriter().Header().Set("Allow", "GET")
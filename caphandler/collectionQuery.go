@@ -0,0 +1,287 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+
+	iris "github.com/kataras/iris/v12"
+)
+
+// memberLoader returns the collection member with the given @odata.id as it is served by its GET
+type memberLoader func(oid string) (interface{}, error)
+
+// collectionResponse is the collection response whose members may be expanded
+type collectionResponse struct {
+	model.Collection
+	Members         []interface{} `json:"Members"`
+	MembersNextLink string        `json:"Members@odata.nextLink,omitempty"`
+}
+
+// collectionQuery holds the Redfish query parameters requested on a collection
+type collectionQuery struct {
+	expand     bool
+	selectList [][]string
+	filter     filterExpression
+	// top is -1 when all the remaining members are requested
+	top      int
+	skip     int
+	rawQuery string
+}
+
+// unsupportedQueryParameters are the Redfish query parameters which the plugin doesn't implement
+var unsupportedQueryParameters = map[string]bool{
+	"only":    true,
+	"excerpt": true,
+}
+
+// writeCollection responds with the collection after applying the $expand, $select, $filter,
+// $top and $skip query parameters of the request, the members are only loaded when they
+// are expanded or filtered
+func writeCollection(ctx iris.Context, collection model.Collection, load memberLoader) {
+	query, statusCode, err := parseCollectionQuery(ctx.Request().URL.RawQuery)
+	if err != nil {
+		ctx.StatusCode(statusCode)
+		ctx.JSON(updateErrorResponse(response.QueryNotSupported, err.Error(), nil))
+		return
+	}
+
+	links := collection.Members
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Oid < links[j].Oid
+	})
+	members := []interface{}{}
+	for _, link := range links {
+		if !query.expand && query.filter == nil {
+			members = append(members, link)
+			continue
+		}
+		member, err := loadCollectionMember(link.Oid, load)
+		if errors.Is(err, db.ErrorKeyNotFound) {
+			// the member was removed after the collection was read
+			continue
+		}
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to fetch collection member %s: %s", link.Oid, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{collection.Name, link.Oid})
+			return
+		}
+		if query.filter != nil && !query.filter.evaluate(member) {
+			continue
+		}
+		if !query.expand {
+			members = append(members, link)
+			continue
+		}
+		if query.selectList != nil {
+			member = selectProperties(member, query.selectList)
+		}
+		members = append(members, member)
+	}
+
+	collectionResp := collectionResponse{
+		Collection: collection,
+		Members:    query.page(members),
+	}
+	collectionResp.MembersCount = len(members)
+	if query.top >= 0 && query.skip+query.top < len(members) {
+		collectionResp.MembersNextLink = collection.ODataID + "?" + query.nextPageQuery()
+	}
+
+	ctx.StatusCode(http.StatusOK)
+	if query.selectList == nil || query.expand {
+		ctx.JSON(collectionResp)
+		return
+	}
+	resource, err := toJSONObject(collectionResp)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to select properties of collection %s: %s", collection.ODataID, err.Error())
+		ctx.StatusCode(http.StatusInternalServerError)
+		ctx.JSON(updateErrorResponse(response.InternalError, errMsg, nil))
+		return
+	}
+	ctx.JSON(selectProperties(resource, query.selectList))
+}
+
+func loadCollectionMember(oid string, load memberLoader) (map[string]interface{}, error) {
+	member, err := load(oid)
+	if err != nil {
+		return nil, err
+	}
+	return toJSONObject(member)
+}
+
+// toJSONObject returns the resource as it is seen by the clients
+func toJSONObject(resource interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// parseCollectionQuery parses the query string of a collection request, the returned
+// status code tells apart the malformed queries from the unsupported ones
+func parseCollectionQuery(rawQuery string) (*collectionQuery, int, error) {
+	query := &collectionQuery{
+		top:      -1,
+		rawQuery: rawQuery,
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid query %s: %v", rawQuery, err)
+	}
+	for name, value := range values {
+		if len(value) > 1 {
+			return nil, http.StatusBadRequest, fmt.Errorf("query parameter %s is repeated", name)
+		}
+		switch name {
+		case "$expand":
+			err = query.parseExpand(value[0])
+		case "$select":
+			err = query.parseSelect(value[0])
+		case "$filter":
+			query.filter, err = parseFilter(value[0])
+		case "$top":
+			query.top, err = parseNonNegative(name, value[0])
+		case "$skip":
+			query.skip, err = parseNonNegative(name, value[0])
+		default:
+			if strings.HasPrefix(name, "$") || unsupportedQueryParameters[name] {
+				return nil, http.StatusNotImplemented, fmt.Errorf("query parameter %s is not supported", name)
+			}
+		}
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	return query, http.StatusOK, nil
+}
+
+// parseExpand accepts the expansion of the collection members only, which is all the plugin
+// resources link to below a collection
+func (q *collectionQuery) parseExpand(value string) error {
+	expandType, levels := value, ""
+	if i := strings.Index(value, "("); i >= 0 {
+		expandType, levels = value[:i], value[i:]
+	}
+	if expandType != "." && expandType != "*" && expandType != "~" {
+		return fmt.Errorf("invalid $expand value %s, expected one of '.', '*' or '~'", value)
+	}
+	if levels != "" && levels != "($levels=1)" {
+		return fmt.Errorf("invalid $expand value %s, only one level of expansion is supported", value)
+	}
+	q.expand = true
+	return nil
+}
+
+func (q *collectionQuery) parseSelect(value string) error {
+	for _, property := range strings.Split(value, ",") {
+		path := strings.Split(strings.TrimSpace(property), "/")
+		for _, name := range path {
+			if name == "" {
+				return fmt.Errorf("invalid $select value %s", value)
+			}
+		}
+		q.selectList = append(q.selectList, path)
+	}
+	return nil
+}
+
+func parseNonNegative(name, value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid %s value %s, expected a non-negative integer", name, value)
+	}
+	return number, nil
+}
+
+// page returns the members requested by $skip and $top
+func (q *collectionQuery) page(members []interface{}) []interface{} {
+	if q.skip >= len(members) {
+		return []interface{}{}
+	}
+	members = members[q.skip:]
+	if q.top >= 0 && q.top < len(members) {
+		members = members[:q.top]
+	}
+	return members
+}
+
+// nextPageQuery returns the query of the request with $skip pointing to the next page
+func (q *collectionQuery) nextPageQuery() string {
+	var params []string
+	for _, param := range strings.Split(q.rawQuery, "&") {
+		name, _ := url.QueryUnescape(strings.SplitN(param, "=", 2)[0])
+		if param == "" || name == "$skip" {
+			continue
+		}
+		params = append(params, param)
+	}
+	params = append(params, "$skip="+strconv.Itoa(q.skip+q.top))
+	return strings.Join(params, "&")
+}
+
+// selectProperties returns the properties of the resource given by the $select paths
+// along with its @odata annotations
+func selectProperties(resource map[string]interface{}, paths [][]string) map[string]interface{} {
+	selected := make(map[string]interface{})
+	for name, value := range resource {
+		if strings.HasPrefix(name, "@odata.") {
+			selected[name] = value
+		}
+	}
+	for _, path := range paths {
+		copyProperty(selected, resource, path)
+	}
+	return selected
+}
+
+func copyProperty(dst, src map[string]interface{}, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	dstNested, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		dstNested = make(map[string]interface{})
+		dst[path[0]] = dstNested
+	}
+	copyProperty(dstNested, nested, path[1:])
+}
@@ -0,0 +1,183 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	"github.com/stretchr/testify/assert"
+
+	iris "github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestParseFilter(t *testing.T) {
+	port := map[string]interface{}{
+		"Id":           "eth1-1",
+		"LinkStatus":   "LinkDown",
+		"CurrentSpeed": float64(10),
+		"Enabled":      true,
+		"Status": map[string]interface{}{
+			"Health": "Warning",
+		},
+		"Description": "server's uplink",
+	}
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"LinkStatus eq 'LinkDown'", true},
+		{"LinkStatus ne 'LinkDown'", false},
+		{"Status/Health eq 'Warning'", true},
+		{"CurrentSpeed ge 10 and CurrentSpeed lt 25", true},
+		{"CurrentSpeed gt 10", false},
+		{"Enabled eq true", true},
+		{"MaxFrameSize eq null", true},
+		{"not (LinkStatus eq 'LinkUp')", true},
+		{"LinkStatus eq 'LinkUp' or Status/Health eq 'Critical'", false},
+		{"LinkStatus eq 'LinkUp' or Status/Health eq 'Warning' and Enabled eq true", true},
+		{"(LinkStatus eq 'LinkUp' or Status/Health eq 'Warning') and Enabled eq false", false},
+		{"Description eq 'server''s uplink'", true},
+		{"Id gt 'eth1-0'", true},
+		{"Status gt 'Warning'", false},
+	}
+	for _, tt := range tests {
+		filter, err := parseFilter(tt.filter)
+		if !assert.Nil(t, err, tt.filter) {
+			continue
+		}
+		assert.Equal(t, tt.want, filter.evaluate(port), tt.filter)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"LinkStatus",
+		"LinkStatus eq",
+		"LinkStatus has 'LinkDown'",
+		"LinkStatus eq LinkDown",
+		"LinkStatus eq 'LinkDown",
+		"(LinkStatus eq 'LinkDown'",
+		"LinkStatus eq 'LinkDown')",
+		"Status//Health eq 'OK'",
+		"LinkStatus eq 'LinkDown' and",
+	} {
+		_, err := parseFilter(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestParseCollectionQuery(t *testing.T) {
+	query, statusCode, err := parseCollectionQuery("$expand=.($levels=1)&$select=Id,Status/Health&$top=2&$skip=4")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.True(t, query.expand)
+	assert.Equal(t, [][]string{{"Id"}, {"Status", "Health"}}, query.selectList)
+	assert.Equal(t, 2, query.top)
+	assert.Equal(t, 4, query.skip)
+	assert.Equal(t, "$expand=.($levels=1)&$select=Id,Status/Health&$top=2&$skip=6", query.nextPageQuery())
+
+	query, _, err = parseCollectionQuery("")
+	assert.Nil(t, err)
+	assert.Equal(t, -1, query.top)
+
+	for rawQuery, wantStatusCode := range map[string]int{
+		"$top=-1":              http.StatusBadRequest,
+		"$skip=two":            http.StatusBadRequest,
+		"$top=1&$top=2":        http.StatusBadRequest,
+		"$expand=Links":        http.StatusBadRequest,
+		"$expand=*($levels=2)": http.StatusBadRequest,
+		"$select=Status/":      http.StatusBadRequest,
+		"$filter=Id":           http.StatusBadRequest,
+		"$orderby=Id":          http.StatusNotImplemented,
+		"only":                 http.StatusNotImplemented,
+	} {
+		_, statusCode, err := parseCollectionQuery(rawQuery)
+		assert.NotNil(t, err, rawQuery)
+		assert.Equal(t, wantStatusCode, statusCode, rawQuery)
+	}
+}
+
+func TestGetZonesWithQuery(t *testing.T) {
+	config.SetUpMockConfig(t)
+	db.Connector = db.NewInMemoryConnector()
+	defer func() {
+		db.Connector = db.MockConnector{}
+	}()
+	fabricID := "d72dade0-c35a-984c-4859-1108132d72da"
+	assert.Nil(t, capmodel.SaveFabric(fabricID, &capdata.Fabric{}))
+	zonesURI := "/ODIM/v1/Fabrics/" + fabricID + "/Zones"
+	for i, zoneType := range []string{"ZoneOfEndpoints", "Default", "ZoneOfEndpoints", "ZoneOfZones", "ZoneOfEndpoints"} {
+		zoneURI := fmt.Sprintf("%s/zone-%d", zonesURI, i)
+		assert.Nil(t, capmodel.SaveZone(fabricID, zoneURI, &model.Zone{
+			ODataID:   zoneURI,
+			ODataType: "#Zone.v1_6_1.Zone",
+			ID:        fmt.Sprintf("zone-%d", i),
+			Name:      fmt.Sprintf("Zone %d", i),
+			ZoneType:  zoneType,
+		}))
+	}
+
+	mockApp := iris.New()
+	mockApp.Get("/ODIM/v1/Fabrics/{id}/Zones", GetZones)
+	e := httptest.New(t, mockApp)
+
+	// members are ordered by @odata.id and only the requested page is returned
+	resp := e.GET(zonesURI).WithQuery("$top", 2).Expect().Status(http.StatusOK).JSON().Object()
+	resp.Value("@odata.id").Equal(zonesURI)
+	resp.Value("Members@odata.count").Equal(5)
+	resp.Value("Members").Array().Length().Equal(2)
+	resp.Value("Members").Array().Element(0).Object().Value("@odata.id").Equal(zonesURI + "/zone-0")
+	resp.Value("Members@odata.nextLink").Equal(zonesURI + "?%24top=2&$skip=2")
+
+	// the last page has no next link
+	resp = e.GET(zonesURI).WithQuery("$top", 2).WithQuery("$skip", 4).Expect().Status(http.StatusOK).JSON().Object()
+	resp.Value("Members").Array().Length().Equal(1)
+	resp.Value("Members").Array().Element(0).Object().Value("@odata.id").Equal(zonesURI + "/zone-4")
+	resp.NotContainsKey("Members@odata.nextLink")
+
+	// filtered members are counted and expanded with the selected properties
+	resp = e.GET(zonesURI).WithQuery("$filter", "ZoneType eq 'ZoneOfEndpoints'").WithQuery("$expand", ".").
+		WithQuery("$select", "Name").WithQuery("$skip", 1).
+		Expect().Status(http.StatusOK).JSON().Object()
+	resp.Value("Members@odata.count").Equal(3)
+	members := resp.Value("Members").Array()
+	members.Length().Equal(2)
+	members.Element(0).Object().Equal(map[string]interface{}{
+		"@odata.id":   zonesURI + "/zone-2",
+		"@odata.type": "#Zone.v1_6_1.Zone",
+		"Name":        "Zone 2",
+	})
+	members.Element(1).Object().Value("Name").Equal("Zone 4")
+
+	// without $expand the members stay links and $select applies to the collection
+	resp = e.GET(zonesURI).WithQuery("$filter", "ZoneType ne 'ZoneOfEndpoints'").
+		WithQuery("$select", "Members").Expect().Status(http.StatusOK).JSON().Object()
+	resp.Keys().ContainsOnly("@odata.context", "@odata.id", "@odata.type", "Members")
+	resp.Value("Members").Equal([]interface{}{
+		map[string]interface{}{"@odata.id": zonesURI + "/zone-1"},
+		map[string]interface{}{"@odata.id": zonesURI + "/zone-3"},
+	})
+
+	e.GET(zonesURI).WithQuery("$filter", "ZoneType eq").Expect().Status(http.StatusBadRequest)
+	e.GET(zonesURI).WithQuery("$orderby", "Name").Expect().Status(http.StatusNotImplemented)
+}
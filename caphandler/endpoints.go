@@ -35,7 +35,7 @@ import (
 
 // GetEndpointCollection : Fetches details of the given resource from the device
 func GetEndpointCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	fabricID := ctx.Params().Get("id")
 
 	endpointData, err := capmodel.GetAllEndpoints(fabricID)
@@ -61,8 +61,13 @@ func GetEndpointCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(endpointCollection)
+	writeCollection(ctx, endpointCollection, func(oid string) (interface{}, error) {
+		endpointData, err := capmodel.GetEndpoints(fabricID, oid)
+		if err != nil {
+			return nil, err
+		}
+		return endpointData.Endpoint, nil
+	})
 }
 
 // CreateEndpoint : created endpoints under given fabric
@@ -118,6 +123,7 @@ func CreateEndpoint(ctx iris.Context) {
 	}
 	var switchURI = ""
 	var portPattern = ""
+	var podID = ""
 	portList := make(map[string]bool)
 	// check if given ports are present in plugin database
 	for i := 0; i < len(endpoint.Redundancy[0].RedundancySet); i++ {
@@ -144,7 +150,48 @@ func CreateEndpoint(ctx iris.Context) {
 		}
 		portURIData := strings.Split(portURI, "/")
 		switchID := portURIData[6]
+		// the leaves of a vPC pair are in the same pod, the path of the endpoint is in that pod
+		switchPodID := fabricData.SwitchPod(switchID)
+		if podID != "" && switchPodID != podID {
+			errMsg := fmt.Sprintf("Endpoint cannot be created, port %s is in pod %s while the other ports are in pod %s", portURI, switchPodID, podID)
+			resp := updateErrorResponse(response.PropertyValueConflict, errMsg, []interface{}{portURI, endpoint.Redundancy[0].RedundancySet[0].Oid})
+			ctx.StatusCode(http.StatusBadRequest)
+			ctx.JSON(resp)
+			return
+		}
+		podID = switchPodID
+		// the servers are attached to the leaves, the spine ports only connect the leaves
+		switchData, err := capmodel.GetSwitch(switchID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to fetch switch data for uri %s: %s", portURI, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{"Switch", switchID})
+			return
+		}
+		if capmodel.GetSwitchRole(&switchData) == capmodel.NodeRoleSpine {
+			errMsg := fmt.Sprintf("Endpoint cannot be created, port %s is on spine switch %s", portURI, switchID)
+			resp := updateErrorResponse(response.PropertyValueNotInList, errMsg, []interface{}{portURI, "RedundancySet"})
+			ctx.StatusCode(http.StatusBadRequest)
+			ctx.JSON(resp)
+			return
+		}
 		switchIDData := strings.Split(switchID, ":")
+		// the policy group of an interface override takes precedence over the policy group of the endpoint
+		overrideGroupDN, err := caputilities.GetInterfaceOverrideGroup(podID, switchIDData[1], portData.PortID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to read the interface override of port %s from APIC: %s", portURI, err.Error())
+			log.Error(errMsg)
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			ctx.StatusCode(statusCode)
+			ctx.JSON(resp)
+			return
+		}
+		if overrideGroupDN != "" {
+			errMsg := fmt.Sprintf("Endpoint cannot be created, the speed or the MTU of port %s are configured, they must be set back to the ones of the port first", portURI)
+			resp := updateErrorResponse(response.ResourceInUse, errMsg, nil)
+			ctx.StatusCode(http.StatusConflict)
+			ctx.JSON(resp)
+			return
+		}
 		switchURI = switchURI + "-" + switchIDData[1]
 		portIDData := strings.Split(portURIData[8], ":")
 		tmpPortPattern := strings.Replace(portIDData[1], "eth", "", -1)
@@ -162,7 +209,10 @@ func CreateEndpoint(ctx iris.Context) {
 	}
 
 	log.Info("Dn of Policy group:" + policyGroupDN)
-	aciPolicyGroupData.PolicyGroupDN = fmt.Sprintf("topology/pod-%s/protpaths%s/pathep-[%s]", fabricData.PodID, switchURI, aciPolicyGroupData.PcVPCPolicyGroupName)
+	aciPolicyGroupData.PolicyGroupDN = fmt.Sprintf("topology/pod-%s/protpaths%s/pathep-[%s]", podID, switchURI, aciPolicyGroupData.PcVPCPolicyGroupName)
+	if locksLost(ctx) {
+		return
+	}
 	if err = saveEndpointData(uri, fabricID, aciPolicyGroupData, &endpoint); err != nil {
 		errMsg := fmt.Sprintf("failed to store endpoint data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
@@ -180,12 +230,13 @@ func GetEndpointInfo(ctx iris.Context) {
 	uri := ctx.Request().RequestURI
 	fabricID := ctx.Params().Get("id")
 
-	endpointData, err := capmodel.GetEndpoints(fabricID, uri)
+	endpointData, etag, err := capmodel.GetEndpointsWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch endpoint data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Endpoint", fabricID})
 		return
 	}
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(endpointData.Endpoint)
 }
@@ -195,12 +246,15 @@ func DeleteEndpointInfo(ctx iris.Context) {
 	uri := ctx.Request().RequestURI
 	fabricID := ctx.Params().Get("id")
 
-	endpointData, err := capmodel.GetEndpoints(fabricID, uri)
+	endpointData, etag, err := capmodel.GetEndpointsWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch endpoint data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Endpoint", fabricID})
 		return
 	}
+	if !checkIfMatch(ctx, uri, etag) {
+		return
+	}
 
 	if endpointData.Endpoint.Links != nil && len(endpointData.Endpoint.Links.AddressPools) > 0 {
 		errMsg := fmt.Sprintf("Endpoint cannot be deleted as there are dependent upon AddressPool")
@@ -218,6 +272,9 @@ func DeleteEndpointInfo(ctx iris.Context) {
 		return
 	}
 	// check if endpoint is associated with any ZoneOfEndpoints
+	if locksLost(ctx) {
+		return
+	}
 	// get all zones
 	zoneCollectionData, err := capmodel.GetAllZones(fabricID)
 	if err != nil {
@@ -227,25 +284,14 @@ func DeleteEndpointInfo(ctx iris.Context) {
 
 	}
 	for zoneURI, zoneData := range zoneCollectionData {
-		if zoneData.ZoneType == "ZoneOfEndpoints" {
-			updateRequired := false
-			endpointsList := zoneData.Links.Endpoints
-			for i := 0; i < len(endpointsList); i++ {
-				if endpointsList[i].Oid == uri {
-					endpointsList = append(endpointsList[:i], endpointsList[i+1:]...)
-					updateRequired = true
-					break
-				}
+		if zoneData.ZoneType == "ZoneOfEndpoints" && removeZoneEndpoint(&zoneData, uri) {
+			if err = capmodel.ModifyZone(fabricID, zoneURI, func(zone *model.Zone) {
+				removeZoneEndpoint(zone, uri)
+			}); err != nil {
+				errMsg := fmt.Sprintf("failed to update zone data for %s: %s", zoneURI, err.Error())
+				createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", fabricID})
+				return
 			}
-			if updateRequired {
-				zoneData.Links.Endpoints = endpointsList
-				if err = capmodel.UpdateZone(fabricID, zoneURI, &zoneData); err != nil {
-					errMsg := fmt.Sprintf("failed to update zone data for %s: %s", zoneURI, err.Error())
-					createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", fabricID})
-					return
-				}
-			}
-
 		}
 	}
 
@@ -257,6 +303,20 @@ func DeleteEndpointInfo(ctx iris.Context) {
 	ctx.StatusCode(http.StatusNoContent)
 }
 
+// removeZoneEndpoint removes the link to the given endpoint from the zone and reports whether it was linked
+func removeZoneEndpoint(zone *model.Zone, endpointOID string) bool {
+	if zone.Links == nil {
+		return false
+	}
+	for i := 0; i < len(zone.Links.Endpoints); i++ {
+		if zone.Links.Endpoints[i].Oid == endpointOID {
+			zone.Links.Endpoints = append(zone.Links.Endpoints[:i], zone.Links.Endpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func saveEndpointData(uri, fabricID string, aciPolicyGroupData *capdata.ACIPolicyGroupData, endpoint *model.Endpoint) error {
 	endpointID := uuid.NewV4().String()
 	endpoint.ID = endpointID
@@ -318,6 +378,20 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 
 	switchProfileSelectorName := "Switch" + switchPattern + "_Profile_ifselector"
 	accessPortSelectorName := "Switch" + switchPattern + "_" + portPattern
+	portVPCPolicyName := "ODIM-PORT-VPCPolicy"
+	switchProfileName := "Switch" + switchPattern + "_Profile"
+
+	// the profiles and the LACP policy are shared with the other endpoints on the same switches,
+	// of any fabric, lock them so that concurrent requests don't both find them missing and create them
+	locks, resp, statusCode := lockACIObjects("create endpoint policy group",
+		"uni/infra/accportprof-"+switchProfileSelectorName,
+		"uni/infra/lacplagp-"+portVPCPolicyName,
+		"uni/infra/nprof-"+switchProfileName,
+	)
+	if locks == nil {
+		return resp, statusCode, nil
+	}
+	defer locks.Release()
 
 	var switchInterfaceProfileResp *aciModels.LeafInterfaceProfile
 	portPatternData := strings.Split(portPattern, "-ports-")
@@ -326,8 +400,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 		// switch profile is not found creating the switch profile
 		leafInterfaceAttributes := aciModels.LeafInterfaceProfileAttributes{
@@ -337,8 +411,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 	}
 	// create access port seletor
@@ -350,8 +424,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 	if err != nil {
 		errMsg := "Error while creating Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	portBlockName := "block-" + portPatternData[1]
 	portBlockAttributes := aciModels.AccessPortBlockAttributes{
@@ -363,19 +437,17 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 	if err != nil {
 		errMsg := "Error while creating Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	// check if vpc port policy is created with name ODIM-PORT-VPCPolicy
-	portVPCPolicyName := "ODIM-PORT-VPCPolicy"
-
 	_, err = aciClient.ReadLACPPolicy(portVPCPolicyName)
 	if err != nil {
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 		// switch profile is not found creating the switch profile
 		lacpPolicyAttributes := aciModels.LACPPolicyAttributes{
@@ -386,8 +458,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 	}
 	// createPCVPC interface policy group
@@ -400,28 +472,27 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 	if err != nil {
 		errMsg := "Error while creating Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	log.Info("Attaching policy group to port selector")
 	err = aciClient.CreateRelationinfraRsAccBaseGrpFromAccessPortSelector(accessPortSelectorResp.BaseAttributes.DistinguishedName, pcVPCPolicyGroupResp.BaseAttributes.DistinguishedName)
 	if err != nil {
 		errMsg := "Error while creating Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 
 	}
 	err = aciClient.CreateRelationinfraRsLacpPolFromPCVPCInterfacePolicyGroup(pcVPCPolicyGroupResp.BaseAttributes.DistinguishedName, portVPCPolicyName)
 	if err != nil {
 		errMsg := "Error while creating Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 
 	}
 	// if leaf profile is created else create the same
-	var switchProfileName = "Switch" + switchPattern + "_Profile"
 	switchPatternData := strings.Split(switchPattern, "-")
 	var switchProfileResp *aciModels.LeafProfile
 	switchProfileResp, err = aciClient.ReadLeafProfile(switchProfileName)
@@ -429,8 +500,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 		// switch profile is not found creating the switch profile
 		leafprofileAttributes := aciModels.LeafProfileAttributes{
@@ -440,8 +511,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 	}
 	// check if switch assoication exist for given switch profile
@@ -454,8 +525,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 		// switch profile is not found creating the switch profile
 		switchAssociationAttributes := aciModels.SwitchAssociationAttributes{
@@ -466,8 +537,8 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 	}
 
@@ -485,16 +556,16 @@ func createPolicyGroup(switchPattern, portPattern string) (interface{}, int, *ca
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 		}
 		// associate switch profile with the switch interface profile
 		err = aciClient.CreateRelationinfraRsAccPortPFromLeafProfile(switchProfileResp.BaseAttributes.DistinguishedName, switchInterfaceProfileResp.BaseAttributes.DistinguishedName)
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest, nil
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode, nil
 
 		}
 
@@ -521,8 +592,8 @@ func createNodeBlock(switchProfileName, switchAssociationName, switchID string,
 		if !strings.Contains(err.Error(), "Object may not exists") {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode
 		}
 		// switch profile is not found creating the switch profile
 		nodeBlockAttributes := aciModels.NodeBlockAttributes{
@@ -534,29 +605,37 @@ func createNodeBlock(switchProfileName, switchAssociationName, switchID string,
 		if err != nil {
 			errMsg := "Error while creating Endpoint: " + err.Error()
 			log.Error(errMsg)
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			return resp, http.StatusBadRequest
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return resp, statusCode
 		}
 	}
 	return nil, http.StatusCreated
 }
 
 func deletePolicyGroup(aciPolicyGroupData *capdata.ACIPolicyGroupData) (interface{}, int) {
+	locks, resp, statusCode := lockACIObjects("delete endpoint policy group",
+		"uni/infra/accportprof-"+aciPolicyGroupData.SwitchProfileSelectorName,
+	)
+	if locks == nil {
+		return resp, statusCode
+	}
+	defer locks.Release()
+
 	aciClient := caputilities.GetConnection()
 
 	err := aciClient.DeleteAccessPortSelector("range", aciPolicyGroupData.AccessPortSelectorName, aciPolicyGroupData.SwitchProfileSelectorName)
 	if err != nil {
 		errMsg := "Error while deleting Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	err = aciClient.DeletePCVPCInterfacePolicyGroup(aciPolicyGroupData.PcVPCPolicyGroupName)
 	if err != nil {
 		errMsg := "Error while deleting  Endpoint: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusOK
 }
@@ -0,0 +1,48 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+
+	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkIfMatch verifies the If-Match header of the request against the entity tag of the stored
+// resource and responds with PreconditionFailed when none of the given tags match, the tags are
+// compared weakly as the resources are served along with their state read from APIC
+func checkIfMatch(ctx iris.Context, uri, etag string) bool {
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	errMsg := fmt.Sprintf("If-Match %s doesn't match the ETag %s of %s", ifMatch, etag, uri)
+	log.Error(errMsg)
+	ctx.StatusCode(http.StatusPreconditionFailed)
+	ctx.JSON(capresponse.NewPreconditionFailed(errMsg))
+	return false
+}
@@ -18,7 +18,6 @@ package caphandler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
@@ -63,33 +62,37 @@ func GetFabricData(ctx iris.Context) {
 		},
 		FabricType: "Ethernet",
 		MaxZones:   800,
-		Status: &model.Status{
-			State:  "Enabled",
-			Health: getFabricHealthData(fabricData.PodID),
-		},
 	}
+	status := getFabricHealthData(fabricData.Pods(), getCachePolicy(ctx))
+	status.State = "Enabled"
 	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(fabricResponse)
+	ctx.JSON(fabricWithStatus{
+		Fabric: fabricResponse,
+		Status: status,
+	})
 }
 
-func getFabricHealthData(podID string) string {
-	fabricHealthResposne, err := caputilities.GetFabricHealth(podID)
-	if err != nil {
-		log.Info("Unable to get fabric health" + err.Error())
-		return ""
-	}
-	log.Info(fabricHealthResposne)
-	data := fabricHealthResposne.IMData[0].FabricHealthData.Attributes
-	currentHealthValue := data["cur"].(string)
-	healthValue, err := strconv.Atoi(currentHealthValue)
-	if err != nil {
-		log.Error("Unable to convert current Health value:" + currentHealthValue + " go the error" + err.Error())
-		return ""
-	}
-	if healthValue > 90 {
-		return "OK"
-	} else if healthValue <= 90 && healthValue < 30 {
-		return "Warning"
+// fabricWithStatus is the fabric response along with the conditions affecting its health
+type fabricWithStatus struct {
+	model.Fabric
+	Status *capmodel.Status `json:"Status,omitempty"`
+}
+
+// getFabricHealthData combines the health of the pods of the fabric, the pods whose health can't be read are left out
+func getFabricHealthData(pods []string, policy caputilities.CachePolicy) *capmodel.Status {
+	var podStatuses []*capmodel.Status
+	for _, podID := range pods {
+		fabricHealthResposne, err := caputilities.GetFabricHealth(podID, policy)
+		if err != nil {
+			log.Info("Unable to get fabric health of pod " + podID + ": " + err.Error())
+			continue
+		}
+		status, err := caputilities.EvaluateHealth(caputilities.HealthResourceFabric, &fabricHealthResposne.Attributes, "topology/pod-"+podID)
+		if err != nil {
+			log.Error("Unable to evaluate fabric health of pod " + podID + ": " + err.Error())
+			continue
+		}
+		podStatuses = append(podStatuses, status)
 	}
-	return "Critical"
+	return caputilities.CombineHealth(podStatuses)
 }
@@ -16,25 +16,42 @@
 package caphandler
 
 import (
+	"fmt"
 	"net/http"
+	"path"
+	"sort"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/ODIM/lib-utilities/response"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
 	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
 	pluginConfig "github.com/ODIM-Project/PluginCiscoACI/config"
 
 	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
 )
 
 // GetManagersCollection Fetches details of the manager collection
 func GetManagersCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	var members = []*model.Link{
 		&model.Link{
 			Oid: "/ODIM/v1/Managers/" + pluginConfig.Data.RootServiceUUID,
 		},
 	}
+	// each APIC controller is a manager along with the plugin
+	controllerIDs, err := capmodel.GetControllerIDs()
+	if err != nil {
+		capresponse.SetErrorResponse(ctx, http.StatusInternalServerError, response.InternalError, err.Error(), nil)
+		return
+	}
+	sort.Strings(controllerIDs)
+	for _, controllerID := range controllerIDs {
+		members = append(members, &model.Link{
+			Oid: "/ODIM/v1/Managers/" + controllerID,
+		})
+	}
 
 	managers := model.Collection{
 		ODataContext: "/ODIM/v1/$metadata#ManagerCollection.ManagerCollection",
@@ -45,28 +62,44 @@ func GetManagersCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(managers)
-	return
-
+	writeCollection(ctx, managers, func(oid string) (interface{}, error) {
+		if managerID := path.Base(oid); managerID != pluginConfig.Data.RootServiceUUID {
+			return getControllerResponse(managerID)
+		}
+		return getManagerResponse(oid)
+	})
 }
 
 // GetManagersInfo Fetches details of the given manager info
 func GetManagersInfo(ctx iris.Context) {
 	uri := ctx.Request().RequestURI
-	// Get all switch data uri
-	managedSwitches := []model.Link{}
-	allFabric, err := capmodel.GetAllFabric("")
+	if managerID := ctx.Params().Get("id"); managerID != "" && managerID != pluginConfig.Data.RootServiceUUID {
+		controller, err := getControllerResponse(managerID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to fetch manager data for uri %s: %s", uri, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{"Manager", managerID})
+			return
+		}
+		ctx.StatusCode(http.StatusOK)
+		ctx.JSON(controller)
+		return
+	}
+	managers, err := getManagerResponse(uri)
 	if err != nil {
 		capresponse.SetErrorResponse(ctx, http.StatusInternalServerError, response.InternalError, err.Error(), nil)
 		return
 	}
-	for fabricID, fabricData := range allFabric {
-		for i := 0; i < len(fabricData.SwitchData); i++ {
-			managedSwitches = append(managedSwitches, model.Link{
-				Oid: "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + fabricData.SwitchData[i],
-			})
-		}
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(managers)
+	return
+
+}
+
+// getManagerResponse builds the plugin manager resource which manages all the switches
+func getManagerResponse(uri string) (*model.Manager, error) {
+	managedSwitches, err := getManagedSwitches()
+	if err != nil {
+		return nil, err
 	}
 
 	managers := model.Manager{
@@ -87,12 +120,99 @@ func GetManagersInfo(ctx iris.Context) {
 			ManagerForSwitchesCount: len(managedSwitches),
 		},
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(managers)
-	return
+	return &managers, nil
+}
 
+// controllerWithOem is the manager of an APIC controller along with its ACI properties
+type controllerWithOem struct {
+	model.Manager
+	Oem *capmodel.ControllerOem `json:"Oem,omitempty"`
 }
 
-func getInfoFromDevice(uri string, deviceDetails capmodel.Device, ctx iris.Context) {
-	// TODO: implementation pending
+// getControllerResponse builds the manager resource of the APIC controller,
+// the stored details are refreshed with the system and cluster state read from the controller
+func getControllerResponse(controllerID string) (*controllerWithOem, error) {
+	controller, err := capmodel.GetController(controllerID)
+	if err != nil {
+		return nil, err
+	}
+	managedSwitches, err := getManagedSwitches()
+	if err != nil {
+		return nil, err
+	}
+	manager := controllerWithOem{
+		Manager: *controller.Manager,
+		Oem: &capmodel.ControllerOem{
+			Cisco: &capmodel.CiscoControllerOem{
+				PodID:  controller.PodID,
+				NodeID: controller.NodeID,
+			},
+		},
+	}
+	manager.Links = &model.ManagerLinks{
+		ManagerForSwitches:      managedSwitches,
+		ManagerForSwitchesCount: len(managedSwitches),
+	}
+
+	// the controller may be unreachable, its stored details are returned then
+	system, err := caputilities.GetControllerSystem(controller.PodID, controller.NodeID)
+	if err != nil {
+		log.Error("Unable to get the system details of controller " + controllerID + ": " + err.Error())
+	} else {
+		oem := manager.Oem.Cisco
+		if system.Version != "" {
+			manager.FirmwareVersion = system.Version
+		}
+		if system.Serial != "" {
+			manager.SerialNumber = system.Serial
+		}
+		manager.LastResetTime = system.LastRebootTime
+		manager.DateTime = system.CurrentTime
+		oem.Address = system.Address
+		oem.InBandManagementAddress = system.InbMgmtAddr
+		oem.OutOfBandManagementAddress = system.OobMgmtAddr
+		oem.UpTime = system.SystemUpTime
+	}
+	members, err := caputilities.GetClusterNodes(controller.PodID, controller.NodeID)
+	if err != nil {
+		log.Error("Unable to get the cluster state seen by controller " + controllerID + ": " + err.Error())
+	} else if len(members) > 0 {
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].DN < members[j].DN
+		})
+		for _, member := range members {
+			manager.Oem.Cisco.ClusterMembers = append(manager.Oem.Cisco.ClusterMembers, capmodel.ClusterMember{
+				NodeID:           member.ID,
+				Name:             member.NodeName,
+				Address:          member.Addr,
+				Health:           member.Health,
+				OperationalState: member.OperSt,
+				AdminState:       member.AdminSt,
+			})
+		}
+		status := model.Status{}
+		if manager.Status != nil {
+			status = *manager.Status
+		}
+		status.Health = caputilities.ClusterHealth(members)
+		manager.Status = &status
+	}
+	return &manager, nil
+}
+
+// getManagedSwitches returns the links of all the switches of the fabrics
+func getManagedSwitches() ([]model.Link, error) {
+	managedSwitches := []model.Link{}
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		return nil, err
+	}
+	for fabricID, fabricData := range allFabric {
+		for i := 0; i < len(fabricData.SwitchData); i++ {
+			managedSwitches = append(managedSwitches, model.Link{
+				Oid: "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + fabricData.SwitchData[i],
+			})
+		}
+	}
+	return managedSwitches, nil
 }
@@ -17,10 +17,15 @@ package caphandler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
 	"github.com/ODIM-Project/PluginCiscoACI/capmessagebus"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
 	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
@@ -28,11 +33,15 @@ import (
 	"github.com/ODIM-Project/PluginCiscoACI/config"
 	pluginConfig "github.com/ODIM-Project/PluginCiscoACI/config"
 	"github.com/ODIM-Project/PluginCiscoACI/constants"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
 	iris "github.com/kataras/iris/v12"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 )
 
+// publishEvent sends the events to ODIM through the message bus
+var publishEvent = capmessagebus.Publish
+
 // GetPluginStatus defines the GetPluginStatus iris handler.
 // and returns status
 func GetPluginStatus(ctx iris.Context) {
@@ -69,50 +78,204 @@ func GetPluginStatus(ctx iris.Context) {
 		})
 	}
 	resp.EventMessageBus.EmbQueue = messageQueueInfo
+	resp.Locks = getLocksStatus()
+	resp.Leadership = getLeadershipStatus()
 
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(resp)
-	if capmodel.PluginIntialStatus == false {
-		go publishFabricAddedEvent()
-		capmodel.PluginIntialStatus = true
-	}
-
 }
 
-// GetPluginStartup ...
+// GetPluginStartup reconciles the plugin with the startup data ODIM sends after it or the plugin restarted,
+// the fabrics ODIM doesn't know about are announced again and the event subscriptions ODIM made are made again.
+// The reconciliation is asked by ODIM, so it is done by whichever instance receives the request
 func GetPluginStartup(ctx iris.Context) {
-	// TODO: implementation pending
-	ctx.StatusCode(http.StatusNotImplemented)
+	var startUpData capmodel.PluginStartUpData
+	if err := ctx.ReadJSON(&startUpData); err != nil {
+		errMsg := "error while trying to get JSON body from the request: " + err.Error()
+		log.Error(errMsg)
+		resp := updateErrorResponse(response.MalformedJSON, errMsg, nil)
+		ctx.StatusCode(http.StatusBadRequest)
+		ctx.JSON(resp)
+		return
+	}
+	requestType := strings.ToLower(startUpData.RequestType)
+	if requestType != "full" && requestType != "delta" {
+		errMsg := "RequestType " + startUpData.RequestType + " of the startup data is neither full nor delta"
+		log.Error(errMsg)
+		resp := updateErrorResponse(response.PropertyValueNotInList, errMsg, []interface{}{startUpData.RequestType, "RequestType"})
+		ctx.StatusCode(http.StatusBadRequest)
+		ctx.JSON(resp)
+		return
+	}
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		errMsg := "failed to fetch fabric data: " + err.Error()
+		createDbErrResp(ctx, err, errMsg, nil)
+		return
+	}
+
+	// ODIM may identify the fabrics by their URI
+	devices := make(map[string]capmodel.DeviceData, len(startUpData.Devices))
+	for deviceID, device := range startUpData.Devices {
+		devices[strings.TrimPrefix(deviceID, "/ODIM/v1/Fabrics/")] = device
+	}
+	resp := capresponse.PluginStartupResponse{
+		RequestType:           startUpData.RequestType,
+		AddedFabrics:          []string{},
+		UnknownDevices:        []string{},
+		ResyncedSubscriptions: []string{},
+	}
+	// a delta only holds the devices which changed, the fabrics missing from it are still known to ODIM
+	if requestType == "full" {
+		for _, fabricID := range sortedKeys(allFabric) {
+			if _, ok := devices[fabricID]; ok {
+				continue
+			}
+			if err := publishFabricAddedEvent(fabricID); err != nil {
+				log.Error("failed to publish the added event of fabric " + fabricID + ": " + err.Error())
+				continue
+			}
+			resp.AddedFabrics = append(resp.AddedFabrics, fabricID)
+		}
+	}
+	for _, deviceID := range sortedKeys(devices) {
+		device := devices[deviceID]
+		if _, ok := allFabric[deviceID]; !ok && device.Address == "" {
+			resp.UnknownDevices = append(resp.UnknownDevices, deviceID)
+			continue
+		}
+		// the subscriptions ODIM still has are made again only when it asks for it
+		subscription := device.EventSubscriptionInfo
+		if device.Address == "" || device.Operation == "del" || subscription == nil ||
+			(subscription.Location != "" && !startUpData.ResyncEvtSubscription) {
+			continue
+		}
+		if err := resyncEventSubscription(device); err != nil {
+			log.Error("failed to subscribe for the events of device " + deviceID + ": " + err.Error())
+			if resp.FailedSubscriptions == nil {
+				resp.FailedSubscriptions = make(map[string]string)
+			}
+			resp.FailedSubscriptions[deviceID] = err.Error()
+			continue
+		}
+		resp.ResyncedSubscriptions = append(resp.ResyncedSubscriptions, deviceID)
+	}
+	log.Info(fmt.Sprintf("reconciled with the %s startup data of ODIM: %d fabrics added, %d event subscriptions made again",
+		requestType, len(resp.AddedFabrics), len(resp.ResyncedSubscriptions)))
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(resp)
 }
 
-func publishFabricAddedEvent() {
-	time.Sleep(5 * time.Second)
-	// Send resource added event odim
-	allFabric, err := capmodel.GetAllFabric("")
+// resyncEventSubscription makes again the event subscription ODIM made on the device
+func resyncEventSubscription(device capmodel.DeviceData) error {
+	redfishDevice := &caputilities.RedfishDevice{
+		Host:     device.Address,
+		Username: device.UserName,
+		Password: string(device.Password),
+	}
+	resp, err := subscribeForEvents(redfishDevice, capmodel.EvtSubPost{
+		EventTypes: device.EventSubscriptionInfo.EventTypes,
+		Context:    "ODIMRA_Event",
+		Protocol:   "Redfish",
+	})
 	if err != nil {
-		log.Fatal("while fetching all stored fabric data got: " + err.Error())
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the device responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sortedKeys[T any](data map[string]T) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
 	}
-	for fabricID := range allFabric {
-		var event = common.Event{
+	sort.Strings(keys)
+	return keys
+}
+
+// publishFabricAddedEvent sends the ResourceAdded event of the fabric to ODIM
+func publishFabricAddedEvent(fabricID string) error {
+	return PublishEvents("Fabric added event", []common.Event{
+		{
 			EventID:   uuid.NewV4().String(),
 			MessageID: constants.ResourceCreatedMessageID,
 			EventType: "ResourceAdded",
 			OriginOfCondition: &common.Link{
 				Oid: "/ODIM/v1/Fabrics/" + fabricID,
 			},
+		},
+	})
+}
+
+// PublishEvents sends the events to ODIM in a single message with the given name
+func PublishEvents(name string, events []common.Event) error {
+	var messageData = common.MessageData{
+		Name:      name,
+		Context:   "/redfish/v1/$metadata#Event.Event",
+		OdataType: constants.EventODataType,
+		Events:    events,
+	}
+	data, err := json.Marshal(messageData)
+	if err != nil {
+		return err
+	}
+	eventData := common.Events{
+		IP:      config.Data.LoadBalancerConf.Host,
+		Request: data,
+	}
+	if !publishEvent(eventData) {
+		return errors.New("the event couldn't be published on the message bus")
+	}
+	return nil
+}
+
+// getLocksStatus returns the lock diagnostics, the held locks are left out when they can't be read from the DB
+func getLocksStatus() capresponse.Locks {
+	stats := capmodel.GetLockStats()
+	status := capresponse.Locks{
+		Acquired:  stats.Acquired,
+		Contended: stats.Contended,
+		TimedOut:  stats.TimedOut,
+		Expired:   stats.Expired,
+	}
+	locks, err := capmodel.GetAllLocks()
+	if err != nil {
+		log.Error("failed to fetch the held locks: " + err.Error())
+		return status
+	}
+	for _, lock := range locks {
+		status.HeldLocks = append(status.HeldLocks, capresponse.HeldLock{
+			Name:       lock.Name,
+			Holder:     lock.Holder,
+			AcquiredAt: lock.AcquiredAt.Format(time.RFC3339),
+			ExpiresAt:  lock.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return status
+}
+
+// getLeadershipStatus returns the role of this instance and the current leader, the leader is left out
+// when there is none or it can't be read from the DB
+func getLeadershipStatus() capresponse.Leadership {
+	status := capresponse.Leadership{
+		Instance: capmodel.InstanceID,
+		Role:     "Follower",
+	}
+	if caputilities.IsLeader() {
+		status.Role = "Leader"
+	}
+	leader, err := capmodel.GetLeader()
+	if err != nil {
+		if !errors.Is(err, db.ErrorKeyNotFound) {
+			log.Error("failed to fetch the leader: " + err.Error())
 		}
-		var events = []common.Event{event}
-		var messageData = common.MessageData{
-			Name:      "Fabric added event",
-			Context:   "/redfish/v1/$metadata#Event.Event",
-			OdataType: constants.EventODataType,
-			Events:    events,
-		}
-		data, _ := json.Marshal(messageData)
-		eventData := common.Events{
-			IP:      config.Data.LoadBalancerConf.Host,
-			Request: data,
-		}
-		capmessagebus.Publish(eventData)
+		return status
 	}
+	status.Leader = leader.Instance
+	status.ElectedAt = leader.ElectedAt.Format(time.RFC3339)
+	return status
 }
@@ -0,0 +1,96 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/capmessagebus"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+
+	iris "github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestGetPluginStartup(t *testing.T) {
+	config.SetUpMockConfig(t)
+	db.Connector = db.NewInMemoryConnector()
+	var published []string
+	publishEvent = func(data interface{}) bool {
+		var message common.MessageData
+		json.Unmarshal(data.(common.Events).Request, &message)
+		for _, event := range message.Events {
+			published = append(published, event.EventType+" "+event.OriginOfCondition.Oid)
+		}
+		return true
+	}
+	defer func() {
+		db.Connector = db.MockConnector{}
+		publishEvent = capmessagebus.Publish
+	}()
+	for _, fabricID := range []string{"uuid:1", "uuid:2"} {
+		if err := capmodel.SaveFabric(fabricID, &capdata.Fabric{PodID: fabricID[5:]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mockApp := iris.New()
+	mockApp.Post("/ODIM/v1/Startup", GetPluginStartup)
+	e := httptest.New(t, mockApp)
+
+	e.POST("/ODIM/v1/Startup").WithJSON(map[string]interface{}{"RequestType": "none"}).Expect().Status(http.StatusBadRequest)
+
+	startUpData := capmodel.PluginStartUpData{
+		RequestType:           "full",
+		ResyncEvtSubscription: true,
+		Devices: map[string]capmodel.DeviceData{
+			"/ODIM/v1/Fabrics/uuid:1": {},
+			"uuid:3":                  {},
+			"device1": {
+				Address:               "127.0.0.1:1",
+				EventSubscriptionInfo: &capmodel.EventSubscriptionInfo{EventTypes: []string{"Alert"}, Location: "/subscription"},
+			},
+			"device2": {
+				Address:               "127.0.0.1:1",
+				Operation:             "del",
+				EventSubscriptionInfo: &capmodel.EventSubscriptionInfo{EventTypes: []string{"Alert"}},
+			},
+		},
+	}
+	resp := e.POST("/ODIM/v1/Startup").WithJSON(startUpData).Expect().Status(http.StatusOK).JSON().Object()
+	resp.Value("AddedFabrics").Array().ContainsOnly("uuid:2")
+	resp.Value("UnknownDevices").Array().ContainsOnly("uuid:3")
+	resp.Value("ResyncedSubscriptions").Array().Empty()
+	resp.Value("FailedSubscriptions").Object().Keys().ContainsOnly("device1")
+	if len(published) != 1 || published[0] != "ResourceAdded /ODIM/v1/Fabrics/uuid:2" {
+		t.Errorf("published events %v, want only the ResourceAdded of the fabric unknown to ODIM", published)
+	}
+
+	// a delta doesn't list all the fabrics known to ODIM
+	published = nil
+	startUpData.RequestType = "delta"
+	startUpData.Devices = map[string]capmodel.DeviceData{}
+	resp = e.POST("/ODIM/v1/Startup").WithJSON(startUpData).Expect().Status(http.StatusOK).JSON().Object()
+	resp.Value("AddedFabrics").Array().Empty()
+	if len(published) != 0 {
+		t.Errorf("published events %v for a delta startup data", published)
+	}
+}
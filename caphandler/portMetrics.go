@@ -0,0 +1,127 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetPortMetrics responds with the metrics of the port read from its interface counters on APIC
+func GetPortMetrics(ctx iris.Context) {
+	uri := ctx.Request().URL.Path
+	portURI := strings.TrimSuffix(uri, "/Metrics")
+	switchID := ctx.Params().Get("switchID")
+	fabricID := ctx.Params().Get("id")
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch port metrics for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
+		return
+	}
+	portData, err := capmodel.GetPort(portURI)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch port metrics for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Port", portURI})
+		return
+	}
+	switchIDData := strings.Split(switchID, ":")
+	stats, err := caputilities.GetPortStats(fabricData.SwitchPod(switchID), switchIDData[1], portData.PortID, getCachePolicy(ctx))
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to read the counters of port %s from APIC: %s", portURI, err.Error())
+		log.Error(errMsg)
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusInternalServerError)
+		ctx.StatusCode(statusCode)
+		ctx.JSON(resp)
+		return
+	}
+	metrics := getPortMetrics(stats)
+	metrics.ODataID = uri
+	metrics.ID = "Metrics"
+	metrics.Name = "Metrics of " + portData.Name
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(metrics)
+}
+
+// getPortMetrics maps the counters of the port to the Redfish PortMetrics, the frames are the sum
+// of the unicast, multicast and broadcast packets
+func getPortMetrics(stats *capmodel.PortStats) *capmodel.PortMetrics {
+	metrics := &capmodel.PortMetrics{
+		ODataContext: "/ODIM/v1/$metadata#PortMetrics.PortMetrics",
+		ODataType:    "#PortMetrics.v1_1_0.PortMetrics",
+		Description:  "Counters of the port read from APIC",
+		Networking:   &capmodel.PortMetricsNetworking{},
+	}
+	if in := stats.IfIn; in != nil {
+		metrics.RXBytes = parseCounter(in.Octets)
+		metrics.RXErrors = parseCounter(in.Errors)
+		metrics.Networking.RXDiscards = parseCounter(in.Discards)
+		metrics.Networking.RXUnicastFrames = parseCounter(in.UcastPkts)
+		metrics.Networking.RXMulticastFrames = parseCounter(in.MulticastPkts)
+		metrics.Networking.RXBroadcastFrames = parseCounter(in.BroadcastPkts)
+		metrics.Networking.RXFrames = sumCounters(in.UcastPkts, in.MulticastPkts, in.BroadcastPkts)
+	}
+	if out := stats.IfOut; out != nil {
+		metrics.TXBytes = parseCounter(out.Octets)
+		metrics.TXErrors = parseCounter(out.Errors)
+		metrics.Networking.TXDiscards = parseCounter(out.Discards)
+		metrics.Networking.TXUnicastFrames = parseCounter(out.UcastPkts)
+		metrics.Networking.TXMulticastFrames = parseCounter(out.MulticastPkts)
+		metrics.Networking.TXBroadcastFrames = parseCounter(out.BroadcastPkts)
+		metrics.Networking.TXFrames = sumCounters(out.UcastPkts, out.MulticastPkts, out.BroadcastPkts)
+	}
+	if stats.EtherStats != nil {
+		metrics.Networking.RXCRCErrors = parseCounter(stats.EtherStats.CRCAlignErrors)
+	}
+	if stats.Ingress != nil || stats.Egress != nil {
+		utilization := &capmodel.CiscoPortMetricsOem{}
+		if stats.Ingress != nil {
+			utilization.RXUtilizationPercent = parseUtilization(stats.Ingress.UtilAvg)
+		}
+		if stats.Egress != nil {
+			utilization.TXUtilizationPercent = parseUtilization(stats.Egress.UtilAvg)
+		}
+		metrics.Oem = &capmodel.PortMetricsOem{Cisco: utilization}
+	}
+	return metrics
+}
+
+// parseCounter parses the counter validated as an unsigned 64 bit integer
+func parseCounter(counter string) *uint64 {
+	value, _ := strconv.ParseUint(counter, 10, 64)
+	return &value
+}
+
+func sumCounters(counters ...string) *uint64 {
+	var sum uint64
+	for _, counter := range counters {
+		sum += *parseCounter(counter)
+	}
+	return &sum
+}
+
+// parseUtilization parses the utilization validated as a number
+func parseUtilization(utilization string) *float64 {
+	value, _ := strconv.ParseFloat(utilization, 64)
+	return &value
+}
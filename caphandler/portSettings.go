@@ -0,0 +1,404 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	aciModels "github.com/ciscoecosystem/aci-go-client/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// aciLinkSpeeds are the speeds of the link level policies, a requested speed is checked against
+// them when APIC doesn't give the speeds supported by the port
+var aciLinkSpeeds = []string{"100M", "1G", "10G", "25G", "40G", "50G", "100G", "200G", "400G"}
+
+// the MTU range of the L2 MTU policies
+const (
+	minPortMTU = 576
+	maxPortMTU = 9216
+)
+
+// portLinkSettings are the speed and autonegotiation of the link level policy of a port,
+// speedGbps is 0 when the port keeps the speed of its transceiver
+type portLinkSettings struct {
+	speedGbps float64
+	autoNeg   bool
+}
+
+// defaultPortLinkSettings are the link settings of the ports which were never configured,
+// they keep the speed of their transceiver and autonegotiate it
+var defaultPortLinkSettings = portLinkSettings{autoNeg: true}
+
+// defaultPortMTU is the MTU of the ports when APIC has no default MTU policy
+const defaultPortMTU = 9000
+
+// portBasePolicies are the AEP and the interface policies the port takes from the policy group of its access
+// port selector, they are kept by the policy group of the port which overrides only the speed and the MTU
+type portBasePolicies struct {
+	policyGroupDN string
+	relations     []*capmodel.ACIRelation
+	mtu           int
+}
+
+// checkPortConfiguration checks that the MTU and the speed of the PATCH request are supported by the port and
+// that the interface policies of the port can be overridden when they are changed, nothing is changed on APIC.
+// The policies the port takes from its access port selector are returned when the request changes them.
+// The error response is returned along with its status code when the settings can't be applied
+func checkPortConfiguration(podID, switchID string, p *model.Port, patch *portPatchRequest) (*portBasePolicies, interface{}, int) {
+	nodeID := strings.Split(switchID, ":")[1]
+	// a speed of 0 makes the port keep the speed of its transceiver
+	if speed := patch.configuredSpeed(); speed != nil && *speed != 0 {
+		if resp, statusCode := checkPortSpeed(podID, nodeID, p, *speed); resp != nil {
+			return nil, resp, statusCode
+		}
+	}
+	if patch.MaxFrameSize != nil && (*patch.MaxFrameSize < minPortMTU || *patch.MaxFrameSize > maxPortMTU) {
+		errMsg := fmt.Sprintf("MaxFrameSize %d of port %s is not in the range %d to %d", *patch.MaxFrameSize, p.ODataID, minPortMTU, maxPortMTU)
+		log.Error(errMsg)
+		return nil, updateErrorResponse(response.PropertyValueNotInList, errMsg, []interface{}{strconv.Itoa(*patch.MaxFrameSize), "MaxFrameSize"}), http.StatusBadRequest
+	}
+	if _, linkChanged := patchedLinkSettings(p, patch); !linkChanged && patch.MaxFrameSize == nil {
+		return nil, nil, http.StatusOK
+	}
+	if resp, statusCode := checkPortPoliciesConfigurable(switchID, p); resp != nil {
+		return nil, resp, statusCode
+	}
+	return getPortBasePolicies(nodeID, p)
+}
+
+// configurePort applies the MTU, the speed, the autonegotiation and the description of the PATCH request
+// to the port through an interface override of the port on APIC, the request is checked beforehand with
+// checkPortConfiguration which gives the base policies of the port when the request changes them.
+// The policy group of the port is related to the override only while the speed or the MTU differ from
+// the ones of the base policies, and the override is deleted once the port has no description either.
+// The port is left unchanged, the applied settings are stored with applyPortSettings.
+// The error response is returned along with its status code when the settings couldn't be applied
+func configurePort(podID, switchID string, p *model.Port, patch *portPatchRequest, base *portBasePolicies) (interface{}, int) {
+	nodeID := strings.Split(switchID, ":")[1]
+	link, _ := patchedLinkSettings(p, patch)
+	mtu := p.MaxFrameSize
+	if patch.MaxFrameSize != nil {
+		mtu = *patch.MaxFrameSize
+	}
+	description := p.Description
+	if patch.Description != nil {
+		description = *patch.Description
+	}
+	override := capmodel.NewInterfaceOverride(podID, nodeID, p.PortID)
+	override.Description = &description
+	policyGroupName := capmodel.PortPolicyName(podID, nodeID, p.PortID)
+	policyGroupDN := capmodel.PortPolicyGroupDN(policyGroupName)
+	locks, resp, statusCode := lockACIObjects("configure port", override.DN(), policyGroupDN)
+	if locks == nil {
+		return resp, statusCode
+	}
+	defer locks.Release()
+	var overridesPolicies bool
+	if base != nil {
+		linkOverridden := link != defaultPortLinkSettings
+		var mtuOverride *int
+		if mtu != base.mtu {
+			mtuOverride = &mtu
+		}
+		overridesPolicies = linkOverridden || mtuOverride != nil
+		if overridesPolicies {
+			if err := savePortPolicyGroup(policyGroupName, base, link, linkOverridden, mtuOverride); err != nil {
+				errMsg := fmt.Sprintf("failed to configure the interface policies of port %s on APIC: %s", p.ODataID, err.Error())
+				log.Error(errMsg)
+				return createACIErrResp(err, errMsg, http.StatusBadRequest)
+			}
+		}
+	} else {
+		// the policies of the port are left as they are
+		groupDN, err := caputilities.GetInterfaceOverrideGroup(podID, nodeID, p.PortID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to read the interface override of port %s from APIC: %s", p.ODataID, err.Error())
+			log.Error(errMsg)
+			return createACIErrResp(err, errMsg, http.StatusBadRequest)
+		}
+		overridesPolicies = groupDN != ""
+	}
+	if overridesPolicies {
+		override.PolicyGroupName = policyGroupName
+	}
+	if err := saveInterfaceOverride(override, overridesPolicies || description != ""); err != nil {
+		errMsg := fmt.Sprintf("failed to configure the interface override of port %s on APIC: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		return createACIErrResp(err, errMsg, http.StatusBadRequest)
+	}
+	if base != nil && !overridesPolicies {
+		// the settings are back to the ones of the base policies, the policy group is no longer used
+		if err := caputilities.GetConnection().DeleteByDn(policyGroupDN, capmodel.PortPolicyGroupClass); err != nil {
+			errMsg := fmt.Sprintf("failed to delete the policy group of port %s on APIC: %s", p.ODataID, err.Error())
+			log.Error(errMsg)
+			return createACIErrResp(err, errMsg, http.StatusBadRequest)
+		}
+	}
+	return nil, http.StatusOK
+}
+
+// applyPortSettings sets the MTU, the link configuration and the description of the PATCH request
+// applied by configurePort to the port
+func applyPortSettings(p *model.Port, patch *portPatchRequest) {
+	if link, linkChanged := patchedLinkSettings(p, patch); linkChanged {
+		setPortLinkSettings(p, link)
+	}
+	if patch.MaxFrameSize != nil {
+		p.MaxFrameSize = *patch.MaxFrameSize
+	}
+	if patch.Description != nil {
+		p.Description = *patch.Description
+	}
+}
+
+// patchedLinkSettings returns the link settings of the port with the speed and the autonegotiation
+// of the PATCH request, linkChanged tells if the request sets any of them
+func patchedLinkSettings(p *model.Port, patch *portPatchRequest) (link portLinkSettings, linkChanged bool) {
+	link = getPortLinkSettings(p)
+	if speed := patch.configuredSpeed(); speed != nil {
+		link.speedGbps = *speed
+		linkChanged = true
+	}
+	if autoNeg := patch.autoSpeedNegotiation(); autoNeg != nil {
+		link.autoNeg = *autoNeg
+		linkChanged = true
+	}
+	return link, linkChanged
+}
+
+// checkPortSpeed checks that the requested speed is one the port supports
+func checkPortSpeed(podID, nodeID string, p *model.Port, speedGbps float64) (interface{}, int) {
+	supportedSpeeds := aciLinkSpeeds
+	transceiver, err := caputilities.GetPortTransceiver(podID, nodeID, p.PortID, caputilities.UseCache)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to read the speeds supported by port %s from APIC: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		return createACIErrResp(err, errMsg, http.StatusBadRequest)
+	}
+	if len(transceiver.SupportedSpeeds) > 0 {
+		supportedSpeeds = transceiver.SupportedSpeeds
+	}
+	speed := aciLinkSpeed(speedGbps)
+	for _, supportedSpeed := range supportedSpeeds {
+		if speed == supportedSpeed {
+			return nil, http.StatusOK
+		}
+	}
+	errMsg := fmt.Sprintf("speed %s of port %s is not one of the supported speeds %s", speed, p.ODataID, strings.Join(supportedSpeeds, ", "))
+	log.Error(errMsg)
+	return updateErrorResponse(response.PropertyValueNotInList, errMsg, []interface{}{strconv.FormatFloat(speedGbps, 'f', -1, 64), "ConfiguredLinkSpeedGbps"}), http.StatusBadRequest
+}
+
+// checkPortPoliciesConfigurable checks that the interface policies of the port can be overridden,
+// the fabric uplinks are not governed by the interface policies and overriding the policies
+// of the ports of an endpoint would take them out of the port channel of the endpoint
+func checkPortPoliciesConfigurable(switchID string, p *model.Port) (interface{}, int) {
+	uplink, resp, statusCode := isFabricUplink(switchID, p)
+	if resp != nil {
+		return resp, statusCode
+	}
+	if uplink {
+		errMsg := fmt.Sprintf("port %s is a fabric uplink, its speed and MTU are not configurable", p.ODataID)
+		log.Error(errMsg)
+		return updateErrorResponse(response.ResourceInUse, errMsg, nil), http.StatusConflict
+	}
+	if statusCode, resp := checkEndpointPortMapping(p.ODataID); statusCode == http.StatusConflict {
+		errMsg := fmt.Sprintf("port %s belongs to an endpoint, its speed and MTU are given by the policy group of the endpoint", p.ODataID)
+		log.Error(errMsg)
+		return updateErrorResponse(response.ResourceInUse, errMsg, nil), http.StatusConflict
+	} else if resp != nil {
+		return resp, statusCode
+	}
+	return nil, http.StatusOK
+}
+
+// getPortBasePolicies reads the policies the port takes from its access port selector, the ports of
+// the port channels are not configurable as overriding their policy group takes them out of the port channel
+func getPortBasePolicies(nodeID string, p *model.Port) (*portBasePolicies, interface{}, int) {
+	base := &portBasePolicies{}
+	var err error
+	if base.policyGroupDN, err = caputilities.GetPortBasePolicyGroup(nodeID, p.PortID); err != nil {
+		errMsg := fmt.Sprintf("failed to read the policy group of port %s from APIC: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return nil, resp, statusCode
+	}
+	if capmodel.IsBundlePolicyGroup(base.policyGroupDN) {
+		errMsg := fmt.Sprintf("port %s belongs to the port channel of policy group %s, its speed and MTU are given by the policy group", p.ODataID, base.policyGroupDN)
+		log.Error(errMsg)
+		return nil, updateErrorResponse(response.ResourceInUse, errMsg, nil), http.StatusConflict
+	}
+	mtuPolicyName := capmodel.DefaultMTUPolicyName
+	if base.policyGroupDN != "" {
+		if base.relations, err = caputilities.GetPolicyGroupRelations(base.policyGroupDN); err != nil {
+			errMsg := fmt.Sprintf("failed to read the interface policies of port %s from APIC: %s", p.ODataID, err.Error())
+			log.Error(errMsg)
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			return nil, resp, statusCode
+		}
+		for _, relation := range base.relations {
+			if relation.ClassName == capmodel.MTUPolicyRelationClass && relation.Target != "" {
+				mtuPolicyName = relation.Target
+			}
+		}
+	}
+	base.mtu, err = caputilities.GetMTUPolicy(mtuPolicyName)
+	if errors.Is(err, capmodel.ErrorACIObjectNotFound) {
+		base.mtu, err = defaultPortMTU, nil
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to read the MTU policy of port %s from APIC: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return nil, resp, statusCode
+	}
+	return base, nil, http.StatusOK
+}
+
+// savePortPolicyGroup creates the access port policy group of the port with the AEP and the interface policies of
+// the base policies of the port, the link level policy and the MTU policy are overridden when they are given.
+// The policies are shared by the ports having the same settings
+func savePortPolicyGroup(name string, base *portBasePolicies, link portLinkSettings, linkOverridden bool, mtu *int) error {
+	aciClient := caputilities.GetConnection()
+	policyGroup, err := aciClient.CreateLeafAccessPortPolicyGroup(name, "", aciModels.LeafAccessPortPolicyGroupAttributes{Name: name})
+	if err != nil {
+		return err
+	}
+	policyGroupDN := policyGroup.BaseAttributes.DistinguishedName
+	baseClasses := make(map[string]bool)
+	for _, relation := range base.relations {
+		if (linkOverridden && relation.ClassName == capmodel.LinkLevelPolicyRelationClass) ||
+			(mtu != nil && relation.ClassName == capmodel.MTUPolicyRelationClass) {
+			continue
+		}
+		if err := aciClient.Save(capmodel.RebaseRelation(relation, base.policyGroupDN, policyGroupDN)); err != nil {
+			return err
+		}
+		baseClasses[relation.ClassName] = true
+	}
+	if linkOverridden {
+		autoNeg := capmodel.LinkLevelPolicyAutoNegOff
+		if link.autoNeg {
+			autoNeg = capmodel.LinkLevelPolicyAutoNegOn
+		}
+		speed := capmodel.LinkLevelPolicyInheritSpeed
+		if link.speedGbps > 0 {
+			speed = aciLinkSpeed(link.speedGbps)
+		}
+		linkLevelPolicyName := capmodel.LinkLevelPolicyName(speed, autoNeg)
+		if _, err := aciClient.CreateLinkLevelPolicy(linkLevelPolicyName, "", aciModels.LinkLevelPolicyAttributes{
+			Name:    linkLevelPolicyName,
+			Speed:   speed,
+			AutoNeg: autoNeg,
+		}); err != nil {
+			return err
+		}
+		if err := aciClient.CreateRelationinfraRsHIfPolFromLeafAccessPortPolicyGroup(policyGroupDN, linkLevelPolicyName); err != nil {
+			return err
+		}
+	} else if !baseClasses[capmodel.LinkLevelPolicyRelationClass] {
+		// the link level policy overridden before is dropped for the default one
+		if err := aciClient.DeleteByDn(policyGroupDN+"/rshIfPol", capmodel.LinkLevelPolicyRelationClass); err != nil {
+			return err
+		}
+	}
+	if mtu != nil {
+		mtuPolicy := capmodel.NewMTUPolicy(*mtu)
+		if err := aciClient.Save(mtuPolicy); err != nil {
+			return err
+		}
+		// the relation is posted as it is, the client gives the name of the policy as its tDn
+		if err := aciClient.Save(mtuPolicy.Relation(policyGroupDN)); err != nil {
+			return err
+		}
+	} else if !baseClasses[capmodel.MTUPolicyRelationClass] {
+		if err := aciClient.DeleteByDn(policyGroupDN+"/rsl2InstPol", capmodel.MTUPolicyRelationClass); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveInterfaceOverride creates or updates the interface override of the port along with its relations,
+// the override is deleted when it isn't kept
+func saveInterfaceOverride(override *capmodel.InterfaceOverride, keep bool) error {
+	aciClient := caputilities.GetConnection()
+	if !keep {
+		return aciClient.DeleteByDn(override.DN(), capmodel.InterfaceOverrideClass)
+	}
+	if err := aciClient.Save(override); err != nil {
+		return err
+	}
+	for _, relation := range override.Relations() {
+		if err := aciClient.Save(relation); err != nil {
+			return err
+		}
+	}
+	if override.PolicyGroupName == "" {
+		// the port takes back the policy group of its access port selector
+		return aciClient.DeleteByDn(override.GroupRelationDN(), capmodel.InterfaceOverrideGroupClass)
+	}
+	return nil
+}
+
+// getPortLinkSettings returns the link settings last applied to the port, the ports which
+// were never configured keep the speed of their transceiver and autonegotiate it
+func getPortLinkSettings(p *model.Port) portLinkSettings {
+	link := defaultPortLinkSettings
+	if len(p.LinkConfiguration) == 0 || p.LinkConfiguration[0] == nil {
+		return link
+	}
+	link.autoNeg = p.LinkConfiguration[0].AutoSpeedNegotiationEnabled
+	for _, capableSpeed := range p.LinkConfiguration[0].CapableLinkSpeedGbps {
+		if len(capableSpeed.ConfiguredLinkSpeedGbps) > 0 {
+			link.speedGbps = capableSpeed.ConfiguredLinkSpeedGbps[0]
+			break
+		}
+	}
+	return link
+}
+
+// setPortLinkSettings sets the link configuration of the port to the applied link settings
+func setPortLinkSettings(p *model.Port, link portLinkSettings) {
+	linkConfiguration := &model.LinkConfiguration{
+		AutoSpeedNegotiationCapable: true,
+		AutoSpeedNegotiationEnabled: link.autoNeg,
+	}
+	if link.speedGbps > 0 {
+		linkConfiguration.CapableLinkSpeedGbps = []model.CapableLinkSpeedGbps{
+			{ConfiguredLinkSpeedGbps: []float64{link.speedGbps}},
+		}
+	}
+	p.LinkConfiguration = []*model.LinkConfiguration{linkConfiguration}
+}
+
+// aciLinkSpeed formats the speed in Gbps as the speed of the link level policies like 100M or 25G
+func aciLinkSpeed(speedGbps float64) string {
+	if speedGbps < 1 {
+		return strconv.FormatFloat(math.Round(speedGbps*1000), 'f', -1, 64) + "M"
+	}
+	return strconv.FormatFloat(speedGbps, 'f', -1, 64) + "G"
+}
@@ -16,17 +16,22 @@
 package caphandler
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/capmiddleware"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
 	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
 	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
 	"github.com/ODIM-Project/PluginCiscoACI/db"
 	iris "github.com/kataras/iris/v12"
 	log "github.com/sirupsen/logrus"
@@ -34,7 +39,7 @@ import (
 
 // GetPortCollection fetches the ports  which are linked to that switch
 func GetPortCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	switchID := ctx.Params().Get("switchID")
 
 	// get all port which are store under that switch
@@ -61,8 +66,16 @@ func GetPortCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(portCollectionResponse)
+	fabricID := ctx.Params().Get("id")
+	policy := getCachePolicy(ctx)
+	writeCollection(ctx, portCollectionResponse, func(oid string) (interface{}, error) {
+		fabricData, err := capmodel.GetFabric(fabricID)
+		if err != nil {
+			return nil, err
+		}
+		portResponse, _, err := getPortResponse(oid, fabricData.SwitchPod(switchID), switchID, policy)
+		return portResponse, err
+	})
 }
 
 // GetPortInfo fetches the port info for given port id
@@ -76,21 +89,50 @@ func GetPortInfo(ctx iris.Context) {
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
 		return
 	}
-	portData := getPortData(ctx, uri)
-	if portData == nil {
+	portResponse, etag, err := getPortResponse(uri, fabricData.SwitchPod(switchID), switchID, getCachePolicy(ctx))
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch port data for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Port", uri})
 		return
 	}
-	getPortAddtionalAttributes(fabricData.PodID, switchID, portData)
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(portData)
+	ctx.JSON(portResponse)
+}
 
+// getPortResponse builds the port resource stored at the given uri along with its state on APIC
+// and the entity tag of the stored port, the stored port is returned as it is when its state
+// couldn't be read
+func getPortResponse(uri, podID, switchID string, policy caputilities.CachePolicy) (interface{}, string, error) {
+	portData, etag, err := capmodel.GetPortWithETag(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	if portData.Status != nil && portData.Status.State == constants.AbsentState {
+		return portData, etag, nil
+	}
+	portData.Metrics = &model.Link{Oid: portData.ODataID + "/Metrics"}
+	setPortTransceiver(podID, switchID, portData, policy)
+	if status := getPortAddtionalAttributes(podID, switchID, portData, policy); status != nil {
+		return portWithStatus{
+			Port:   *portData,
+			Status: status,
+		}, etag, nil
+	}
+	return portData, etag, nil
 }
 
 // PatchPort Update the given port with provied information
 func PatchPort(ctx iris.Context) {
 	uri := ctx.Request().RequestURI
 	var port model.Port
-	err := ctx.ReadJSON(&port)
+	var patch portPatchRequest
+	body, err := ctx.GetBody()
+	if err == nil {
+		if err = json.Unmarshal(body, &port); err == nil {
+			err = json.Unmarshal(body, &patch)
+		}
+	}
 	if err != nil {
 		errorMessage := "error while trying to get JSON body from the  request: " + err.Error()
 		log.Error(errorMessage)
@@ -99,12 +141,18 @@ func PatchPort(ctx iris.Context) {
 		ctx.JSON(resp)
 		return
 	}
-	portData := getPortData(ctx, uri)
-	if portData == nil {
+	portData, etag, err := capmodel.GetPortWithETag(uri)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch port data for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Ports", uri})
+		return
+	}
+	if !checkIfMatch(ctx, uri, etag) {
 		return
 	}
 	checkFlag := false
 
+	var connectedPorts []model.Link
 	if port.Links != nil {
 		if port.Links.ConnectedPorts != nil {
 			if len(port.Links.ConnectedPorts) > 0 {
@@ -145,75 +193,390 @@ func PatchPort(ctx iris.Context) {
 					ctx.JSON(resp)
 					return
 				}
-				portData.Links = &model.PortLinks{}
-				portData.Links.ConnectedPorts = []model.Link{}
-				portData.Links.ConnectedPorts = append(portData.Links.ConnectedPorts, model.Link{Oid: ethernetURI})
-			} else {
-				portData.Links.ConnectedPorts = nil
+				connectedPorts = append(connectedPorts, model.Link{Oid: ethernetURI})
+			}
+		}
+	}
+	// applyPatch sets the properties of the request to the stored port, the other properties of the port
+	// are left as they are stored
+	applyPatch := func(p *model.Port) {
+		if port.Links != nil {
+			// the links to the fabric ports are kept, they are discovered from APIC
+			if p.Links == nil {
+				p.Links = &model.PortLinks{}
 			}
-		} else {
-			portData.Links.ConnectedPorts = nil
+			p.Links.ConnectedPorts = connectedPorts
+		}
+		if patch.configuresPort() {
+			applyPortSettings(p, &patch)
 		}
 	}
-	if err := capmodel.UpdatePort(uri, portData); err != nil {
+	if patch.InterfaceEnabled != nil || patch.configuresPort() {
+		fabricID := ctx.Params().Get("id")
+		switchID := ctx.Params().Get("switchID")
+		fabricData, err := capmodel.GetFabric(fabricID)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to fetch fabric data for uri %s: %s", uri, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
+			return
+		}
+		podID := fabricData.SwitchPod(switchID)
+		// the whole request is checked before anything is changed on APIC
+		var basePolicies *portBasePolicies
+		if patch.configuresPort() {
+			var resp interface{}
+			var statusCode int
+			if basePolicies, resp, statusCode = checkPortConfiguration(podID, switchID, portData, &patch); resp != nil {
+				ctx.StatusCode(statusCode)
+				ctx.JSON(resp)
+				return
+			}
+		}
+		if patch.InterfaceEnabled != nil {
+			if resp, statusCode := checkPortInterfaceEnabled(switchID, portData, *patch.InterfaceEnabled, patch.force()); resp != nil {
+				ctx.StatusCode(statusCode)
+				ctx.JSON(resp)
+				return
+			}
+		}
+		if patch.configuresPort() {
+			if resp, statusCode := configurePort(podID, switchID, portData, &patch, basePolicies); resp != nil {
+				ctx.StatusCode(statusCode)
+				ctx.JSON(resp)
+				return
+			}
+		}
+		if patch.InterfaceEnabled != nil {
+			if resp, statusCode := setPortInterfaceEnabled(podID, switchID, portData, *patch.InterfaceEnabled, capmiddleware.FabricLocks(ctx)); resp != nil {
+				ctx.StatusCode(statusCode)
+				ctx.JSON(resp)
+				return
+			}
+		}
+		if locksLost(ctx) {
+			return
+		}
+		// the port may be updated by the link watchers while APIC is configured, only the patched properties are stored
+		if err = capmodel.ModifyPort(uri, applyPatch); err != nil {
+			errMsg := fmt.Sprintf("failed to update port data for uri %s: %s", uri, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{"Ports", uri})
+			return
+		}
+		portResponse, etag, err := getPortResponse(uri, podID, switchID, caputilities.BypassCache)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to fetch port data for uri %s: %s", uri, err.Error())
+			createDbErrResp(ctx, err, errMsg, []interface{}{"Ports", uri})
+			return
+		}
+		ctx.Header("ETag", etag)
+		ctx.StatusCode(http.StatusOK)
+		ctx.JSON(portResponse)
+		return
+	}
+	applyPatch(portData)
+	if etag, err = capmodel.UpdatePortIfMatch(uri, etag, portData); err != nil {
 		errMsg := fmt.Sprintf("failed to update port data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Ports", uri})
 		return
 	}
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(portData)
 }
 
-func getPortAddtionalAttributes(fabricID, switchID string, p *model.Port) {
+// portPatchRequest holds the properties of the PATCH request of a port which are told apart
+// from their zero value
+type portPatchRequest struct {
+	InterfaceEnabled  *bool   `json:"InterfaceEnabled"`
+	MaxFrameSize      *int    `json:"MaxFrameSize"`
+	Description       *string `json:"Description"`
+	LinkConfiguration []struct {
+		AutoSpeedNegotiationEnabled *bool `json:"AutoSpeedNegotiationEnabled"`
+		CapableLinkSpeedGbps        []struct {
+			ConfiguredLinkSpeedGbps []float64 `json:"ConfiguredLinkSpeedGbps"`
+		} `json:"CapableLinkSpeedGbps"`
+	} `json:"LinkConfiguration"`
+	Oem *struct {
+		Cisco *struct {
+			// Force allows taking a fabric uplink out of service
+			Force bool `json:"Force"`
+		} `json:"Cisco"`
+	} `json:"Oem"`
+}
+
+func (p *portPatchRequest) force() bool {
+	return p.Oem != nil && p.Oem.Cisco != nil && p.Oem.Cisco.Force
+}
+
+// configuredSpeed is the first speed of the first link configuration of the request
+func (p *portPatchRequest) configuredSpeed() *float64 {
+	if len(p.LinkConfiguration) == 0 {
+		return nil
+	}
+	for _, capableSpeed := range p.LinkConfiguration[0].CapableLinkSpeedGbps {
+		if len(capableSpeed.ConfiguredLinkSpeedGbps) > 0 {
+			return &capableSpeed.ConfiguredLinkSpeedGbps[0]
+		}
+	}
+	return nil
+}
+
+func (p *portPatchRequest) autoSpeedNegotiation() *bool {
+	if len(p.LinkConfiguration) == 0 {
+		return nil
+	}
+	return p.LinkConfiguration[0].AutoSpeedNegotiationEnabled
+}
+
+// configuresPort tells if the request changes the settings of the port applied through its interface policies
+func (p *portPatchRequest) configuresPort() bool {
+	return p.MaxFrameSize != nil || p.Description != nil || p.configuredSpeed() != nil || p.autoSpeedNegotiation() != nil
+}
+
+// portStateWaitTime is the time the PATCH of InterfaceEnabled waits for the operational state of the port to converge,
+// the state is read again at each portStatePollInterval
+var (
+	portStateWaitTime     = 30 * time.Second
+	portStatePollInterval = time.Second
+)
+
+// checkPortInterfaceEnabled checks that the state of the port can be changed, the fabric uplinks are only
+// taken out of service when forced. The error response is returned along with its status code when the state
+// can't be changed
+func checkPortInterfaceEnabled(switchID string, p *model.Port, enabled, force bool) (interface{}, int) {
+	if enabled || force {
+		return nil, http.StatusOK
+	}
+	uplink, resp, statusCode := isFabricUplink(switchID, p)
+	if resp != nil {
+		return resp, statusCode
+	}
+	if uplink {
+		errMsg := fmt.Sprintf("port %s is a fabric uplink, it is only taken out of service when Oem.Cisco.Force is set", p.ODataID)
+		log.Error(errMsg)
+		return updateErrorResponse(response.ResourceInUse, errMsg, nil), http.StatusConflict
+	}
+	return nil, http.StatusOK
+}
+
+// setPortInterfaceEnabled takes the port out of service or back into service on APIC and waits for its
+// state to converge, the request is checked beforehand with checkPortInterfaceEnabled.
+// The wait is cut short when the fabric lock of the request is lost. The error response is returned
+// along with its status code when the state couldn't be changed
+func setPortInterfaceEnabled(podID, switchID string, p *model.Port, enabled bool, fabricLocks *capmodel.HeldLocks) (interface{}, int) {
+	nodeID := strings.Split(switchID, ":")[1]
+	oosPath := capmodel.NewOutOfServicePath(podID, nodeID, p.PortID)
+	locks, resp, statusCode := lockACIObjects("update port state", oosPath.DN())
+	if locks == nil {
+		return resp, statusCode
+	}
+	defer locks.Release()
+	if err := caputilities.SetPortInService(podID, nodeID, p.PortID, enabled); err != nil {
+		errMsg := fmt.Sprintf("failed to change the state of port %s on APIC: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		return createACIErrResp(err, errMsg, http.StatusBadRequest)
+	}
+	waitForPortState(podID, nodeID, p.PortID, enabled, isLinkExpected(p), fabricLocks.Lost(), locks.Lost())
+	if err := locks.Err(); err != nil {
+		errMsg := fmt.Sprintf("aborting the state change of port %s: %s", p.ODataID, err.Error())
+		log.Error(errMsg)
+		return capresponse.NewServiceTemporarilyUnavailable(capmodel.LockWaitTime(), errMsg), http.StatusServiceUnavailable
+	}
+	return nil, http.StatusOK
+}
+
+// isFabricUplink tells if the port connects the switch to the other switches of the fabric,
+// all the ports of the spine switches are taken as fabric uplinks
+func isFabricUplink(switchID string, p *model.Port) (bool, interface{}, int) {
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch switch data for port %s: %s", p.ODataID, err.Error())
+		statusCode, resp := createDbErrResp(nil, err, errMsg, []interface{}{"Switch", switchID})
+		return false, resp, statusCode
+	}
+	uplink := capmodel.GetSwitchRole(&switchData) == capmodel.NodeRoleSpine || (p.Links != nil && len(p.Links.ConnectedSwitchPorts) > 0)
+	return uplink, nil, http.StatusOK
+}
+
+// waitForPortState waits until the port is taken out of service when disabled, or back into service
+// when enabled. The link of an enabled port is also waited for when a link is expected on the port,
+// the port is left as it is when its state doesn't converge in portStateWaitTime.
+// The wait stops as soon as one of the locks held for the change is lost
+func waitForPortState(podID, nodeID, portID string, enabled, linkExpected bool, fabricLockLost, portLockLost <-chan struct{}) {
+	deadline := time.Now().Add(portStateWaitTime)
+	for {
+		portInfo, err := caputilities.GetPortInfo(podID, nodeID, portID, caputilities.BypassCache)
+		if err == nil && portStateConverged(&portInfo.Attributes, enabled, linkExpected) {
+			return
+		}
+		if time.Now().After(deadline) {
+			log.Warn(fmt.Sprintf("the state of port %s of node-%s of pod-%s didn't converge in %v", portID, nodeID, podID, portStateWaitTime))
+			return
+		}
+		select {
+		case <-fabricLockLost:
+			return
+		case <-portLockLost:
+			return
+		case <-time.After(portStatePollInterval):
+		}
+	}
+}
+
+// portStateConverged tells if the state of the port is the requested one
+func portStateConverged(portInfo *capmodel.PortInfoAttributes, enabled, linkExpected bool) bool {
+	if !enabled {
+		return portInfo.IsAdminDown()
+	}
+	return !portInfo.IsAdminDown() && (!linkExpected || portInfo.IsUp())
+}
+
+// isLinkExpected tells if the port is known to be connected to a switch or to a system,
+// the ports without a known connection may have no cable so their link isn't waited for
+func isLinkExpected(p *model.Port) bool {
+	return p.Links != nil && (len(p.Links.ConnectedSwitchPorts) > 0 || len(p.Links.ConnectedPorts) > 0)
+}
+
+// getPortAddtionalAttributes updates the port with its state and health read from ACI,
+// the returned status includes the conditions affecting the health of the port
+func getPortAddtionalAttributes(fabricID, switchID string, p *model.Port, policy caputilities.CachePolicy) *capmodel.Status {
 	switchIDData := strings.Split(switchID, ":")
-	PortInfoResponse, err := caputilities.GetPortInfo(fabricID, switchIDData[1], p.PortID)
+	PortInfoResponse, err := caputilities.GetPortInfo(fabricID, switchIDData[1], p.PortID, policy)
 	if err != nil {
 		log.Error("Unable to get addtional port info " + err.Error())
-		return
+		return nil
 	}
-	portInfoData := PortInfoResponse.IMData[0].PhysicalInterface.Attributes
-	operationState := portInfoData["operSt"].(string)
-	if operationState == "up" {
+	portInfoData := PortInfoResponse.Attributes
+	if portInfoData.IsUp() {
 		p.LinkState = "Enabled"
 		p.LinkStatus = "LinkUp"
-		p.InterfaceEnabled = true
 	} else {
 		p.LinkState = "Disabled"
 		p.LinkStatus = "LinkDown"
-		p.InterfaceEnabled = false
 	}
-	curSpeedData := strings.Split(portInfoData["operSpeed"].(string), "G")
+	// the port is enabled as long as it isn't taken out of service, whether its link is up or not
+	p.InterfaceEnabled = !portInfoData.IsAdminDown()
+	curSpeedData := strings.Split(portInfoData.OperSpeed, "G")
 	data, err := strconv.ParseFloat(curSpeedData[0], 64)
 	if err != nil {
 		log.Error("Unable to get current speed  of port " + err.Error())
 	}
 	p.CurrentSpeedGbps = data
-	portsHealthResposne, err := caputilities.GetPortHealth(fabricID, switchIDData[1], p.PortID)
+	portsHealthResposne, err := caputilities.GetPortHealth(fabricID, switchIDData[1], p.PortID, policy)
 	if err != nil {
 		log.Error("Unable to get Health of port " + err.Error())
-		return
+		return nil
+	}
+
+	portDN := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]", fabricID, switchIDData[1], p.PortID)
+	status, err := caputilities.EvaluateHealth(caputilities.HealthResourcePort, &portsHealthResposne.Attributes, portDN)
+	if err != nil {
+		log.Error("Unable to evaluate Health of port " + err.Error())
+		return nil
 	}
+	status.State = p.LinkState
+	p.Status = status.DMTFStatus()
+	return status
+}
 
-	Healthdata := portsHealthResposne.IMData[0].HealthData.Attributes
-	currentHealthValue := Healthdata["cur"].(string)
-	healthValue, err := strconv.Atoi(currentHealthValue)
+// setPortTransceiver sets the SFP of the port from the transceiver plugged in it, its DOM readings
+// and the speeds supported by the port are given in the Oem
+func setPortTransceiver(podID, switchID string, p *model.Port, policy caputilities.CachePolicy) {
+	switchIDData := strings.Split(switchID, ":")
+	transceiver, err := caputilities.GetPortTransceiver(podID, switchIDData[1], p.PortID, policy)
 	if err != nil {
-		log.Error("Unable to convert current Health value:" + currentHealthValue + " go the error" + err.Error())
+		log.Error("Unable to get the transceiver of port " + p.PortID + ": " + err.Error())
 		return
 	}
-	var portStatus = model.Status{
-		State: p.LinkState,
+	if transceiver.Fcot != nil {
+		p.SFP = getSFP(transceiver.Fcot)
 	}
-	if healthValue > 90 {
-		portStatus.Health = "OK"
-	} else if healthValue <= 90 && healthValue < 30 {
-		portStatus.Health = "Warning"
-	} else {
-		portStatus.Health = "Critical"
+	if len(transceiver.SupportedSpeeds) == 0 && transceiver.DOM == nil {
+		return
+	}
+	oem := capmodel.GetPortOem(p)
+	if oem.Cisco == nil {
+		oem.Cisco = &capmodel.CiscoPortOem{}
+	}
+	oem.Cisco.SupportedSpeeds = transceiver.SupportedSpeeds
+	oem.Cisco.TransceiverDOM = transceiver.DOM
+	p.Oem = &oem
+}
+
+// getSFP maps the ethpmFcot of the transceiver to the Redfish SFP, the type and the medium
+// are told by the type name like 10Gbase-SR or 100Gbase-CU3M
+func getSFP(fcot *capmodel.FcotAttributes) model.SFP {
+	if !fcot.IsPresent() {
+		return model.SFP{
+			Status: model.Status{State: constants.AbsentState},
+		}
+	}
+	sfp := model.SFP{
+		Manufacturer: firstNonEmpty(fcot.GuiName, fcot.VendorName),
+		PartNumber:   firstNonEmpty(fcot.GuiPN, fcot.VendorPn),
+		SerialNumber: firstNonEmpty(fcot.GuiSN, fcot.VendorSn),
+		Status:       model.Status{State: "Enabled"},
+	}
+	typeName := strings.ToLower(fcot.TypeName)
+	for _, sfpType := range sfpTypesBySpeed {
+		if strings.HasPrefix(typeName, sfpType.speedPrefix) {
+			sfp.Type = sfpType.sfpType
+			sfp.SupportedSFPTypes = []string{sfpType.sfpType}
+			break
+		}
+	}
+	switch {
+	case typeName == "":
+	case strings.Contains(typeName, "-cu") || strings.HasSuffix(typeName, "-t"):
+		sfp.MediumType = "Copper"
+	default:
+		sfp.MediumType = "Optical"
+		if strings.Contains(typeName, "-sr") {
+			sfp.FiberConnectionType = "MultiMode"
+		} else if strings.Contains(typeName, "-lr") || strings.Contains(typeName, "-er") || strings.Contains(typeName, "-zr") {
+			sfp.FiberConnectionType = "SingleMode"
+		}
 	}
+	return sfp
+}
+
+// sfpTypesBySpeed gives the Redfish SFP type of the transceivers by the speed starting their type name,
+// the longer prefixes come first
+var sfpTypesBySpeed = []struct {
+	speedPrefix string
+	sfpType     string
+}{
+	{"400gbase", "QSFPDD"},
+	{"100gbase", "QSFP28"},
+	{"40gbase", "QSFPPlus"},
+	{"25gbase", "SFP28"},
+	{"10gbase", "SFPPlus"},
+	{"1000base", "SFP"},
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// portWithStatus is the port response along with the conditions affecting its health
+type portWithStatus struct {
+	model.Port
+	Status *capmodel.Status `json:"Status,omitempty"`
+}
 
-	p.Status = &portStatus
-	return
+// getCachePolicy returns BypassCache when the client asks for the live data with Cache-Control: no-cache
+func getCachePolicy(ctx iris.Context) caputilities.CachePolicy {
+	for _, directive := range strings.Split(ctx.GetHeader("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return caputilities.BypassCache
+		}
+	}
+	return caputilities.UseCache
 }
 
 func updateErrorResponse(statusMsg, errMsg string, msgArgs []interface{}) interface{} {
@@ -244,6 +607,9 @@ func createDbErrResp(ctx iris.Context, err error, errMsg string, msgArgs []inter
 	case errors.Is(err, db.ErrorKeyAlreadyExist):
 		resp = updateErrorResponse(response.ResourceAlreadyExists, errMsg, msgArgs)
 		statusCode = http.StatusConflict
+	case errors.Is(err, db.ErrorDataChanged):
+		resp = capresponse.NewPreconditionFailed(errMsg)
+		statusCode = http.StatusPreconditionFailed
 	default:
 		resp = updateErrorResponse(response.InternalError, errMsg, nil)
 		statusCode = http.StatusInternalServerError
@@ -256,6 +622,50 @@ func createDbErrResp(ctx iris.Context, err error, errMsg string, msgArgs []inter
 	return statusCode, resp
 }
 
+// lockACIObjects locks the ACI objects with the given DNs on behalf of operation,
+// when the locks can't be acquired the error response is returned along with nil locks
+func lockACIObjects(operation string, dns ...string) (*capmodel.HeldLocks, interface{}, int) {
+	names := make([]string, 0, len(dns))
+	for _, dn := range dns {
+		names = append(names, capmodel.ACIObjectLockName(dn))
+	}
+	locks, err := capmodel.AcquireLocks(operation, names...)
+	if err != nil {
+		errMsg := "failed to lock the ACI objects for " + operation + ": " + err.Error()
+		if errors.Is(err, capmodel.ErrorLockTimeout) {
+			log.Error(errMsg)
+			return nil, capresponse.NewServiceTemporarilyUnavailable(capmodel.LockWaitTime(), errMsg), http.StatusServiceUnavailable
+		}
+		statusCode, resp := createDbErrResp(nil, err, errMsg, nil)
+		return nil, resp, statusCode
+	}
+	return locks, nil, http.StatusOK
+}
+
+// locksLost answers the request with 503 when the fabric lock of the request or one of the given locks
+// couldn't be renewed, the request must stop modifying the fabric as another request may hold the locks
+func locksLost(ctx iris.Context, locks ...*capmodel.HeldLocks) bool {
+	for _, held := range append(locks, capmiddleware.FabricLocks(ctx)) {
+		if err := held.Err(); err != nil {
+			errMsg := "aborting " + ctx.Method() + " on " + ctx.Path() + ": " + err.Error()
+			log.Error(errMsg)
+			capresponse.SetServiceTemporarilyUnavailableResponse(ctx, capmodel.LockWaitTime(), errMsg)
+			return true
+		}
+	}
+	return false
+}
+
+// createACIErrResp returns the ServiceTemporarilyUnavailable response when APIC couldn't be reached
+// even after retrying, for all other failures a GeneralError response with statusCode is returned
+func createACIErrResp(err error, errMsg string, statusCode int) (interface{}, int) {
+	var unavailableErr *caputilities.APICUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return capresponse.NewServiceTemporarilyUnavailable(unavailableErr.RetryAfter, errMsg), http.StatusServiceUnavailable
+	}
+	return updateErrorResponse(response.GeneralError, errMsg, nil), statusCode
+}
+
 func getPortData(ctx iris.Context, portOID string) *model.Port {
 	log.Info("Port uri" + portOID)
 	portData, err := capmodel.GetPort(portOID)
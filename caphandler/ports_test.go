@@ -0,0 +1,114 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Packahe caphandler ...
+package caphandler
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+func TestGetPortAddtionalAttributesAcrossAPICReleases(t *testing.T) {
+	tests := []struct {
+		release          string
+		portID           string
+		linkStatus       string
+		interfaceEnabled bool
+		currentSpeedGbps float64
+	}{
+		{"4.2.7f", "eth1/1", "LinkUp", true, 10},
+		{"4.2.7f", "eth1/2", "LinkDown", true, 0},
+		{"5.2.1g", "eth1/1", "LinkUp", true, 25},
+		{"5.2.1g", "eth1/49/1", "LinkUp", true, 25},
+		{"6.0.2h", "eth1/1", "LinkUp", true, 100},
+		{"6.0.2h", "eth1/2", "LinkUp", true, 40},
+		{"6.0.2h", "eth1/3", "LinkDown", true, 0},
+	}
+	config.SetUpMockConfig(t)
+	defer func() { caputilities.APICTransport = nil }()
+	for _, tt := range tests {
+		t.Run(tt.release+"/"+tt.portID, func(t *testing.T) {
+			replay, err := caputilities.NewReplayTransport(filepath.Join("..", "caputilities", "testdata", "apic", tt.release))
+			if err != nil {
+				t.Fatalf("failed to load recorded APIC exchanges: %v", err)
+			}
+			caputilities.APICTransport = replay
+			caputilities.ClearAPICCache()
+			config.Data.APICConf = &config.APICConf{
+				APICHost: "apic.example.com",
+				UserName: "admin",
+				Password: "password",
+			}
+			port := model.Port{
+				PortID: tt.portID,
+			}
+			getPortAddtionalAttributes("1", "uuid:101", &port, caputilities.UseCache)
+			if port.LinkStatus != tt.linkStatus || port.InterfaceEnabled != tt.interfaceEnabled {
+				t.Errorf("got LinkStatus %s and InterfaceEnabled %v, want %s and %v", port.LinkStatus, port.InterfaceEnabled, tt.linkStatus, tt.interfaceEnabled)
+			}
+			if port.CurrentSpeedGbps != tt.currentSpeedGbps {
+				t.Errorf("got CurrentSpeedGbps %v, want %v", port.CurrentSpeedGbps, tt.currentSpeedGbps)
+			}
+			if port.Status == nil || port.Status.Health != "OK" {
+				t.Errorf("got Status %+v, want Health OK", port.Status)
+			}
+		})
+	}
+}
+
+func TestApplyPortSettings(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		wantMTU         int
+		wantDescription string
+		wantSpeed       float64
+		wantAutoNeg     bool
+	}{
+		{"only the MTU", `{"MaxFrameSize":9000}`, 9000, "host port", 10, false},
+		{"only the description", `{"Description":"uplink"}`, 1500, "uplink", 10, false},
+		{"only the speed", `{"LinkConfiguration":[{"CapableLinkSpeedGbps":[{"ConfiguredLinkSpeedGbps":[25]}]}]}`, 1500, "host port", 25, false},
+		{"only the autonegotiation", `{"LinkConfiguration":[{"AutoSpeedNegotiationEnabled":true}]}`, 1500, "host port", 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch portPatchRequest
+			if err := json.Unmarshal([]byte(tt.body), &patch); err != nil {
+				t.Fatalf("failed to unmarshal the patch: %v", err)
+			}
+			p := &model.Port{
+				MaxFrameSize: 1500,
+				Description:  "host port",
+				LinkConfiguration: []*model.LinkConfiguration{{
+					CapableLinkSpeedGbps: []model.CapableLinkSpeedGbps{{ConfiguredLinkSpeedGbps: []float64{10}}},
+				}},
+				Links: &model.PortLinks{ConnectedSwitchPorts: []model.Link{{Oid: "/ODIM/v1/Fabrics/1/Switches/2/Ports/3"}}},
+			}
+			applyPortSettings(p, &patch)
+			link := getPortLinkSettings(p)
+			if p.MaxFrameSize != tt.wantMTU || p.Description != tt.wantDescription || link.speedGbps != tt.wantSpeed || link.autoNeg != tt.wantAutoNeg {
+				t.Errorf("got MTU %d, description %q, link settings %+v", p.MaxFrameSize, p.Description, link)
+			}
+			if len(p.Links.ConnectedSwitchPorts) != 1 {
+				t.Errorf("the links of the port are changed: %+v", p.Links)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterExpression is a parsed $filter expression which is evaluated on the JSON form of a resource
+type filterExpression interface {
+	evaluate(resource map[string]interface{}) bool
+}
+
+type filterOr struct {
+	left, right filterExpression
+}
+
+func (f filterOr) evaluate(resource map[string]interface{}) bool {
+	return f.left.evaluate(resource) || f.right.evaluate(resource)
+}
+
+type filterAnd struct {
+	left, right filterExpression
+}
+
+func (f filterAnd) evaluate(resource map[string]interface{}) bool {
+	return f.left.evaluate(resource) && f.right.evaluate(resource)
+}
+
+type filterNot struct {
+	expression filterExpression
+}
+
+func (f filterNot) evaluate(resource map[string]interface{}) bool {
+	return !f.expression.evaluate(resource)
+}
+
+// filterComparison compares the property at path with a literal, a missing property is null
+type filterComparison struct {
+	path     []string
+	operator string
+	value    interface{}
+}
+
+var filterComparisonOperators = map[string]bool{
+	"eq": true,
+	"ne": true,
+	"gt": true,
+	"ge": true,
+	"lt": true,
+	"le": true,
+}
+
+func (f filterComparison) evaluate(resource map[string]interface{}) bool {
+	var property interface{} = resource
+	for _, name := range f.path {
+		object, ok := property.(map[string]interface{})
+		if !ok {
+			property = nil
+			break
+		}
+		property = object[name]
+	}
+
+	switch f.operator {
+	case "eq":
+		return property == f.value
+	case "ne":
+		return property != f.value
+	}
+	var order int
+	switch value := f.value.(type) {
+	case float64:
+		number, ok := property.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case number < value:
+			order = -1
+		case number > value:
+			order = 1
+		}
+	case string:
+		text, ok := property.(string)
+		if !ok {
+			return false
+		}
+		order = strings.Compare(text, value)
+	default:
+		return false
+	}
+	switch f.operator {
+	case "gt":
+		return order > 0
+	case "ge":
+		return order >= 0
+	case "lt":
+		return order < 0
+	default:
+		return order <= 0
+	}
+}
+
+// filterParser is a recursive descent parser of the $filter grammar
+//
+//	expression = and-expression *( "or" and-expression )
+//	and-expression = not-expression *( "and" not-expression )
+//	not-expression = "not" not-expression / "(" expression ")" / property operator literal
+type filterParser struct {
+	filter string
+	tokens []string
+	pos    int
+}
+
+// parseFilter parses the $filter value, literals are quoted strings, numbers, true, false and
+// null while the properties of nested objects are given as paths such as Status/Health
+func parseFilter(filter string) (filterExpression, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{
+		filter: filter,
+		tokens: tokens,
+	}
+	expression, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return expression, nil
+}
+
+func tokenizeFilter(filter string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'':
+			// quotes inside a string literal are escaped by doubling them
+			j := i + 1
+			for ; j < len(filter); j++ {
+				if filter[j] != '\'' {
+					continue
+				}
+				if j+1 < len(filter) && filter[j+1] == '\'' {
+					j++
+					continue
+				}
+				break
+			}
+			if j >= len(filter) {
+				return nil, fmt.Errorf("invalid $filter value %s: unterminated string", filter)
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(filter) && !strings.ContainsRune(" \t()'", rune(filter[j])) {
+				j++
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid $filter value %s: %s", p.filter, fmt.Sprintf(format, args...))
+}
+
+// next returns the next token, it is empty at the end of the filter
+func (p *filterParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+func (p *filterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) parseOr() (filterExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterExpression, error) {
+	switch p.peek() {
+	case "not":
+		p.next()
+		expression, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{expression: expression}, nil
+	case "(":
+		p.next()
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token := p.next(); token != ")" {
+			return nil, p.errorf("expected ) but found %q", token)
+		}
+		return expression, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpression, error) {
+	property := p.next()
+	if property == "" || property == ")" || strings.HasPrefix(property, "'") {
+		return nil, p.errorf("expected a property but found %q", property)
+	}
+	path := strings.Split(property, "/")
+	for _, name := range path {
+		if name == "" {
+			return nil, p.errorf("invalid property %s", property)
+		}
+	}
+	operator := p.next()
+	if !filterComparisonOperators[operator] {
+		return nil, p.errorf("expected a comparison operator after %s but found %q", property, operator)
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return filterComparison{path: path, operator: operator, value: value}, nil
+}
+
+func (p *filterParser) parseLiteral() (interface{}, error) {
+	token := p.next()
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(token, "'") {
+		return strings.ReplaceAll(token[1:len(token)-1], "''", "'"), nil
+	}
+	number, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, p.errorf("expected a literal but found %q", token)
+	}
+	return number, nil
+}
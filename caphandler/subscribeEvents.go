@@ -38,8 +38,6 @@ func CreateEventSubscription(ctx iris.Context) {
 	if err != nil {
 		return
 	}
-	//First delete existing matching subscription(our subscription) from device
-	deleteMatchingSubscriptions(device)
 
 	var reqPostBody capmodel.EvtSubPost
 	var reqData string
@@ -59,40 +57,43 @@ func CreateEventSubscription(ctx iris.Context) {
 	// remove the mesaageids, resourcestypes and originresources from the request and post it to device
 	// since some of device doesnt support these
 	req := capmodel.EvtSubPost{
-		Destination: "https://" + evtConfig.Data.LoadBalancerConf.Host + ":" + evtConfig.Data.LoadBalancerConf.Port + evtConfig.Data.EventConf.DestURI,
 		EventTypes:  reqPostBody.EventTypes,
 		Context:     reqPostBody.Context,
 		HTTPHeaders: reqPostBody.HTTPHeaders,
 		Protocol:    reqPostBody.Protocol,
 	}
-	device.PostBody, err = json.Marshal(req)
+	resp, err := subscribeForEvents(device, req)
 	if err != nil {
+		log.Error(err.Error())
 		ctx.StatusCode(http.StatusInternalServerError)
 		ctx.WriteString(err.Error())
 		return
 	}
-
-	redfishClient, err := caputilities.GetRedfishClient()
-	if err != nil {
-		log.Error(err.Error())
-		ctx.StatusCode(http.StatusInternalServerError)
-		ctx.WriteString(err.Error())
+	defer resp.Body.Close()
+	if err := validateResponse(ctx, device, resp, http.MethodPost); err != nil {
 		return
 	}
+}
 
-	var resp *http.Response
-	//Subscribe to Events
-	resp, err = redfishClient.SubscribeForEvents(device)
+// subscribeForEvents replaces the subscriptions of the plugin on the device with the one described by req,
+// the events are sent to the plugin through the load balancer
+func subscribeForEvents(device *caputilities.RedfishDevice, req capmodel.EvtSubPost) (*http.Response, error) {
+	//First delete existing matching subscription(our subscription) from device
+	deleteMatchingSubscriptions(device)
+
+	var err error
+	req.Destination = "https://" + evtConfig.Data.LoadBalancerConf.Host + ":" + evtConfig.Data.LoadBalancerConf.Port + evtConfig.Data.EventConf.DestURI
+	device.PostBody, err = json.Marshal(req)
 	if err != nil {
-		log.Error(err.Error())
-		ctx.StatusCode(http.StatusInternalServerError)
-		ctx.WriteString(err.Error())
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
-	if err := validateResponse(ctx, device, resp, http.MethodPost); err != nil {
-		return
+
+	redfishClient, err := caputilities.GetRedfishClient()
+	if err != nil {
+		return nil, err
 	}
+	//Subscribe to Events
+	return redfishClient.SubscribeForEvents(device)
 }
 
 // Delete match subscription from device
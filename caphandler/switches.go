@@ -18,12 +18,12 @@ package caphandler
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
 	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
 
 	iris "github.com/kataras/iris/v12"
 	log "github.com/sirupsen/logrus"
@@ -31,7 +31,7 @@ import (
 
 // GetSwitchCollection fetches the switches which are linked to that fabric
 func GetSwitchCollection(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	fabricID := ctx.Params().Get("id")
 
 	// get all switches which are store under that fabric
@@ -58,8 +58,11 @@ func GetSwitchCollection(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(switchCollectionResponse)
+	policy := getCachePolicy(ctx)
+	writeCollection(ctx, switchCollectionResponse, func(oid string) (interface{}, error) {
+		switchID := oid[strings.LastIndex(oid, "/")+1:]
+		return getSwitchResponse(oid, fabricData.SwitchPod(switchID), switchID, policy)
+	})
 }
 
 // GetSwitchInfo fetches the switch info for given swith id
@@ -74,44 +77,61 @@ func GetSwitchInfo(ctx iris.Context) {
 		return
 	}
 
-	// Get the switch data from the memory
-	switchResponse, err := capmodel.GetSwitch(switchID)
+	switchResponse, err := getSwitchResponse(uri, fabricData.SwitchPod(switchID), switchID, getCachePolicy(ctx))
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch switch data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Switch", uri})
 		return
 	}
-	switchResponse.ODataID = uri
-	switchResponse.Ports = &model.Link{
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(switchResponse)
+}
+
+// getSwitchResponse builds the switch resource served at the given uri along with its health
+func getSwitchResponse(uri, podID, switchID string, policy caputilities.CachePolicy) (*switchWithStatus, error) {
+	// Get the switch data from the memory
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		return nil, err
+	}
+	switchData.ODataID = uri
+	switchData.Ports = &model.Link{
 		Oid: uri + "/Ports",
 	}
 
-	switchResponse.Status = &model.Status{
-		State:  "Enabled",
-		Health: getSwitchHealthData(fabricData.PodID, switchID),
+	// the node of an absent switch is no longer in the fabric, APIC has no health for it
+	if switchData.Status != nil && switchData.Status.State == constants.AbsentState {
+		return &switchWithStatus{
+			Switch: switchData,
+			Status: &capmodel.Status{State: constants.AbsentState},
+		}, nil
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(switchResponse)
+	status := getSwitchHealthData(podID, switchID, policy)
+	status.State = "Enabled"
+	return &switchWithStatus{
+		Switch: switchData,
+		Status: status,
+	}, nil
 }
 
-func getSwitchHealthData(podID, switchID string) string {
+// switchWithStatus is the switch response along with the conditions affecting its health
+type switchWithStatus struct {
+	model.Switch
+	Status *capmodel.Status `json:"Status,omitempty"`
+}
+
+func getSwitchHealthData(podID, switchID string, policy caputilities.CachePolicy) *capmodel.Status {
 	switchIDData := strings.Split(switchID, ":")
-	switchHealthResposne, err := caputilities.GetSwitchHealth(podID, switchIDData[1])
+	switchHealthResposne, err := caputilities.GetSwitchHealth(podID, switchIDData[1], policy)
 	if err != nil {
 		log.Error("Unable to get Health of switch " + err.Error())
-		return ""
+		return &capmodel.Status{}
 	}
-	data := switchHealthResposne.IMData[0].HealthData.Attributes
-	currentHealthValue := data["cur"].(string)
-	healthValue, err := strconv.Atoi(currentHealthValue)
+	switchDN := fmt.Sprintf("topology/pod-%s/node-%s/sys", podID, switchIDData[1])
+	status, err := caputilities.EvaluateHealth(caputilities.HealthResourceSwitch, &switchHealthResposne.Attributes, switchDN)
 	if err != nil {
-		log.Error("Unable to convert current Health value:" + currentHealthValue + " go the error" + err.Error())
-		return ""
-	}
-	if healthValue > 90 {
-		return "OK"
-	} else if healthValue <= 90 && healthValue < 30 {
-		return "Warning"
+		log.Error("Unable to evaluate Health of switch " + err.Error())
+		return &capmodel.Status{}
 	}
-	return "Critical"
+	return status
 }
@@ -0,0 +1,135 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	iris "github.com/kataras/iris/v12"
+)
+
+// GetTelemetryService responds with the telemetry service of the plugin
+func GetTelemetryService(ctx iris.Context) {
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(capresponse.TelemetryService{
+		OdataContext:            "/ODIM/v1/$metadata#TelemetryService.TelemetryService",
+		OdataID:                 capmodel.TelemetryServiceURI,
+		OdataType:               "#TelemetryService.v1_3_0.TelemetryService",
+		ID:                      "TelemetryService",
+		Name:                    "Telemetry Service",
+		Description:             "Metric reports of the fabric counters read from APIC",
+		ServiceEnabled:          true,
+		MetricReportDefinitions: &model.Link{Oid: capmodel.TelemetryServiceURI + "/MetricReportDefinitions"},
+		MetricReports:           &model.Link{Oid: capmodel.TelemetryServiceURI + "/MetricReports"},
+		Status: &model.Status{
+			State:  "Enabled",
+			Health: "OK",
+		},
+	})
+}
+
+// GetMetricReportDefinitionCollection responds with the metric report definitions of the fabric counters
+func GetMetricReportDefinitionCollection(ctx iris.Context) {
+	uri := ctx.Request().URL.Path
+	var members = []*model.Link{}
+	for _, definitionID := range capmodel.MetricReportDefinitionIDs {
+		members = append(members, &model.Link{Oid: uri + "/" + definitionID})
+	}
+	definitions := model.Collection{
+		ODataContext: "/ODIM/v1/$metadata#MetricReportDefinitionCollection.MetricReportDefinitionCollection",
+		ODataID:      uri,
+		ODataType:    "#MetricReportDefinitionCollection.MetricReportDefinitionCollection",
+		Description:  "MetricReportDefinitionCollection view",
+		Name:         "MetricReportDefinitions",
+		Members:      members,
+		MembersCount: len(members),
+	}
+	writeCollection(ctx, definitions, func(oid string) (interface{}, error) {
+		definition, _ := capmodel.GetMetricReportDefinition(path.Base(oid), getMetricReportInterval())
+		return definition, nil
+	})
+}
+
+// GetMetricReportDefinition responds with the metric report definition with the given id
+func GetMetricReportDefinition(ctx iris.Context) {
+	definitionID := ctx.Params().Get("id")
+	definition, ok := capmodel.GetMetricReportDefinition(definitionID, getMetricReportInterval())
+	if !ok {
+		errMsg := "metric report definition " + definitionID + " not found"
+		ctx.StatusCode(http.StatusNotFound)
+		ctx.JSON(updateErrorResponse(response.ResourceNotFound, errMsg, []interface{}{"MetricReportDefinition", definitionID}))
+		return
+	}
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(definition)
+}
+
+// GetMetricReportCollection responds with the last metric reports sent for the fabric counters
+func GetMetricReportCollection(ctx iris.Context) {
+	uri := ctx.Request().URL.Path
+	reportIDs, err := capmodel.GetMetricReportIDs()
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch metric reports for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"MetricReports", uri})
+		return
+	}
+	sort.Strings(reportIDs)
+	var members = []*model.Link{}
+	for _, reportID := range reportIDs {
+		members = append(members, &model.Link{Oid: uri + "/" + reportID})
+	}
+	reports := model.Collection{
+		ODataContext: "/ODIM/v1/$metadata#MetricReportCollection.MetricReportCollection",
+		ODataID:      uri,
+		ODataType:    "#MetricReportCollection.MetricReportCollection",
+		Description:  "MetricReportCollection view",
+		Name:         "MetricReports",
+		Members:      members,
+		MembersCount: len(members),
+	}
+	writeCollection(ctx, reports, func(oid string) (interface{}, error) {
+		return capmodel.GetMetricReport(path.Base(oid))
+	})
+}
+
+// GetMetricReport responds with the last metric report of the metric report definition with the given id
+func GetMetricReport(ctx iris.Context) {
+	uri := ctx.Request().URL.Path
+	reportID := ctx.Params().Get("id")
+	report, err := capmodel.GetMetricReport(reportID)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch metric report for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"MetricReport", reportID})
+		return
+	}
+	ctx.StatusCode(http.StatusOK)
+	ctx.JSON(report)
+}
+
+func getMetricReportInterval() int {
+	if config.Data.TelemetryConf == nil {
+		return config.DefaultMetricReportInterval
+	}
+	return config.Data.TelemetryConf.ReportIntervalInSeconds
+}
@@ -0,0 +1,286 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caphandler ...
+package caphandler
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+
+	iris "github.com/kataras/iris/v12"
+)
+
+// dotContentType is the media type of the topology in GraphViz DOT
+const dotContentType = "text/vnd.graphviz"
+
+// types of the nodes of the topology
+const (
+	topologyNodeFabric        = "Fabric"
+	topologyNodePod           = "Pod"
+	topologyNodeSwitch        = "Switch"
+	topologyNodePort          = "Port"
+	topologyNodeEndpoint      = "Endpoint"
+	topologyNodeZone          = "Zone"
+	topologyNodeHostInterface = "HostInterface"
+)
+
+// types of the edges of the topology
+const (
+	// topologyEdgeContains links a resource to the resources it is made of
+	topologyEdgeContains = "Contains"
+	// topologyEdgeCable links the ports at both ends of a fabric link
+	topologyEdgeCable = "Cable"
+	// topologyEdgeConnects links a port to the ethernet interface of the server attached to it
+	topologyEdgeConnects = "Connects"
+	// topologyEdgeUses links an endpoint to the ports of its redundancy set
+	topologyEdgeUses = "Uses"
+)
+
+// topology is the node/edge view of all the resources of a fabric
+type topology struct {
+	ODataID  string         `json:"@odata.id"`
+	FabricID string         `json:"FabricId"`
+	Nodes    []topologyNode `json:"Nodes"`
+	Edges    []topologyEdge `json:"Edges"`
+}
+
+// topologyNode is a resource of the fabric, its id is the uri of the resource
+// except for the pods which are not resources
+type topologyNode struct {
+	ID    string `json:"Id"`
+	Type  string `json:"Type"`
+	Name  string `json:"Name,omitempty"`
+	Role  string `json:"Role,omitempty"`
+	State string `json:"State,omitempty"`
+}
+
+// topologyEdge is a relationship between two nodes of the topology
+type topologyEdge struct {
+	Source string `json:"Source"`
+	Target string `json:"Target"`
+	Type   string `json:"Type"`
+}
+
+// GetFabricTopology responds with the topology of the fabric, in DOT when the format query parameter is dot
+// or the request accepts text/vnd.graphviz, in JSON otherwise
+func GetFabricTopology(ctx iris.Context) {
+	uri := ctx.Request().URL.Path
+	fabricID := ctx.Params().Get("id")
+	format := ctx.URLParamDefault("format", "")
+	if format == "" && strings.Contains(ctx.GetHeader("Accept"), dotContentType) {
+		format = "dot"
+	}
+	if format != "" && format != "dot" && format != "json" {
+		errMsg := "unsupported topology format " + format + ", supported formats are json and dot"
+		ctx.StatusCode(http.StatusBadRequest)
+		ctx.JSON(updateErrorResponse(response.QueryNotSupported, errMsg, nil))
+		return
+	}
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to fetch fabric data for uri %s: %s", uri, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
+		return
+	}
+	fabricTopology, err := buildFabricTopology(fabricID, &fabricData)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to build the topology of fabric %s: %s", fabricID, err.Error())
+		createDbErrResp(ctx, err, errMsg, []interface{}{"Fabric", fabricID})
+		return
+	}
+	fabricTopology.ODataID = uri
+	ctx.StatusCode(http.StatusOK)
+	if format == "dot" {
+		ctx.ContentType(dotContentType)
+		ctx.WriteString(fabricTopology.dot())
+		return
+	}
+	ctx.JSON(fabricTopology)
+}
+
+// buildFabricTopology walks the stored fabric, pods, switches, ports, endpoints and zones
+// along with the host interfaces connected to the ports
+func buildFabricTopology(fabricID string, fabricData *capdata.Fabric) (*topology, error) {
+	fabricURI := "/ODIM/v1/Fabrics/" + fabricID
+	t := &topology{
+		FabricID: fabricID,
+	}
+	t.addNode(topologyNode{ID: fabricURI, Type: topologyNodeFabric, Name: "ACI Fabric"})
+	podNodeID := func(podID string) string {
+		return fabricURI + "#pod-" + podID
+	}
+	for _, podID := range fabricData.Pods() {
+		t.addNode(topologyNode{ID: podNodeID(podID), Type: topologyNodePod, Name: "pod-" + podID})
+		t.addEdge(fabricURI, podNodeID(podID), topologyEdgeContains)
+	}
+
+	switchIDs := append([]string{}, fabricData.SwitchData...)
+	sort.Strings(switchIDs)
+	hostInterfaces := make(map[string]bool)
+	for _, switchID := range switchIDs {
+		switchData, err := capmodel.GetSwitch(switchID)
+		if err != nil {
+			return nil, err
+		}
+		switchURI := fabricURI + "/Switches/" + switchID
+		t.addNode(topologyNode{
+			ID:    switchURI,
+			Type:  topologyNodeSwitch,
+			Name:  switchData.Name,
+			Role:  capmodel.GetSwitchRole(&switchData),
+			State: resourceState(switchData.Status),
+		})
+		t.addEdge(podNodeID(fabricData.SwitchPod(switchID)), switchURI, topologyEdgeContains)
+
+		portIDs, err := capmodel.GetSwitchPort(switchID)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(portIDs)
+		for _, portID := range portIDs {
+			portURI := switchURI + "/Ports/" + portID
+			port, err := capmodel.GetPort(portURI)
+			if err != nil {
+				return nil, err
+			}
+			t.addNode(topologyNode{ID: portURI, Type: topologyNodePort, Name: port.PortID, State: resourceState(port.Status)})
+			t.addEdge(switchURI, portURI, topologyEdgeContains)
+			if port.Links == nil {
+				continue
+			}
+			// both ends of a cable list each other, the cable is given once
+			for _, connectedPort := range port.Links.ConnectedSwitchPorts {
+				if portURI < connectedPort.Oid {
+					t.addEdge(portURI, connectedPort.Oid, topologyEdgeCable)
+				}
+			}
+			for _, hostInterface := range port.Links.ConnectedPorts {
+				if !hostInterfaces[hostInterface.Oid] {
+					hostInterfaces[hostInterface.Oid] = true
+					t.addNode(topologyNode{ID: hostInterface.Oid, Type: topologyNodeHostInterface, Name: path.Base(hostInterface.Oid)})
+				}
+				t.addEdge(portURI, hostInterface.Oid, topologyEdgeConnects)
+			}
+		}
+	}
+
+	endpoints, err := capmodel.GetAllEndpoints(fabricID)
+	if err != nil {
+		return nil, err
+	}
+	for _, endpointData := range sortedEndpoints(endpoints) {
+		endpoint := endpointData.Endpoint
+		t.addNode(topologyNode{ID: endpoint.ODataID, Type: topologyNodeEndpoint, Name: endpoint.Name, State: resourceState(endpoint.Status)})
+		for _, redundancy := range endpoint.Redundancy {
+			for _, port := range redundancy.RedundancySet {
+				t.addEdge(endpoint.ODataID, port.Oid, topologyEdgeUses)
+			}
+		}
+	}
+
+	zones, err := capmodel.GetAllZones(fabricID)
+	if err != nil {
+		return nil, err
+	}
+	zoneURIs := make([]string, 0, len(zones))
+	for zoneURI := range zones {
+		zoneURIs = append(zoneURIs, zoneURI)
+	}
+	sort.Strings(zoneURIs)
+	for _, zoneURI := range zoneURIs {
+		zone := zones[zoneURI]
+		t.addNode(topologyNode{ID: zoneURI, Type: topologyNodeZone, Name: zone.Name, Role: zone.ZoneType, State: resourceState(zone.Status)})
+		if zone.Links == nil {
+			continue
+		}
+		for _, containedZone := range zone.Links.ContainsZones {
+			t.addEdge(zoneURI, containedZone.Oid, topologyEdgeContains)
+		}
+		for _, endpoint := range zone.Links.Endpoints {
+			t.addEdge(zoneURI, endpoint.Oid, topologyEdgeContains)
+		}
+	}
+	return t, nil
+}
+
+func sortedEndpoints(endpoints map[string]capdata.EndpointData) []capdata.EndpointData {
+	sorted := make([]capdata.EndpointData, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint.Endpoint != nil {
+			sorted = append(sorted, endpoint)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Endpoint.ODataID < sorted[j].Endpoint.ODataID
+	})
+	return sorted
+}
+
+func resourceState(status *model.Status) string {
+	if status == nil {
+		return ""
+	}
+	return status.State
+}
+
+func (t *topology) addNode(node topologyNode) {
+	t.Nodes = append(t.Nodes, node)
+}
+
+func (t *topology) addEdge(source, target, edgeType string) {
+	t.Edges = append(t.Edges, topologyEdge{Source: source, Target: target, Type: edgeType})
+}
+
+// dot returns the topology as a GraphViz digraph, the nodes are labelled with their names
+// and the cables are drawn without direction
+func (t *topology) dot() string {
+	var graph strings.Builder
+	fmt.Fprintf(&graph, "digraph %s {\n", dotQuote(t.FabricID))
+	for _, node := range t.Nodes {
+		label := node.Name
+		if label == "" {
+			label = node.ID
+		}
+		fmt.Fprintf(&graph, "\t%s [label=%s, type=%s", dotQuote(node.ID), dotQuote(label), dotQuote(node.Type))
+		if node.Role != "" {
+			fmt.Fprintf(&graph, ", role=%s", dotQuote(node.Role))
+		}
+		if node.State != "" {
+			fmt.Fprintf(&graph, ", state=%s", dotQuote(node.State))
+		}
+		graph.WriteString("];\n")
+	}
+	for _, edge := range t.Edges {
+		fmt.Fprintf(&graph, "\t%s -> %s [type=%s", dotQuote(edge.Source), dotQuote(edge.Target), dotQuote(edge.Type))
+		if edge.Type == topologyEdgeCable {
+			graph.WriteString(", dir=none")
+		}
+		graph.WriteString("];\n")
+	}
+	graph.WriteString("}\n")
+	return graph.String()
+}
+
+func dotQuote(id string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(id) + `"`
+}
@@ -49,7 +49,7 @@ func CreateVRF(name string, tenant string, description string, fvCtxattr aciMode
 
 // GetZones returns the collection of zones present under a fabric
 func GetZones(ctx iris.Context) {
-	uri := ctx.Request().RequestURI
+	uri := ctx.Request().URL.Path
 	fabricID := ctx.Params().Get("id")
 	if _, err := capmodel.GetFabric(fabricID); err != nil {
 		errMsg := fmt.Sprintf("failed to fetch fabric data for uri %s: %s", uri, err.Error())
@@ -79,8 +79,9 @@ func GetZones(ctx iris.Context) {
 		Members:      members,
 		MembersCount: len(members),
 	}
-	ctx.StatusCode(http.StatusOK)
-	ctx.JSON(zoneCollection)
+	writeCollection(ctx, zoneCollection, func(oid string) (interface{}, error) {
+		return capmodel.GetZone(fabricID, oid)
+	})
 }
 
 // GetZone returns a specific zone present under a fabric
@@ -93,12 +94,13 @@ func GetZone(ctx iris.Context) {
 		return
 	}
 
-	zoneData, err := capmodel.GetZone(fabricID, uri)
+	zoneData, etag, err := capmodel.GetZoneWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch zone data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", fabricID})
 		return
 	}
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(zoneData)
 }
@@ -154,6 +156,9 @@ func CreateZone(ctx iris.Context) {
 			}
 		}
 		if !conflictFlag {
+			if locksLost(ctx) {
+				return
+			}
 			defaultZoneID = uuid.NewV4().String()
 			if zone, err = saveZoneData(defaultZoneID, uri, fabricID, zone); err != nil {
 				errMsg := fmt.Sprintf("failed to store default zone data for uri %s: %s", uri, err.Error())
@@ -197,6 +202,9 @@ func CreateZone(ctx iris.Context) {
 			}
 		}
 		if !conflictFlag {
+			if locksLost(ctx) {
+				return
+			}
 			defaultZoneID = uuid.NewV4().String()
 			if zone, err = saveZoneData(defaultZoneID, uri, fabricID, zone); err != nil {
 				errMsg := fmt.Sprintf("failed to store zone of zone data for uri %s: %s", uri, err.Error())
@@ -232,6 +240,9 @@ func CreateZone(ctx iris.Context) {
 			ctx.JSON(resp)
 			return
 		}
+		if locksLost(ctx) {
+			return
+		}
 		zoneID := uuid.NewV4().String()
 		if zone, err = saveZoneData(zoneID, uri, fabricID, zone); err != nil {
 			errMsg := fmt.Sprintf("failed to store zone of endpoints data for uri %s: %s", uri, err.Error())
@@ -288,8 +299,8 @@ func CreateDefaultZone(zone model.Zone) (interface{}, int) {
 	tenantList, err := aciClient.ListTenant()
 	if err != nil {
 		errMsg := "Error while creating default Zone: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	for _, tenant := range tenantList {
 		if tenant.TenantAttributes.Name == zone.Name {
@@ -303,8 +314,8 @@ func CreateDefaultZone(zone model.Zone) (interface{}, int) {
 	resp, err := aciClient.CreateTenant(zone.Name, zone.Description, tenantAttributesStruct)
 	if err != nil {
 		errMsg := "Error while creating default Zone: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return resp, http.StatusCreated
 }
@@ -321,12 +332,15 @@ func DeleteZone(ctx iris.Context) {
 
 	//TODO: Get list of zones which are pre-populated from onstart and compare the members for item not present in odim but present in ACI
 
-	zoneData, err := capmodel.GetZone(fabricID, uri)
+	zoneData, etag, err := capmodel.GetZoneWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch zone data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", uri})
 		return
 	}
+	if !checkIfMatch(ctx, uri, etag) {
+		return
+	}
 	if zoneData.Links != nil {
 		if zoneData.Links.ContainsZonesCount != 0 {
 			errMsg := fmt.Sprintf("Zone cannot be deleted as there are dependent resources still tied to it")
@@ -341,8 +355,8 @@ func DeleteZone(ctx iris.Context) {
 		err := deleteZoneOfZone(fabricID, uri, &zoneData)
 		if err != nil {
 			if err.Error() == "Error deleting Application Profile" {
-				resp := updateErrorResponse(response.GeneralError, err.Error(), nil)
-				ctx.StatusCode(http.StatusBadRequest)
+				resp, statusCode := createACIErrResp(err, err.Error(), http.StatusBadRequest)
+				ctx.StatusCode(statusCode)
 				ctx.JSON(resp)
 				return
 			}
@@ -367,11 +381,14 @@ func DeleteZone(ctx iris.Context) {
 		err := aciClient.DeleteTenant(zoneData.Name)
 		if err != nil {
 			errMsg := "Error while deleting Zone: " + err.Error()
-			resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-			ctx.StatusCode(http.StatusBadRequest)
+			resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+			ctx.StatusCode(statusCode)
 			ctx.JSON(resp)
 			return
 		}
+		if locksLost(ctx) {
+			return
+		}
 		if err = capmodel.DeleteZone(fabricID, uri); err != nil {
 			errMsg := fmt.Sprintf("failed to delete zone data for %s: %s", uri, err.Error())
 			createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", uri})
@@ -445,16 +462,9 @@ func deleteZoneOfZone(fabricID, uri string, respData *model.Zone) error {
 		if err = capmodel.DeleteZoneDomain(uri); err != nil {
 			return fmt.Errorf("failed to delete zone domain %s: %s", uri, err.Error())
 		}
-		for i := 0; i < len(parentZone.Links.ContainsZones); i++ {
-			if parentZone.Links.ContainsZones[i].Oid == respData.ODataID {
-				parentZone.Links.ContainsZones[i] = parentZone.Links.ContainsZones[len(parentZone.Links.ContainsZones)-1] // Copy last element to index i.
-				parentZone.Links.ContainsZones[len(parentZone.Links.ContainsZones)-1] = model.Link{}                      // Erase last element (write zero value).
-				parentZone.Links.ContainsZones = parentZone.Links.ContainsZones[:len(parentZone.Links.ContainsZones)-1]
-			}
-		}
-		parentZone.Links.ContainsZonesCount = len(parentZone.Links.ContainsZones)
-
-		if err = capmodel.UpdateZone(fabricID, parentZoneLink.Oid, &parentZone); err != nil {
+		if err = capmodel.ModifyZone(fabricID, parentZoneLink.Oid, func(parentZone *model.Zone) {
+			removeContainedZone(parentZone, respData.ODataID)
+		}); err != nil {
 			return fmt.Errorf("failed to update zone data for %s: %s", parentZoneLink.Oid, err.Error())
 		}
 
@@ -538,14 +548,14 @@ func CreateZoneOfZones(uri string, fabricID string, zone model.Zone) (string, in
 	apResp, err := CreateApplicationProfile(zone.Name, respData.Name, respData.Description, apModel)
 	if err != nil {
 		errMsg := "Error while creating application profile: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return "", resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return "", resp, statusCode, nil
 	}
 	_, vrfErr := CreateVRF(vrfModel.Name, respData.Name, respData.Description, vrfModel)
 	if vrfErr != nil {
 		errMsg := "Error while creating application profile: " + vrfErr.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return "", resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(vrfErr, errMsg, http.StatusBadRequest)
+		return "", resp, statusCode, nil
 	}
 	// create contract with name vrf and suffix-Con
 	resp, statusCode = createContract(vrfModel.Name, respData.Name, zone.Name)
@@ -563,30 +573,16 @@ func CreateZoneOfZones(uri string, fabricID string, zone model.Zone) (string, in
 }
 
 func updateZoneData(fabricID, defaultZoneLink string, zone model.Zone) error {
-	defaultZoneData, err := capmodel.GetZone(fabricID, defaultZoneLink)
-	if err != nil {
-		return fmt.Errorf("failed to fetch zone data of %s: %s", defaultZoneLink, err.Error())
-	}
-	if defaultZoneData.Links == nil {
-		defaultZoneData.Links = &model.ZoneLinks{}
-	}
-	if defaultZoneData.Links.ContainsZones == nil {
-		var containsList []model.Link
-		log.Println("List of contains")
-		log.Println(containsList)
-		var link model.Link
-		link.Oid = zone.ODataID
-		containsList = append(containsList, link)
-		defaultZoneData.Links.ContainsZones = containsList
-		defaultZoneData.Links.ContainsZonesCount = len(containsList)
-	} else {
+	err := capmodel.ModifyZone(fabricID, defaultZoneLink, func(defaultZoneData *model.Zone) {
+		if defaultZoneData.Links == nil {
+			defaultZoneData.Links = &model.ZoneLinks{}
+		}
 		var link model.Link
 		link.Oid = zone.ODataID
 		defaultZoneData.Links.ContainsZones = append(defaultZoneData.Links.ContainsZones, link)
 		defaultZoneData.Links.ContainsZonesCount = len(defaultZoneData.Links.ContainsZones)
-	}
-
-	if err = capmodel.UpdateZone(fabricID, defaultZoneLink, &defaultZoneData); err != nil {
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update zone data of %s: %s", defaultZoneLink, err.Error())
 	}
 	return nil
@@ -703,8 +699,8 @@ func createBridgeDomain(tenantName string, zone model.Zone, aciClient *client.Se
 	if err != nil && !strings.Contains(err.Error(), "Object may not exists") {
 		errMsg := "Error while creating Zone endpoints: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, "", http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, "", statusCode
 	}
 	for _, bd := range bridgeDomainList {
 		if bd.Name == zone.Name {
@@ -718,8 +714,8 @@ func createBridgeDomain(tenantName string, zone model.Zone, aciClient *client.Se
 	resp, err := aciClient.CreateBridgeDomain(zone.Name, tenantName, zone.Description, bridgeDomainAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, "", http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, "", statusCode
 	}
 	return resp, resp.BaseAttributes.DistinguishedName, http.StatusCreated
 }
@@ -730,8 +726,8 @@ func createSubnets(tenantName, bdName string, addresspoolData *model.AddressPool
 	_, err := aciClient.CreateSubnet(subnetAttributes.Ip, bdName, tenantName, "subnet for ip"+subnetAttributes.Ip, subnetAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -740,8 +736,8 @@ func linkBDtoVRF(bdDN, vrfName string, aciClient *client.ServiceManager) (interf
 	err := aciClient.CreateRelationfvRsCtxFromBridgeDomain(bdDN, vrfName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -780,8 +776,8 @@ func createapplicationEPG(tenantName, applicationProfileName, epgName string, ac
 	resp, err := aciClient.CreateApplicationEPG(epgName, applicationProfileName, tenantName, "Application EPG for "+epgName, epgAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, "", http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, "", statusCode
 	}
 	return resp, resp.BaseAttributes.DistinguishedName, http.StatusCreated
 }
@@ -790,8 +786,8 @@ func linkAPPEPGtoBD(appEPGDN, bdName string, aciClient *client.ServiceManager) (
 	err := aciClient.CreateRelationfvRsBdFromApplicationEPG(appEPGDN, bdName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -801,8 +797,8 @@ func linkEpgtoDomain(appEPGDN, domain string, aciClient *client.ServiceManager)
 	err := aciClient.CreateRelationfvRsDomAttFromApplicationEPG(appEPGDN, domain)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Endpoints: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -851,24 +847,18 @@ func deleteZoneOfEndpoints(fabricID string, zoneData *model.Zone) (interface{},
 	}
 	if err = aciClient.DeleteApplicationEPG(zoneData.Name+"-EPG", zoneofZoneData.Name, defaultZoneData.Name); err != nil {
 		errMsg := "Error while deleting Zone: " + err.Error()
-		return updateErrorResponse(response.GeneralError, errMsg, nil), http.StatusBadRequest
+		return createACIErrResp(err, errMsg, http.StatusBadRequest)
 	}
 	err = aciClient.DeleteBridgeDomain(zoneData.Name, defaultZoneData.Name)
 	if err != nil {
 		errMsg := "Error while deleting Zone: " + err.Error()
-		return updateErrorResponse(response.GeneralError, errMsg, nil), http.StatusBadRequest
+		return createACIErrResp(err, errMsg, http.StatusBadRequest)
 	}
 	//updating the contains zonesdata
 	if zoneofZoneData.Links != nil {
-		for i := 0; i < len(zoneofZoneData.Links.ContainsZones); i++ {
-			if zoneofZoneData.Links.ContainsZones[i].Oid == zoneData.ODataID {
-				zoneofZoneData.Links.ContainsZones[i] = zoneofZoneData.Links.ContainsZones[len(zoneofZoneData.Links.ContainsZones)-1] // Copy last element to index i.
-				zoneofZoneData.Links.ContainsZones[len(zoneofZoneData.Links.ContainsZones)-1] = model.Link{}                          // Erase last element (write zero value).
-				zoneofZoneData.Links.ContainsZones = zoneofZoneData.Links.ContainsZones[:len(zoneofZoneData.Links.ContainsZones)-1]
-			}
-		}
-		zoneofZoneData.Links.ContainsZonesCount = len(zoneofZoneData.Links.ContainsZones)
-		if err = capmodel.UpdateZone(fabricID, zoneofZoneURL, &zoneofZoneData); err != nil {
+		if err = capmodel.ModifyZone(fabricID, zoneofZoneURL, func(zoneofZoneData *model.Zone) {
+			removeContainedZone(zoneofZoneData, zoneData.ODataID)
+		}); err != nil {
 			errMsg := fmt.Sprintf("failed to update zone data for uri %s: %s", zoneofZoneURL, err.Error())
 			statusCode, resp := createDbErrResp(nil, err, errMsg, []interface{}{"Zone", zoneofZoneURL})
 			return resp, statusCode
@@ -888,6 +878,21 @@ func deleteZoneOfEndpoints(fabricID string, zoneData *model.Zone) (interface{},
 	return nil, http.StatusNoContent
 }
 
+// removeContainedZone removes the link to the given zone from the zones contained by zone
+func removeContainedZone(zone *model.Zone, zoneOID string) {
+	if zone.Links == nil {
+		return
+	}
+	for i := 0; i < len(zone.Links.ContainsZones); i++ {
+		if zone.Links.ContainsZones[i].Oid == zoneOID {
+			zone.Links.ContainsZones[i] = zone.Links.ContainsZones[len(zone.Links.ContainsZones)-1] // Copy last element to index i.
+			zone.Links.ContainsZones[len(zone.Links.ContainsZones)-1] = model.Link{}                // Erase last element (write zero value).
+			zone.Links.ContainsZones = zone.Links.ContainsZones[:len(zone.Links.ContainsZones)-1]
+		}
+	}
+	zone.Links.ContainsZonesCount = len(zone.Links.ContainsZones)
+}
+
 func createContract(vrfName, tenantName, description string) (interface{}, int) {
 	contractName := vrfName + "-Con"
 	contractAttributes := aciModels.ContractAttributes{
@@ -899,8 +904,8 @@ func createContract(vrfName, tenantName, description string) (interface{}, int)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	// create the contract subject
 	contractSubjectName := contractName + "-Subject"
@@ -912,16 +917,16 @@ func createContract(vrfName, tenantName, description string) (interface{}, int)
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
 		log.Error(errMsg)
 
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	// create filter for the contract subject
 	err = aciClient.CreateRelationvzRsSubjFiltAttFromContractSubject(subjectResp.BaseAttributes.DistinguishedName, "default")
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	// create vrfContract
 	vzAnyAttributes := aciModels.AnyAttributes{
@@ -931,21 +936,21 @@ func createContract(vrfName, tenantName, description string) (interface{}, int)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
 		log.Error(errMsg)
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	// relate VRF contract consumer
 	err = aciClient.CreateRelationvzRsAnyToConsFromAny(vzAnyresp.BaseAttributes.DistinguishedName, contractName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	err = aciClient.CreateRelationvzRsAnyToProvFromAny(vzAnyresp.BaseAttributes.DistinguishedName, contractName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -962,15 +967,15 @@ func createStaticPort(epgName, tenantName, applicationProfileName string, aciPol
 	_, err := aciClient.CreateStaticPath(aciPolicyGroupData.PolicyGroupDN, epgName, applicationProfileName, tenantName, "", staticPathAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	// Attach the domain entity profile to given policy group
 	err = aciClient.CreateRelationinfraRsAttEntPFromPCVPCInterfacePolicyGroup(aciPolicyGroupData.PCVPCPolicyGroupDN, domainData.DomainEntityProfileDn)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusCreated
 }
@@ -984,8 +989,8 @@ func createACIDomain(addressPoolData *model.AddressPool, zoneName string) (inter
 	physDomResp, err := aciClient.CreatePhysicalDomain(domainName, "", physicalDomainAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	// createVLANpool
 	vlanPoolAttributes := aciModels.VLANPoolAttributes{
@@ -995,8 +1000,8 @@ func createACIDomain(addressPoolData *model.AddressPool, zoneName string) (inter
 	vlanPoolResp, err := aciClient.CreateVLANPool(vlanPoolAttributes.AllocMode, vlanPoolAttributes.Name, "", vlanPoolAttributes)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	rangesAttribute := aciModels.RangesAttributes{
 		From:      fmt.Sprintf("vlan-%d", addressPoolData.Ethernet.IPv4.VLANIdentifierAddressRange.Lower),
@@ -1006,14 +1011,14 @@ func createACIDomain(addressPoolData *model.AddressPool, zoneName string) (inter
 	_, err = aciClient.CreateRanges(rangesAttribute.To, rangesAttribute.From, rangesAttribute.AllocMode, vlanPoolAttributes.Name, "", rangesAttribute)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	err = aciClient.CreateRelationinfraRsVlanNsFromPhysicalDomain(physDomResp.BaseAttributes.DistinguishedName, vlanPoolResp.BaseAttributes.DistinguishedName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	//CreateDomainEntityProfile for the given Domain
 	entityProfileAttribute := aciModels.AttachableAccessEntityProfileAttributes{
@@ -1022,8 +1027,8 @@ func createACIDomain(addressPoolData *model.AddressPool, zoneName string) (inter
 	entityProfileResp, err := aciClient.CreateAttachableAccessEntityProfile(entityProfileAttribute.Name, "", entityProfileAttribute)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest, nil
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode, nil
 	}
 	err = aciClient.CreateRelationinfraRsDomPFromAttachableAccessEntityProfile(entityProfileResp.BaseAttributes.DistinguishedName, physDomResp.BaseAttributes.DistinguishedName)
 	return nil, http.StatusCreated, &capdata.ACIDomainData{
@@ -1052,12 +1057,15 @@ func UpdateZoneData(ctx iris.Context) {
 
 	//TODO: Get list of zones which are pre-populated from onstart and compare the members for item not present in odim but present in ACI
 
-	zoneData, err := capmodel.GetZone(fabricID, uri)
+	zoneData, etag, err := capmodel.GetZoneWithETag(fabricID, uri)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to fetch zone data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", uri})
 		return
 	}
+	if !checkIfMatch(ctx, uri, etag) {
+		return
+	}
 	if zoneData.ZoneType != "ZoneOfEndpoints" {
 		ctx.StatusCode(http.StatusMethodNotAllowed)
 		resp := updateErrorResponse(response.ActionNotSupported, "", []interface{}{ctx.Request().Method})
@@ -1187,12 +1195,16 @@ func UpdateZoneData(ctx iris.Context) {
 		}
 		delete(endPointData, endpointOID)
 	}
+	if locksLost(ctx) {
+		return
+	}
 	zoneData.Links.Endpoints = zoneRequest.Links.Endpoints
-	if err = updatezoneData(fabricID, uri, &zoneData); err != nil {
+	if etag, err = capmodel.UpdateZoneIfMatch(fabricID, uri, etag, &zoneData); err != nil {
 		errMsg := fmt.Sprintf("failed to update zone data for uri %s: %s", uri, err.Error())
 		createDbErrResp(ctx, err, errMsg, []interface{}{"Zone", uri})
 		return
 	}
+	ctx.Header("ETag", etag)
 	ctx.StatusCode(http.StatusOK)
 	ctx.JSON(zoneData)
 }
@@ -1202,8 +1214,8 @@ func deleteStaticPort(policyGroupDN, epgName, tenantName, applicationProfileName
 	err := aciClient.DeleteStaticPath(policyGroupDN, epgName, applicationProfileName, tenantName)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusOK
 }
@@ -1213,16 +1225,12 @@ func deleteRelationDomainEntityGroupInterfacePolicyGroup(policyGroupDN string) (
 	err := aciClient.DeleteRelationinfraRsAttEntPFromPCVPCInterfacePolicyGroup(policyGroupDN)
 	if err != nil {
 		errMsg := "Error while creating  Zone of Zones: " + err.Error()
-		resp := updateErrorResponse(response.GeneralError, errMsg, nil)
-		return resp, http.StatusBadRequest
+		resp, statusCode := createACIErrResp(err, errMsg, http.StatusBadRequest)
+		return resp, statusCode
 	}
 	return nil, http.StatusOK
 }
 
-func updatezoneData(fabricID, zoneOID string, zoneData *model.Zone) error {
-	return capmodel.UpdateZone(fabricID, zoneOID, zoneData)
-}
-
 func checkEndpointExits(enpointURL string, zonesData map[string]model.Zone, zoneofZoneData model.Zone, zoeURL string) bool {
 	for i := 0; i < len(zoneofZoneData.Links.ContainsZones); i++ {
 		if zoneofZoneData.Links.ContainsZones[i].Oid != zoeURL {
@@ -0,0 +1,39 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmiddleware ...
+package capmiddleware
+
+import (
+	"net/http"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
+)
+
+// APICAvailability fails the requests modifying the fabric right away while the APIC is known to be down,
+// GET requests are let through as they are served from the stored data
+func APICAvailability(ctx iris.Context) {
+	if ctx.Method() != http.MethodGet {
+		if open, retryAfter := caputilities.IsAPICCircuitBreakerOpen(); open {
+			errMsg := "APIC is temporarily unavailable, " + ctx.Method() + " on " + ctx.Path() + " is not attempted"
+			log.Error(errMsg)
+			capresponse.SetServiceTemporarilyUnavailableResponse(ctx, retryAfter, errMsg)
+			return
+		}
+	}
+	ctx.Next()
+}
@@ -0,0 +1,65 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmiddleware ...
+package capmiddleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/capresponse"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	iris "github.com/kataras/iris/v12"
+	log "github.com/sirupsen/logrus"
+)
+
+// fabricLocksKey is the key of the request value holding the fabric lock of the request
+const fabricLocksKey = "fabricLocks"
+
+// FabricLock serializes the requests modifying a fabric across all the plugin instances,
+// GET requests are let through as they only read the stored data
+func FabricLock(ctx iris.Context) {
+	fabricID := ctx.Params().Get("id")
+	if ctx.Method() == http.MethodGet || fabricID == "" {
+		ctx.Next()
+		return
+	}
+	locks, err := capmodel.AcquireLocks(ctx.Method()+" "+ctx.Path(), capmodel.FabricLockName(fabricID))
+	if err != nil {
+		errMsg := "failed to lock fabric " + fabricID + " for " + ctx.Method() + " on " + ctx.Path() + ": " + err.Error()
+		log.Error(errMsg)
+		switch {
+		case errors.Is(err, capmodel.ErrorLockTimeout):
+			capresponse.SetServiceTemporarilyUnavailableResponse(ctx, capmodel.LockWaitTime(), errMsg)
+		case errors.Is(err, db.ErrorServiceUnavailable):
+			capresponse.SetErrorResponse(ctx, http.StatusServiceUnavailable, response.CouldNotEstablishConnection, errMsg, nil)
+		default:
+			capresponse.SetErrorResponse(ctx, http.StatusInternalServerError, response.InternalError, errMsg, nil)
+		}
+		return
+	}
+	defer locks.Release()
+	ctx.Values().Set(fabricLocksKey, locks)
+	ctx.Next()
+}
+
+// FabricLocks returns the fabric lock held for the request, it is nil when the request holds none.
+// The handlers check that it wasn't lost before storing their changes
+func FabricLocks(ctx iris.Context) *capmodel.HeldLocks {
+	locks, _ := ctx.Values().Get(fabricLocksKey).(*capmodel.HeldLocks)
+	return locks
+}
@@ -0,0 +1,214 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The classes of the access policies giving the policy group of the ports of the leaves,
+// a leaf profile selects the nodes with its node blocks and relates to the interface profiles
+// whose access port selectors select the ports with their port blocks
+const (
+	NodeBlockClass                = "infraNodeBlk"
+	InterfaceProfileRelationClass = "infraRsAccPortP"
+	PortBlockClass                = "infraPortBlk"
+	SubPortBlockClass             = "infraSubPortBlk"
+	PortSelectorGroupClass        = "infraRsAccBaseGrp"
+)
+
+// MTUPolicyRelationTarget is the attribute of infraRsL2InstPol naming the MTU policy
+const MTUPolicyRelationTarget = "tnL2InstPolName"
+
+// DefaultMTUPolicyName is the MTU policy of the policy groups which don't relate to any
+const DefaultMTUPolicyName = "default"
+
+// policyGroupRelationTargets are the relations of the access port policy groups to the AEP and to the
+// interface policies, by their class, along with the attribute giving their target
+var policyGroupRelationTargets = map[string]string{
+	"infraRsAttEntP":           "tDn",
+	"infraRsHIfPol":            "tnFabricHIfPolName",
+	"infraRsCdpIfPol":          "tnCdpIfPolName",
+	"infraRsLldpIfPol":         "tnLldpIfPolName",
+	"infraRsMcpIfPol":          "tnMcpIfPolName",
+	"infraRsStpIfPol":          "tnStpIfPolName",
+	"infraRsL2IfPol":           "tnL2IfPolName",
+	"infraRsStormctrlIfPol":    "tnStormctrlIfPolName",
+	"infraRsMonIfInfraPol":     "tnMonInfraPolName",
+	"infraRsL2PortSecurityPol": "tnL2PortSecurityPolName",
+	"infraRsQosPfcIfPol":       "tnQosPfcIfPolName",
+	MTUPolicyRelationClass:     MTUPolicyRelationTarget,
+}
+
+// PolicyGroupRelationClasses returns the classes of the relations of the access port policy groups
+func PolicyGroupRelationClasses() []string {
+	classNames := make([]string, 0, len(policyGroupRelationTargets))
+	for className := range policyGroupRelationTargets {
+		classNames = append(classNames, className)
+	}
+	sort.Strings(classNames)
+	return classNames
+}
+
+// AccessPolicyResponse holds the access policy objects returned by a query, the objects
+// of any class are kept with their attributes
+type AccessPolicyResponse struct {
+	TotalCount string                          `json:"totalCount"`
+	IMData     []map[string]AccessPolicyObject `json:"imdata"`
+}
+
+// AccessPolicyObject is an access policy object
+type AccessPolicyObject struct {
+	Attributes map[string]string `json:"attributes"`
+}
+
+// Validate drops the objects without a dn, they are logged and skipped so that a bad object
+// doesn't fail the lookup of the policies of the port
+func (a *AccessPolicyResponse) Validate() error {
+	valid := a.IMData[:0]
+	for _, imdata := range a.IMData {
+		if len(imdata) != 1 {
+			log.Warn(fmt.Sprintf("skipping the access policy: %v: expected a single object, got %d", ErrorInvalidACIObject, len(imdata)))
+			continue
+		}
+		var err error
+		for className, object := range imdata {
+			err = requireACIAttributes(className, object.Attributes["dn"], "dn", object.Attributes["dn"])
+		}
+		if err != nil {
+			log.Warn("skipping the access policy: " + err.Error())
+			continue
+		}
+		valid = append(valid, imdata)
+	}
+	a.IMData = valid
+	return nil
+}
+
+// Objects returns the objects of the class
+func (a *AccessPolicyResponse) Objects(className string) []AccessPolicyObject {
+	var objects []AccessPolicyObject
+	for _, imdata := range a.IMData {
+		if object, ok := imdata[className]; ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// PolicyGroupRelations returns the relations of the access port policy group of the given dn
+// to its AEP and to its interface policies
+func (a *AccessPolicyResponse) PolicyGroupRelations(policyGroupDN string) []*ACIRelation {
+	var relations []*ACIRelation
+	for _, imdata := range a.IMData {
+		for className, object := range imdata {
+			targetAttribute, ok := policyGroupRelationTargets[className]
+			if !ok || !strings.HasPrefix(object.Attributes["dn"], policyGroupDN+"/") {
+				continue
+			}
+			relations = append(relations, &ACIRelation{
+				ClassName:       className,
+				DN:              object.Attributes["dn"],
+				TargetAttribute: targetAttribute,
+				Target:          object.Attributes[targetAttribute],
+			})
+		}
+	}
+	return relations
+}
+
+// DNParent returns the part of the dn before the child of the given rn prefix,
+// it is empty when the dn has no such child
+func DNParent(dn, rnPrefix string) string {
+	index := strings.Index(dn, "/"+rnPrefix)
+	if index < 0 {
+		return ""
+	}
+	return dn[:index]
+}
+
+// ContainsNode tells if the node is in the range of the infraNodeBlk
+func (o *AccessPolicyObject) ContainsNode(nodeID int) bool {
+	return o.inRange("from_", "to_", nodeID)
+}
+
+// ContainsPort tells if the port is in the range of the infraPortBlk, or of the infraSubPortBlk
+// when the port is a breakout port
+func (o *AccessPolicyObject) ContainsPort(port PortNumbers) bool {
+	if !o.inRange("fromCard", "toCard", port.Card) || !o.inRange("fromPort", "toPort", port.Port) {
+		return false
+	}
+	return port.SubPort == 0 || o.inRange("fromSubPort", "toSubPort", port.SubPort)
+}
+
+func (o *AccessPolicyObject) inRange(fromAttribute, toAttribute string, value int) bool {
+	from, err := strconv.Atoi(o.Attributes[fromAttribute])
+	if err != nil {
+		return false
+	}
+	to, err := strconv.Atoi(o.Attributes[toAttribute])
+	if err != nil {
+		return false
+	}
+	return from <= value && value <= to
+}
+
+// PortNumbers are the card, the port and the sub port of a breakout port,
+// SubPort is 0 for the other ports
+type PortNumbers struct {
+	Card    int
+	Port    int
+	SubPort int
+}
+
+// ParsePortNumbers parses the id of a port like eth1/10 or eth1/49/1
+func ParsePortNumbers(portID string) (PortNumbers, error) {
+	var numbers PortNumbers
+	parts := strings.Split(strings.TrimPrefix(portID, "eth"), "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return numbers, fmt.Errorf("malformed port id %s", portID)
+	}
+	values := make([]int, len(parts))
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return numbers, fmt.Errorf("malformed port id %s", portID)
+		}
+		values[i] = value
+	}
+	numbers.Card, numbers.Port = values[0], values[1]
+	if len(values) == 3 {
+		numbers.SubPort = values[2]
+	}
+	return numbers, nil
+}
+
+// IsBundlePolicyGroup tells if the policy group of the dn is the infraAccBndlGrp of a port channel or vPC
+func IsBundlePolicyGroup(policyGroupDN string) bool {
+	return strings.HasPrefix(policyGroupDN, "uni/infra/funcprof/accbundle-")
+}
+
+// RebaseRelation returns the relation of the policy group of the given dn to the target of
+// the relation of another policy group
+func RebaseRelation(relation *ACIRelation, fromDN, toDN string) *ACIRelation {
+	rebased := *relation
+	rebased.DN = toDN + strings.TrimPrefix(relation.DN, fromDN)
+	return &rebased
+}
@@ -0,0 +1,83 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAccessPolicyPortSelection(t *testing.T) {
+	blocks := map[string]AccessPolicyObject{
+		"ports": {Attributes: map[string]string{"fromCard": "1", "toCard": "1", "fromPort": "1", "toPort": "10"}},
+		"breakout": {Attributes: map[string]string{
+			"fromCard": "1", "toCard": "1", "fromPort": "49", "toPort": "49", "fromSubPort": "1", "toSubPort": "2",
+		}},
+	}
+	tests := []struct {
+		portID string
+		block  string
+		want   bool
+	}{
+		{"eth1/1", "ports", true},
+		{"eth1/10", "ports", true},
+		{"eth1/11", "ports", false},
+		{"eth2/1", "ports", false},
+		{"eth1/49/2", "breakout", true},
+		{"eth1/49/3", "breakout", false},
+	}
+	for _, tt := range tests {
+		port, err := ParsePortNumbers(tt.portID)
+		if err != nil {
+			t.Fatalf("ParsePortNumbers(%s) failed: %v", tt.portID, err)
+		}
+		block := blocks[tt.block]
+		if got := block.ContainsPort(port); got != tt.want {
+			t.Errorf("ContainsPort(%s) of the %s block = %v, want %v", tt.portID, tt.block, got, tt.want)
+		}
+	}
+	if _, err := ParsePortNumbers("po1"); err == nil {
+		t.Errorf("ParsePortNumbers should fail on a port channel")
+	}
+}
+
+func TestPolicyGroupRelations(t *testing.T) {
+	var response AccessPolicyResponse
+	err := json.Unmarshal([]byte(`{"totalCount": "4", "imdata": [
+		{"infraRsAttEntP": {"attributes": {"dn": "uni/infra/funcprof/accportgrp-servers/rsattEntP", "tDn": "uni/infra/attentp-servers"}}},
+		{"infraRsCdpIfPol": {"attributes": {"dn": "uni/infra/funcprof/accportgrp-servers/rscdpIfPol", "tnCdpIfPolName": "cdp-on"}}},
+		{"infraRsAttEntP": {"attributes": {"tDn": "uni/infra/attentp-other"}}},
+		{"infraRsCdpIfPol": {"attributes": {"dn": "uni/infra/funcprof/accportgrp-other/rscdpIfPol", "tnCdpIfPolName": "cdp-off"}}}
+	]}`), &response)
+	if err == nil {
+		err = response.Validate()
+	}
+	if err != nil {
+		t.Fatalf("failed to decode the access policies: %v", err)
+	}
+	want := []*ACIRelation{
+		{ClassName: "infraRsAttEntP", DN: "uni/infra/funcprof/accportgrp-servers/rsattEntP", TargetAttribute: "tDn", Target: "uni/infra/attentp-servers"},
+		{ClassName: "infraRsCdpIfPol", DN: "uni/infra/funcprof/accportgrp-servers/rscdpIfPol", TargetAttribute: "tnCdpIfPolName", Target: "cdp-on"},
+	}
+	relations := response.PolicyGroupRelations("uni/infra/funcprof/accportgrp-servers")
+	if !reflect.DeepEqual(relations, want) {
+		t.Errorf("PolicyGroupRelations() = %v, want %v", relations, want)
+	}
+	rebased := RebaseRelation(relations[0], "uni/infra/funcprof/accportgrp-servers", "uni/infra/funcprof/accportgrp-ODIM-port")
+	if rebased.DN != "uni/infra/funcprof/accportgrp-ODIM-port/rsattEntP" || rebased.Target != "uni/infra/attentp-servers" {
+		t.Errorf("RebaseRelation() = %v", rebased)
+	}
+}
@@ -26,7 +26,34 @@ type HealthIMData struct {
 	HealthData HealthData `json:"healthInst"`
 }
 
-// HealthData ...
+// HealthData is the healthInst object of an ACI entity
 type HealthData struct {
-	Attributes map[string]interface{} `json:"attributes"`
+	Attributes HealthAttributes `json:"attributes"`
+}
+
+// HealthAttributes are the attributes of healthInst and fabricHealthTotal objects
+type HealthAttributes struct {
+	DN     string `json:"dn"`
+	Cur    string `json:"cur"`
+	Prev   string `json:"prev"`
+	MaxSev string `json:"maxSev"`
+	Chng   string `json:"chng"`
+	UpdTs  string `json:"updTs"`
+}
+
+// Validate checks that all the health objects are valid
+func (h *Health) Validate() error {
+	for _, imdata := range h.IMData {
+		if err := imdata.HealthData.Attributes.validate("healthInst"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HealthAttributes) validate(className string) error {
+	if err := requireACIAttributes(className, h.DN, "cur", h.Cur); err != nil {
+		return err
+	}
+	return requireNumericACIAttribute(className, h.DN, "cur", h.Cur)
 }
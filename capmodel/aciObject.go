@@ -0,0 +1,48 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Using below variables as part of errors will enabling errors.Is() function
+var (
+	// ErrorInvalidACIObject is for identifing the APIC objects with missing or malformed attributes
+	ErrorInvalidACIObject = errors.New("Invalid ACI object")
+	// ErrorACIObjectNotFound is for identifing the APIC queries which didn't return any object
+	ErrorACIObjectNotFound = errors.New("ACI object not found")
+)
+
+// requireACIAttributes checks that all the given attributes of the object are set,
+// attributes are passed as name and value pairs
+func requireACIAttributes(className, dn string, attributes ...string) error {
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if attributes[i+1] == "" {
+			return fmt.Errorf("%w: %s %s has no %s attribute", ErrorInvalidACIObject, className, dn, attributes[i])
+		}
+	}
+	return nil
+}
+
+// requireNumericACIAttribute checks that the attribute of the object holds an integer
+func requireNumericACIAttribute(className, dn, name, value string) error {
+	if _, err := strconv.Atoi(value); err != nil {
+		return fmt.Errorf("%w: %s %s has non numeric %s attribute %q", ErrorInvalidACIObject, className, dn, name, value)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateACIObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    interface{ Validate() error }
+		body    string
+		wantErr bool
+	}{
+		{
+			name: "valid l1PhysIf",
+			data: &PortCollectionResponse{},
+			body: `{"imdata":[{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]","id":"eth1/1","mtu":"9000"}}}]}`,
+		},
+		{
+			name: "l1PhysIf without mtu is skipped",
+			data: &PortCollectionResponse{},
+			body: `{"imdata":[{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]","id":"eth1/1"}}}]}`,
+		},
+		{
+			name: "l1PhysIf with non numeric mtu is skipped",
+			data: &PortCollectionResponse{},
+			body: `{"imdata":[{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]","id":"eth1/1","mtu":"inherit"}}}]}`,
+		},
+		{
+			name: "valid ethpmPhysIf",
+			data: &PortInfoResponse{},
+			body: `{"imdata":[{"ethpmPhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]/phys","operSt":"down","operSpeed":"unknown"}}}]}`,
+		},
+		{
+			name:    "ethpmPhysIf without operSt",
+			data:    &PortInfoResponse{},
+			body:    `{"imdata":[{"ethpmPhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]/phys","operSpeed":"10G"}}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "valid healthInst",
+			data: &Health{},
+			body: `{"imdata":[{"healthInst":{"attributes":{"dn":"topology/pod-1/node-101/sys/health","cur":"96"}}}]}`,
+		},
+		{
+			name:    "healthInst with non numeric cur",
+			data:    &Health{},
+			body:    `{"imdata":[{"healthInst":{"attributes":{"dn":"topology/pod-1/node-101/sys/health","cur":"n/a"}}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "fabricHealthTotal without cur",
+			data:    &FabricHealth{},
+			body:    `{"imdata":[{"fabricHealthTotal":{"attributes":{"dn":"topology/pod-1/health"}}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "eqptCh without serial number",
+			data:    &SwitchChassis{},
+			body:    `{"imdata":[{"eqptCh":{"attributes":{"dn":"topology/pod-1/node-101/sys/ch","id":"1","model":"N9K-C93180YC-FX"}}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "valid fabricNode",
+			data: &FabricNodeResponse{},
+			body: `{"imdata":[{"fabricNode":{"attributes":{"dn":"topology/pod-1/node-101","id":"101","role":"leaf"}}}]}`,
+		},
+		{
+			name:    "fabricNode without role",
+			data:    &FabricNodeResponse{},
+			body:    `{"imdata":[{"fabricNode":{"attributes":{"dn":"topology/pod-1/node-101","id":"101"}}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "empty response",
+			data: &Health{},
+			body: `{"totalCount":"0","imdata":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.body), tt.data); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", tt.body, err)
+			}
+			err := tt.data.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrorInvalidACIObject) {
+				t.Errorf("Validate() error = %v, want ErrorInvalidACIObject", err)
+			}
+		})
+	}
+}
+
+func TestValidateSkipsInvalidPorts(t *testing.T) {
+	body := `{"imdata":[
+		{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]","id":"eth1/1","mtu":"9000"}}},
+		{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/2]","id":"eth1/2","mtu":"inherit"}}},
+		{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/3]","mtu":"9000"}}},
+		{"l1PhysIf":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/4]","id":"eth1/4","mtu":"1500"}}}
+	]}`
+	var ports PortCollectionResponse
+	if err := json.Unmarshal([]byte(body), &ports); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", body, err)
+	}
+	if err := ports.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, the invalid ports are expected to be skipped", err)
+	}
+	var ids []string
+	for _, imdata := range ports.IMData {
+		ids = append(ids, imdata.PhysicalInterface.Attributes.ID)
+	}
+	if len(ids) != 2 || ids[0] != "eth1/1" || ids[1] != "eth1/4" {
+		t.Errorf("Validate() kept ports %v, want eth1/1 and eth1/4", ids)
+	}
+}
+
+func TestValidateSkipsInvalidPortStats(t *testing.T) {
+	body := `{"imdata":[
+		{"rmonIfIn":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/1]/dbgIfIn","octets":"10","ucastPkts":"1","multicastPkts":"0","broadcastPkts":"0","discards":"0","errors":"0"}}},
+		{"rmonIfIn":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/2]/dbgIfIn","octets":"n/a","ucastPkts":"1","multicastPkts":"0","broadcastPkts":"0","discards":"0","errors":"0"}}},
+		{"eqptIngrTotal5min":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/3]/CDeqptIngrTotal5min","utilAvg":""}}},
+		{"eqptIngrTotal5min":{"attributes":{"dn":"topology/pod-1/node-101/sys/phys-[eth1/4]/CDeqptIngrTotal5min","utilAvg":"0.5"}}}
+	]}`
+	var stats PortStatsResponse
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", body, err)
+	}
+	if err := stats.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, the invalid stats are expected to be skipped", err)
+	}
+	if len(stats.IMData) != 2 || stats.IMData[0].IfIn == nil || stats.IMData[1].Ingress == nil ||
+		PhysIfDN(stats.IMData[0].IfIn.Attributes.DN) != "topology/pod-1/node-101/sys/phys-[eth1/1]" ||
+		PhysIfDN(stats.IMData[1].Ingress.Attributes.DN) != "topology/pod-1/node-101/sys/phys-[eth1/4]" {
+		t.Errorf("Validate() kept unexpected stats %+v", stats.IMData)
+	}
+}
+
+func TestValidateSkipsInvalidSwitchUsages(t *testing.T) {
+	body := `{"imdata":[
+		{"procSysCPU5min":{"attributes":{"dn":"topology/pod-1/node-101/sys/procsys/CDprocSysCPU5min","userAvg":"n/a","kernelAvg":"4"}}},
+		{"procSysCPU5min":{"attributes":{"dn":"topology/pod-1/node-102/sys/procsys/CDprocSysCPU5min","userAvg":"10.5","kernelAvg":"4"}}},
+		{"procSysMem5min":{"attributes":{"dn":"topology/pod-1/node-101/sys/procsys/CDprocSysMem5min","usedAvg":"4000","totalAvg":""}}},
+		{"procSysMem5min":{"attributes":{"dn":"topology/pod-1/node-102/sys/procsys/CDprocSysMem5min","usedAvg":"4000","totalAvg":"16000"}}}
+	]}`
+	var usages ProcSysResponse
+	if err := json.Unmarshal([]byte(body), &usages); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", body, err)
+	}
+	if err := usages.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, the invalid usages are expected to be skipped", err)
+	}
+	if len(usages.IMData) != 2 || usages.IMData[0].CPU == nil || usages.IMData[1].Memory == nil ||
+		usages.IMData[0].CPU.Attributes.DN != "topology/pod-1/node-102/sys/procsys/CDprocSysCPU5min" ||
+		usages.IMData[1].Memory.Attributes.DN != "topology/pod-1/node-102/sys/procsys/CDprocSysMem5min" {
+		t.Errorf("Validate() kept unexpected usages %+v", usages.IMData)
+	}
+}
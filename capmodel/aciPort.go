@@ -16,10 +16,14 @@ package capmodel
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	dmtf "github.com/ODIM-Project/ODIM/lib-dmtf/model"
 	"github.com/ODIM-Project/PluginCiscoACI/db"
+	log "github.com/sirupsen/logrus"
 )
 
 // PortCollectionResponse ...
@@ -33,9 +37,23 @@ type PortCollectionIMData struct {
 	PhysicalInterface PhysicalInterface `json:"l1PhysIf"`
 }
 
-// PhysicalInterface ...
+// PhysicalInterface is the l1PhysIf object holding the configuration of a port
 type PhysicalInterface struct {
-	Attributes map[string]interface{} `json:"attributes"`
+	Attributes PhysicalInterfaceAttributes `json:"attributes"`
+}
+
+// PhysicalInterfaceAttributes are the attributes of l1PhysIf object
+type PhysicalInterfaceAttributes struct {
+	DN      string `json:"dn"`
+	ID      string `json:"id"`
+	AdminSt string `json:"adminSt"`
+	AutoNeg string `json:"autoNeg"`
+	Descr   string `json:"descr"`
+	Layer   string `json:"layer"`
+	Mode    string `json:"mode"`
+	Mtu     string `json:"mtu"`
+	Speed   string `json:"speed"`
+	Usage   string `json:"usage"`
 }
 
 // PortInfoResponse ...
@@ -46,20 +64,273 @@ type PortInfoResponse struct {
 
 // PortInfoIMData ...
 type PortInfoIMData struct {
-	PhysicalInterface PhysicalInterface `json:"ethpmPhysIf"`
+	PortInfo PortInfo `json:"ethpmPhysIf"`
+}
+
+// PortInfo is the ethpmPhysIf object holding the operational state of a port
+type PortInfo struct {
+	Attributes PortInfoAttributes `json:"attributes"`
+}
+
+// PortInfoAttributes are the attributes of ethpmPhysIf object
+type PortInfoAttributes struct {
+	DN           string `json:"dn"`
+	OperSt       string `json:"operSt"`
+	OperStQual   string `json:"operStQual"`
+	OperSpeed    string `json:"operSpeed"`
+	OperDuplex   string `json:"operDuplex"`
+	OperMode     string `json:"operMode"`
+	BackplaneMac string `json:"backplaneMac"`
+}
+
+// IsUp tells if the link of the port is up
+func (a *PortInfoAttributes) IsUp() bool {
+	return a.OperSt == "up"
+}
+
+// IsAdminDown tells if the port is administratively down, that is taken out of service
+func (a *PortInfoAttributes) IsAdminDown() bool {
+	return a.OperStQual == "admin-down"
+}
+
+// OutOfServicePathClass is the class of the objects taking the ports out of service
+const OutOfServicePathClass = "fabricRsOosPath"
+
+// OutOfServicePath is the fabricRsOosPath object blacklisting the path of a port,
+// the port is out of service as long as the object exists
+type OutOfServicePath struct {
+	// TDn is the dn of the path of the port
+	TDn string
+}
+
+// NewOutOfServicePath returns the fabricRsOosPath of the port of the node
+func NewOutOfServicePath(podID, nodeID, portID string) *OutOfServicePath {
+	return &OutOfServicePath{
+		TDn: fmt.Sprintf("topology/pod-%s/paths-%s/pathep-[%s]", podID, nodeID, portID),
+	}
+}
+
+// DN is the dn of the fabricRsOosPath object
+func (o *OutOfServicePath) DN() string {
+	return "uni/fabric/outofsvc/rsoosPath-[" + o.TDn + "]"
+}
+
+// ToMap returns the attributes of the fabricRsOosPath object posted to APIC
+func (o *OutOfServicePath) ToMap() (map[string]string, error) {
+	return map[string]string{
+		"classname": OutOfServicePathClass,
+		"dn":        o.DN(),
+		"tDn":       o.TDn,
+		"lc":        "blacklist",
+	}, nil
+}
+
+// The classes of the objects configuring a port through an interface override
+const (
+	MTUPolicyClass               = "l2InstPol"
+	MTUPolicyRelationClass       = "infraRsL2InstPol"
+	InterfaceOverrideClass       = "infraHPathS"
+	InterfaceOverridePathClass   = "infraRsHPathAtt"
+	InterfaceOverrideGroupClass  = "infraRsPathToAccBaseGrp"
+	PortPolicyGroupClass         = "infraAccPortGrp"
+	LinkLevelPolicyRelationClass = "infraRsHIfPol"
+)
+
+// The settings of the link level policies
+const (
+	LinkLevelPolicyAutoNegOn    = "on"
+	LinkLevelPolicyAutoNegOff   = "off"
+	LinkLevelPolicyInheritSpeed = "inherit"
+)
+
+// odimInterfacePolicyPrefix prefixes the names of the interface policies created by the plugin
+const odimInterfacePolicyPrefix = "ODIM-"
+
+// MTUPolicy is the l2InstPol object giving the MTU of the ports of the policy groups related to it,
+// the policies are shared by the ports having the same MTU
+type MTUPolicy struct {
+	Name string
+	MTU  int
+}
+
+// NewMTUPolicy returns the l2InstPol of the given MTU
+func NewMTUPolicy(mtu int) *MTUPolicy {
+	return &MTUPolicy{
+		Name: fmt.Sprintf("%sMTU-%d", odimInterfacePolicyPrefix, mtu),
+		MTU:  mtu,
+	}
+}
+
+// DN is the dn of the l2InstPol object
+func (m *MTUPolicy) DN() string {
+	return MTUPolicyDN(m.Name)
+}
+
+// MTUPolicyDN is the dn of the l2InstPol of the given name
+func MTUPolicyDN(name string) string {
+	return "uni/fabric/l2pol-" + name
+}
+
+// ToMap returns the attributes of the l2InstPol object posted to APIC
+func (m *MTUPolicy) ToMap() (map[string]string, error) {
+	return map[string]string{
+		"classname": MTUPolicyClass,
+		"dn":        m.DN(),
+		"name":      m.Name,
+		"fabricMtu": strconv.Itoa(m.MTU),
+	}, nil
+}
+
+// Relation returns the relation of the access port policy group of the given dn to the l2InstPol,
+// the relation is named after the policy
+func (m *MTUPolicy) Relation(policyGroupDN string) *ACIRelation {
+	return &ACIRelation{
+		ClassName:       MTUPolicyRelationClass,
+		DN:              policyGroupDN + "/rsl2InstPol",
+		TargetAttribute: "tnL2InstPolName",
+		Target:          m.Name,
+	}
+}
+
+// LinkLevelPolicyName is the name of the fabricHIfPol shared by the ports having the same speed and autonegotiation
+func LinkLevelPolicyName(speed, autoNeg string) string {
+	return fmt.Sprintf("%sLinkLevel-%s-AutoNeg-%s", odimInterfacePolicyPrefix, speed, autoNeg)
+}
+
+// InterfaceOverride is the infraHPathS object overriding the policies of a single port,
+// the port takes the policies of PolicyGroupName when it is set. Description is left
+// as it is on APIC when it is nil
+type InterfaceOverride struct {
+	Name            string
+	PathDN          string
+	Description     *string
+	PolicyGroupName string
+}
+
+// NewInterfaceOverride returns the infraHPathS of the port of the node
+func NewInterfaceOverride(podID, nodeID, portID string) *InterfaceOverride {
+	return &InterfaceOverride{
+		Name:   PortPolicyName(podID, nodeID, portID),
+		PathDN: fmt.Sprintf("topology/pod-%s/paths-%s/pathep-[%s]", podID, nodeID, portID),
+	}
+}
+
+// PortPolicyName is the name of the interface override and of the policy group of the port,
+// the slashes of the port are not allowed in the names
+func PortPolicyName(podID, nodeID, portID string) string {
+	return fmt.Sprintf("%sPod%s-Node%s-%s", odimInterfacePolicyPrefix, podID, nodeID, strings.ReplaceAll(portID, "/", "-"))
+}
+
+// PortPolicyGroupDN is the dn of the infraAccPortGrp of the given name
+func PortPolicyGroupDN(name string) string {
+	return "uni/infra/funcprof/accportgrp-" + name
+}
+
+// DN is the dn of the infraHPathS object
+func (o *InterfaceOverride) DN() string {
+	return "uni/infra/hpaths-" + o.Name
+}
+
+// ToMap returns the attributes of the infraHPathS object posted to APIC
+func (o *InterfaceOverride) ToMap() (map[string]string, error) {
+	attributes := map[string]string{
+		"classname": InterfaceOverrideClass,
+		"dn":        o.DN(),
+		"name":      o.Name,
+	}
+	if o.Description != nil {
+		attributes["descr"] = *o.Description
+	}
+	return attributes, nil
+}
+
+// Relations returns the relations of the infraHPathS object to the path of the port and to its policy group
+func (o *InterfaceOverride) Relations() []*ACIRelation {
+	relations := []*ACIRelation{
+		{ClassName: InterfaceOverridePathClass, DN: o.DN() + "/rsHPathAtt-[" + o.PathDN + "]", TargetAttribute: "tDn", Target: o.PathDN},
+	}
+	if o.PolicyGroupName != "" {
+		relations = append(relations, &ACIRelation{
+			ClassName:       InterfaceOverrideGroupClass,
+			DN:              o.GroupRelationDN(),
+			TargetAttribute: "tDn",
+			Target:          PortPolicyGroupDN(o.PolicyGroupName),
+		})
+	}
+	return relations
+}
+
+// GroupRelationDN is the dn of the relation of the infraHPathS object to the policy group of the port
+func (o *InterfaceOverride) GroupRelationDN() string {
+	return o.DN() + "/rspathToAccBaseGrp"
+}
+
+// ACIRelation is a relation object of an ACI object, the target object is given by the value
+// of TargetAttribute like the tDn or the name of the target
+type ACIRelation struct {
+	ClassName       string
+	DN              string
+	TargetAttribute string
+	Target          string
+}
+
+// ToMap returns the attributes of the relation object posted to APIC
+func (r *ACIRelation) ToMap() (map[string]string, error) {
+	return map[string]string{
+		"classname":       r.ClassName,
+		"dn":              r.DN,
+		r.TargetAttribute: r.Target,
+	}, nil
+}
+
+// Validate drops the l1PhysIf objects with a missing id or a missing or non numeric mtu,
+// they are logged and skipped so that a bad object doesn't fail the ports of the whole switch
+func (p *PortCollectionResponse) Validate() error {
+	valid := p.IMData[:0]
+	for _, imdata := range p.IMData {
+		attributes := imdata.PhysicalInterface.Attributes
+		err := requireACIAttributes("l1PhysIf", attributes.DN, "id", attributes.ID, "mtu", attributes.Mtu)
+		if err == nil {
+			err = requireNumericACIAttribute("l1PhysIf", attributes.DN, "mtu", attributes.Mtu)
+		}
+		if err != nil {
+			log.Warn("skipping the port: " + err.Error())
+			continue
+		}
+		valid = append(valid, imdata)
+	}
+	p.IMData = valid
+	return nil
+}
+
+// Validate checks that all the ethpmPhysIf objects are valid
+func (p *PortInfoResponse) Validate() error {
+	for _, imdata := range p.IMData {
+		attributes := imdata.PortInfo.Attributes
+		if err := requireACIAttributes("ethpmPhysIf", attributes.DN, "operSt", attributes.OperSt, "operSpeed", attributes.OperSpeed); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetPort collects the port data from the DB
 func GetPort(portID string) (*dmtf.Port, error) {
+	port, _, err := GetPortWithETag(portID)
+	return port, err
+}
+
+// GetPortWithETag collects the port data from the DB along with its entity tag
+func GetPortWithETag(portID string) (*dmtf.Port, string, error) {
 	var port dmtf.Port
 	data, err := db.Connector.Get(db.TablePort, portID)
 	if err != nil {
-		return nil, fmt.Errorf("while trying to collect port data, got: %w", err)
+		return nil, "", fmt.Errorf("while trying to collect port data, got: %w", err)
 	}
 	if err = json.Unmarshal([]byte(data), &port); err != nil {
-		return nil, fmt.Errorf("while trying to unmarshal port data, got: %v", err)
+		return nil, "", fmt.Errorf("while trying to unmarshal port data, got: %v", err)
 	}
-	return &port, nil
+	return &port, ETag(data), nil
 }
 
 // GetSwitchPort collects the switch-port data from the DB
@@ -85,7 +356,44 @@ func SaveSwitchPort(switchID string, data []string) error {
 	return SaveToDB(db.TableSwitchPorts, switchID, data)
 }
 
+// UpdateSwitchPort updates the switch-port data stored in the DB
+func UpdateSwitchPort(switchID string, data []string) error {
+	return UpdateDbData(db.TableSwitchPorts, switchID, data)
+}
+
+// DeleteSwitchPort deletes the switch-port data from the DB
+func DeleteSwitchPort(switchID string) error {
+	return db.Connector.Delete(db.TableSwitchPorts, switchID)
+}
+
+// DeletePort deletes the port data from the DB
+func DeletePort(portID string) error {
+	return db.Connector.Delete(db.TablePort, portID)
+}
+
 // UpdatePort updates the port data stored in the DB
 func UpdatePort(portID string, data *dmtf.Port) error {
 	return UpdateDbData(db.TablePort, portID, *data)
 }
+
+// UpdatePortIfMatch updates the port data stored in the DB if its entity tag is still etag
+func UpdatePortIfMatch(portID, etag string, data *dmtf.Port) (string, error) {
+	return UpdateDbDataIfMatch(db.TablePort, portID, etag, *data)
+}
+
+// ModifyPort applies modify on the port data stored in the DB and stores it back,
+// the port is read and modified again when another request updated it in between
+func ModifyPort(portID string, modify func(port *dmtf.Port)) error {
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		port, etag, getErr := GetPortWithETag(portID)
+		if getErr != nil {
+			return getErr
+		}
+		modify(port)
+		if _, err = UpdatePortIfMatch(portID, etag, port); !errors.Is(err, db.ErrorDataChanged) {
+			return err
+		}
+	}
+	return err
+}
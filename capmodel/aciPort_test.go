@@ -65,3 +65,33 @@ func TestGetPort(t *testing.T) {
 		})
 	}
 }
+
+func TestInterfaceOverrideRelations(t *testing.T) {
+	override := NewInterfaceOverride("1", "101", "eth1/10")
+	if got, want := override.DN(), "uni/infra/hpaths-ODIM-Pod1-Node101-eth1-10"; got != want {
+		t.Errorf("DN() = %v, want %v", got, want)
+	}
+	pathRelation := &ACIRelation{
+		ClassName:       InterfaceOverridePathClass,
+		DN:              "uni/infra/hpaths-ODIM-Pod1-Node101-eth1-10/rsHPathAtt-[topology/pod-1/paths-101/pathep-[eth1/10]]",
+		TargetAttribute: "tDn",
+		Target:          "topology/pod-1/paths-101/pathep-[eth1/10]",
+	}
+	if got := override.Relations(); !reflect.DeepEqual(got, []*ACIRelation{pathRelation}) {
+		t.Errorf("Relations() = %v, want only the relation to the path of the port", got)
+	}
+	override.PolicyGroupName = PortPolicyName("1", "101", "eth1/10")
+	groupRelation := &ACIRelation{
+		ClassName:       InterfaceOverrideGroupClass,
+		DN:              "uni/infra/hpaths-ODIM-Pod1-Node101-eth1-10/rspathToAccBaseGrp",
+		TargetAttribute: "tDn",
+		Target:          "uni/infra/funcprof/accportgrp-ODIM-Pod1-Node101-eth1-10",
+	}
+	if got := override.Relations(); !reflect.DeepEqual(got, []*ACIRelation{pathRelation, groupRelation}) {
+		t.Errorf("Relations() = %v, want the relations to the path and to the policy group of the port", got)
+	}
+	attributes, _ := override.ToMap()
+	if _, ok := attributes["descr"]; ok {
+		t.Errorf("ToMap() = %v, the description is not expected when it is not set", attributes)
+	}
+}
@@ -16,6 +16,7 @@ package capmodel
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
@@ -24,16 +25,23 @@ import (
 
 // GetAddressPool collects the AddressPool data belonging to a fabric from the DB
 func GetAddressPool(fabricID, oid string) (model.AddressPool, error) {
+	addressPool, _, err := GetAddressPoolWithETag(fabricID, oid)
+	return addressPool, err
+}
+
+// GetAddressPoolWithETag collects the AddressPool data belonging to a fabric from the DB
+// along with its entity tag
+func GetAddressPoolWithETag(fabricID, oid string) (model.AddressPool, string, error) {
 	var addressPool model.AddressPool
 	key := fmt.Sprintf("%s:%s", fabricID, oid)
 	data, err := db.Connector.Get(db.TableAddressPool, key)
 	if err != nil {
-		return addressPool, err
+		return addressPool, "", err
 	}
 	if err = json.Unmarshal([]byte(data), &addressPool); err != nil {
-		return addressPool, fmt.Errorf("while trying to unmarshal addressPool data, got: %v", err)
+		return addressPool, "", fmt.Errorf("while trying to unmarshal addressPool data, got: %v", err)
 	}
-	return addressPool, nil
+	return addressPool, ETag(data), nil
 }
 
 // GetAllAddressPools collects all the AddressPool data belonging to a fabric from the DB
@@ -73,6 +81,29 @@ func UpdateAddressPool(fabricID, oid string, data *model.AddressPool) error {
 	return UpdateDbData(db.TableAddressPool, key, *data)
 }
 
+// UpdateAddressPoolIfMatch updates the AddressPool data stored in the DB if its entity tag is still etag
+func UpdateAddressPoolIfMatch(fabricID, oid, etag string, data *model.AddressPool) (string, error) {
+	key := fmt.Sprintf("%s:%s", fabricID, oid)
+	return UpdateDbDataIfMatch(db.TableAddressPool, key, etag, *data)
+}
+
+// ModifyAddressPool applies modify on the AddressPool data stored in the DB and stores it back,
+// the AddressPool is read and modified again when another request updated it in between
+func ModifyAddressPool(fabricID, oid string, modify func(addressPool *model.AddressPool)) error {
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		addressPool, etag, getErr := GetAddressPoolWithETag(fabricID, oid)
+		if getErr != nil {
+			return getErr
+		}
+		modify(&addressPool)
+		if _, err = UpdateAddressPoolIfMatch(fabricID, oid, etag, &addressPool); !errors.Is(err, db.ErrorDataChanged) {
+			return err
+		}
+	}
+	return err
+}
+
 // DeleteAddressPool deletes the AddressPool data stored in the DB
 func DeleteAddressPool(fabricID, oid string) error {
 	key := fmt.Sprintf("%s:%s", fabricID, oid)
@@ -17,12 +17,24 @@ package capmodel
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"os"
 
 	"github.com/ODIM-Project/PluginCiscoACI/db"
 )
 
-// PluginIntialStatus hold value to check if it's intial status request to plugin
-var PluginIntialStatus = false
+// maxUpdateAttempts is the number of times a resource is read, modified and stored back
+// when other requests keep updating it meanwhile
+const maxUpdateAttempts = 5
+
+// InstanceID identifies this plugin instance among the replicas sharing the DB
+var InstanceID = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}()
 
 // SaveToDB is for adding data to the DB
 func SaveToDB(table, resourceID string, data interface{}) error {
@@ -41,3 +53,31 @@ func UpdateDbData(table, resourceID string, data interface{}) error {
 	}
 	return db.Connector.Update(table, resourceID, string(dataByte))
 }
+
+// ETag returns the entity tag of the resource data as it is stored in the DB
+func ETag(data string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(data))
+	return fmt.Sprintf(`W/"%016x"`, hash.Sum64())
+}
+
+// UpdateDbDataIfMatch is for updating data in the DB only when the stored data still has the
+// given entity tag, db.ErrorDataChanged is returned when it was updated by another request.
+// The entity tag of the updated data is returned on success
+func UpdateDbDataIfMatch(table, resourceID, etag string, data interface{}) (string, error) {
+	dataByte, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("while marshalling data, got: %v", err)
+	}
+	current, err := db.Connector.Get(table, resourceID)
+	if err != nil {
+		return "", err
+	}
+	if ETag(current) != etag {
+		return "", fmt.Errorf("%w: entity tag of resource id %s in table %s is no longer %s", db.ErrorDataChanged, resourceID, table, etag)
+	}
+	if err = db.Connector.CompareAndSwap(table, resourceID, current, string(dataByte)); err != nil {
+		return "", err
+	}
+	return ETag(string(dataByte)), nil
+}
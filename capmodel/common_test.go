@@ -15,6 +15,7 @@
 package capmodel
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/ODIM-Project/PluginCiscoACI/db"
@@ -59,3 +60,36 @@ func TestSaveToDB(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateDbDataIfMatch(t *testing.T) {
+	db.Connector = db.NewInMemoryConnector()
+	defer func() {
+		db.Connector = db.MockConnector{}
+	}()
+	if err := SaveToDB("someTable", "someResource", map[string]string{"Name": "first"}); err != nil {
+		t.Fatalf("SaveToDB() error = %v", err)
+	}
+	data, _ := db.Connector.Get("someTable", "someResource")
+	etag := ETag(data)
+	if etag != ETag(`{"Name":"first"}`) || etag == ETag(`{"Name":"second"}`) {
+		t.Fatalf("ETag() = %s doesn't identify the stored data", etag)
+	}
+
+	newETag, err := UpdateDbDataIfMatch("someTable", "someResource", etag, map[string]string{"Name": "second"})
+	if err != nil {
+		t.Fatalf("UpdateDbDataIfMatch() error = %v", err)
+	}
+	if newETag != ETag(`{"Name":"second"}`) {
+		t.Errorf("UpdateDbDataIfMatch() returned ETag %s of data which wasn't stored", newETag)
+	}
+	// the first writer already replaced the data read with etag
+	if _, err = UpdateDbDataIfMatch("someTable", "someResource", etag, map[string]string{"Name": "third"}); !errors.Is(err, db.ErrorDataChanged) {
+		t.Errorf("UpdateDbDataIfMatch() with stale ETag error = %v, want %v", err, db.ErrorDataChanged)
+	}
+	if data, _ = db.Connector.Get("someTable", "someResource"); data != `{"Name":"second"}` {
+		t.Errorf("stale update overwrote the data with %s", data)
+	}
+	if _, err = UpdateDbDataIfMatch("someTable", "missingResource", etag, nil); !errors.Is(err, db.ErrorKeyNotFound) {
+		t.Errorf("UpdateDbDataIfMatch() on missing resource error = %v, want %v", err, db.ErrorKeyNotFound)
+	}
+}
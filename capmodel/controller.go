@@ -0,0 +1,81 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+)
+
+// ControllerOem is the Oem property of the manager of an APIC controller
+type ControllerOem struct {
+	Cisco *CiscoControllerOem `json:"Cisco,omitempty"`
+}
+
+// CiscoControllerOem holds the ACI properties of the APIC controller
+type CiscoControllerOem struct {
+	PodID                      string          `json:"PodId"`
+	NodeID                     string          `json:"NodeId"`
+	Address                    string          `json:"Address,omitempty"`
+	InBandManagementAddress    string          `json:"InBandManagementAddress,omitempty"`
+	OutOfBandManagementAddress string          `json:"OutOfBandManagementAddress,omitempty"`
+	UpTime                     string          `json:"UpTime,omitempty"`
+	ClusterMembers             []ClusterMember `json:"ClusterMembers,omitempty"`
+}
+
+// ClusterMember is an APIC controller of the cluster as seen by the controller
+type ClusterMember struct {
+	NodeID           string `json:"NodeId"`
+	Name             string `json:"Name"`
+	Address          string `json:"Address"`
+	Health           string `json:"Health"`
+	OperationalState string `json:"OperationalState"`
+	AdminState       string `json:"AdminState"`
+}
+
+// GetController collects the APIC controller from the DB
+func GetController(controllerID string) (capdata.Controller, error) {
+	var controller capdata.Controller
+	data, err := db.Connector.Get(db.TableController, controllerID)
+	if err != nil {
+		return controller, err
+	}
+	if err = json.Unmarshal([]byte(data), &controller); err != nil {
+		return controller, fmt.Errorf("while trying to unmarshal controller data, got: %v", err)
+	}
+	return controller, nil
+}
+
+// GetControllerIDs collects the IDs of the managers of all the APIC controllers from the DB
+func GetControllerIDs() ([]string, error) {
+	controllerIDs, err := db.Connector.GetAllMatchingKeys(db.TableController, "")
+	if err != nil {
+		return nil, fmt.Errorf("while trying to collect all controller data, got: %w", err)
+	}
+	return controllerIDs, nil
+}
+
+// UpdateController stores the APIC controller in the DB, replacing the stored one
+func UpdateController(controllerID string, data *capdata.Controller) error {
+	return UpdateDbData(db.TableController, controllerID, *data)
+}
+
+// DeleteController deletes the manager of the APIC controller from the DB
+func DeleteController(controllerID string) error {
+	return db.Connector.Delete(db.TableController, controllerID)
+}
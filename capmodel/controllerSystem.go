@@ -0,0 +1,98 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+// TopSystemResponse ...
+type TopSystemResponse struct {
+	TotalCount string            `json:"totalCount"`
+	IMData     []TopSystemIMData `json:"imdata"`
+}
+
+// TopSystemIMData ...
+type TopSystemIMData struct {
+	TopSystem TopSystem `json:"topSystem"`
+}
+
+// TopSystem is the topSystem object of a switch or controller
+type TopSystem struct {
+	Attributes TopSystemAttributes `json:"attributes"`
+}
+
+// TopSystemAttributes are the attributes of topSystem object
+type TopSystemAttributes struct {
+	DN             string `json:"dn"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	Serial         string `json:"serial"`
+	Version        string `json:"version"`
+	State          string `json:"state"`
+	Address        string `json:"address"`
+	InbMgmtAddr    string `json:"inbMgmtAddr"`
+	OobMgmtAddr    string `json:"oobMgmtAddr"`
+	SystemUpTime   string `json:"systemUpTime"`
+	LastRebootTime string `json:"lastRebootTime"`
+	CurrentTime    string `json:"currentTime"`
+}
+
+// Validate checks that all the topSystem objects are valid
+func (t *TopSystemResponse) Validate() error {
+	for _, imdata := range t.IMData {
+		attributes := imdata.TopSystem.Attributes
+		if err := requireACIAttributes("topSystem", attributes.DN, "dn", attributes.DN, "id", attributes.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InfraWiNodeResponse ...
+type InfraWiNodeResponse struct {
+	TotalCount string              `json:"totalCount"`
+	IMData     []InfraWiNodeIMData `json:"imdata"`
+}
+
+// InfraWiNodeIMData ...
+type InfraWiNodeIMData struct {
+	InfraWiNode InfraWiNode `json:"infraWiNode"`
+}
+
+// InfraWiNode is the infraWiNode object giving the state of an APIC cluster member as seen by a controller
+type InfraWiNode struct {
+	Attributes InfraWiNodeAttributes `json:"attributes"`
+}
+
+// InfraWiNodeAttributes are the attributes of infraWiNode object
+type InfraWiNodeAttributes struct {
+	DN       string `json:"dn"`
+	ID       string `json:"id"`
+	NodeName string `json:"nodeName"`
+	Addr     string `json:"addr"`
+	Health   string `json:"health"`
+	OperSt   string `json:"operSt"`
+	AdminSt  string `json:"adminSt"`
+}
+
+// Validate checks that all the infraWiNode objects are valid
+func (i *InfraWiNodeResponse) Validate() error {
+	for _, imdata := range i.IMData {
+		attributes := imdata.InfraWiNode.Attributes
+		if err := requireACIAttributes("infraWiNode", attributes.DN, "dn", attributes.DN, "id", attributes.ID, "health", attributes.Health); err != nil {
+			return err
+		}
+	}
+	return nil
+}
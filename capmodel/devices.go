@@ -39,3 +39,27 @@ type EvtSubPost struct {
 type HTTPHeaders struct {
 	ContentType string `json:"Content-Type"`
 }
+
+// PluginStartUpData is the startup data ODIM sends after it or the plugin restarted,
+// RequestType is full when Devices holds all the devices ODIM manages through the plugin
+// and delta when it holds only the ones changed since the last startup data
+type PluginStartUpData struct {
+	RequestType           string                `json:"RequestType"`
+	ResyncEvtSubscription bool                  `json:"ResyncEvtSubscription"`
+	Devices               map[string]DeviceData `json:"Devices"`
+}
+
+// DeviceData holds the credentials and the event subscription of a device managed by ODIM through the plugin
+type DeviceData struct {
+	UserName              string                 `json:"UserName"`
+	Password              []byte                 `json:"Password"`
+	Address               string                 `json:"Address"`
+	Operation             string                 `json:"Operation"`
+	EventSubscriptionInfo *EventSubscriptionInfo `json:"EventSubscriptionInfo"`
+}
+
+// EventSubscriptionInfo holds the event subscription ODIM made on a device
+type EventSubscriptionInfo struct {
+	EventTypes []string `json:"EventTypes,omitempty"`
+	Location   string   `json:"Location,omitempty"`
+}
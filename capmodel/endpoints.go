@@ -24,16 +24,23 @@ import (
 
 // GetEndpoints collects the endpoint data belonging to a fabric from the DB
 func GetEndpoints(fabricID, oid string) (capdata.EndpointData, error) {
+	endpoint, _, err := GetEndpointsWithETag(fabricID, oid)
+	return endpoint, err
+}
+
+// GetEndpointsWithETag collects the endpoint data belonging to a fabric from the DB
+// along with its entity tag
+func GetEndpointsWithETag(fabricID, oid string) (capdata.EndpointData, string, error) {
 	var endpoint capdata.EndpointData
 	key := fmt.Sprintf("%s:%s", fabricID, oid)
 	data, err := db.Connector.Get(db.TableEndPoint, key)
 	if err != nil {
-		return endpoint, err
+		return endpoint, "", err
 	}
 	if err = json.Unmarshal([]byte(data), &endpoint); err != nil {
-		return endpoint, fmt.Errorf("while trying to unmarshal endpoint data, got: %v", err)
+		return endpoint, "", fmt.Errorf("while trying to unmarshal endpoint data, got: %v", err)
 	}
-	return endpoint, nil
+	return endpoint, ETag(data), nil
 }
 
 // GetAllEndpoints collects all the endpoint data belonging to a fabric from the DB
@@ -26,7 +26,17 @@ type FabricHealthIMData struct {
 	FabricHealthData FabricHealthData `json:"fabricHealthTotal"`
 }
 
-// FabricHealthData ...
+// FabricHealthData is the fabricHealthTotal object of a pod
 type FabricHealthData struct {
-	Attributes map[string]interface{} `json:"attributes"`
+	Attributes HealthAttributes `json:"attributes"`
+}
+
+// Validate checks that all the fabric health objects are valid
+func (f *FabricHealth) Validate() error {
+	for _, imdata := range f.IMData {
+		if err := imdata.FabricHealthData.Attributes.validate("fabricHealthTotal"); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,125 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FabricLinkResponse ...
+type FabricLinkResponse struct {
+	TotalCount string             `json:"totalCount"`
+	IMData     []FabricLinkIMData `json:"imdata"`
+}
+
+// FabricLinkIMData ...
+type FabricLinkIMData struct {
+	FabricLink FabricLink `json:"fabricLink"`
+}
+
+// FabricLink is the fabricLink object of a link between a leaf port and a spine port
+type FabricLink struct {
+	Attributes FabricLinkAttributes `json:"attributes"`
+}
+
+// FabricLinkAttributes are the attributes of fabricLink object,
+// the link is between port p1 of slot s1 of node n1 and port p2 of slot s2 of node n2
+type FabricLinkAttributes struct {
+	DN        string `json:"dn"`
+	N1        string `json:"n1"`
+	S1        string `json:"s1"`
+	P1        string `json:"p1"`
+	N2        string `json:"n2"`
+	S2        string `json:"s2"`
+	P2        string `json:"p2"`
+	LinkState string `json:"linkState"`
+}
+
+// Validate checks that all the fabric link objects are valid
+func (f *FabricLinkResponse) Validate() error {
+	for _, imdata := range f.IMData {
+		attributes := imdata.FabricLink.Attributes
+		if err := requireACIAttributes("fabricLink", attributes.DN, "dn", attributes.DN, "n1", attributes.N1, "s1", attributes.S1,
+			"p1", attributes.P1, "n2", attributes.N2, "s2", attributes.S2, "p2", attributes.P2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Port1 returns the name of the port at the first end of the link
+func (f *FabricLinkAttributes) Port1() string {
+	return fmt.Sprintf("eth%s/%s", f.S1, f.P1)
+}
+
+// Port2 returns the name of the port at the second end of the link
+func (f *FabricLinkAttributes) Port2() string {
+	return fmt.Sprintf("eth%s/%s", f.S2, f.P2)
+}
+
+// LLDPAdjacencyResponse ...
+type LLDPAdjacencyResponse struct {
+	TotalCount string                `json:"totalCount"`
+	IMData     []LLDPAdjacencyIMData `json:"imdata"`
+}
+
+// LLDPAdjacencyIMData ...
+type LLDPAdjacencyIMData struct {
+	LLDPAdjacency LLDPAdjacency `json:"lldpAdjEp"`
+}
+
+// LLDPAdjacency is the lldpAdjEp object of a neighbor seen with LLDP on a port of a switch
+type LLDPAdjacency struct {
+	Attributes LLDPAdjacencyAttributes `json:"attributes"`
+}
+
+// LLDPAdjacencyAttributes are the attributes of lldpAdjEp object
+type LLDPAdjacencyAttributes struct {
+	DN         string `json:"dn"`
+	SysName    string `json:"sysName"`
+	PortIDV    string `json:"portIdV"`
+	PortDesc   string `json:"portDesc"`
+	ChassisIDV string `json:"chassisIdV"`
+	MgmtIP     string `json:"mgmtIp"`
+}
+
+// Validate checks that all the LLDP adjacency objects are valid
+func (l *LLDPAdjacencyResponse) Validate() error {
+	for _, imdata := range l.IMData {
+		attributes := imdata.LLDPAdjacency.Attributes
+		if err := requireACIAttributes("lldpAdjEp", attributes.DN, "dn", attributes.DN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LocalPort returns the pod, the node and the name of the port the neighbor is seen on, read from the dn
+// like topology/pod-1/node-101/sys/lldp/inst/if-[eth1/49]/adj-1
+func (l *LLDPAdjacencyAttributes) LocalPort() (podID, nodeID, portName string) {
+	return adjacencyLocalPort(l.DN)
+}
+
+// RemotePort returns the name of the neighbor port in the form used by the ACI ports, like eth1/1,
+// the switches advertise it as Eth1/1 or Ethernet1/1
+func (l *LLDPAdjacencyAttributes) RemotePort() string {
+	portName := strings.ToLower(l.PortIDV)
+	if strings.HasPrefix(portName, "ethernet") {
+		return "eth" + strings.TrimPrefix(portName, "ethernet")
+	}
+	return portName
+}
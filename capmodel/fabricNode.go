@@ -0,0 +1,87 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"strings"
+)
+
+// roles of the fabric nodes given by fabricNode
+const (
+	// NodeRoleLeaf is the role of the leaf switches, remote leaves included
+	NodeRoleLeaf = "leaf"
+	// NodeRoleSpine is the role of the spine switches
+	NodeRoleSpine = "spine"
+	// NodeRoleController is the role of the APIC controllers
+	NodeRoleController = "controller"
+)
+
+// FabricNodeResponse ...
+type FabricNodeResponse struct {
+	TotalCount string             `json:"totalCount"`
+	IMData     []FabricNodeIMData `json:"imdata"`
+}
+
+// FabricNodeIMData ...
+type FabricNodeIMData struct {
+	FabricNode FabricNode `json:"fabricNode"`
+}
+
+// FabricNode is the fabricNode object of a switch or controller registered in the fabric
+type FabricNode struct {
+	Attributes FabricNodeAttributes `json:"attributes"`
+}
+
+// FabricNodeAttributes are the attributes of fabricNode object
+type FabricNodeAttributes struct {
+	DN             string `json:"dn"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	NodeType       string `json:"nodeType"`
+	Serial         string `json:"serial"`
+	Model          string `json:"model"`
+	Vendor         string `json:"vendor"`
+	Version        string `json:"version"`
+	Address        string `json:"address"`
+	FabricSt       string `json:"fabricSt"`
+	AdSt           string `json:"adSt"`
+	LastStateModTs string `json:"lastStateModTs"`
+}
+
+// Validate checks that all the fabric node objects are valid
+func (f *FabricNodeResponse) Validate() error {
+	for _, imdata := range f.IMData {
+		attributes := imdata.FabricNode.Attributes
+		if err := requireACIAttributes("fabricNode", attributes.DN, "dn", attributes.DN, "id", attributes.ID, "role", attributes.Role); err != nil {
+			return err
+		}
+		if err := requireNumericACIAttribute("fabricNode", attributes.DN, "id", attributes.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PodID returns the pod of the node read from its dn
+func (f *FabricNodeAttributes) PodID() string {
+	for _, rn := range strings.Split(f.DN, "/") {
+		if strings.HasPrefix(rn, "pod-") {
+			return strings.TrimPrefix(rn, "pod-")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+// FaultResponse ...
+type FaultResponse struct {
+	TotalCount string        `json:"totalCount"`
+	IMData     []FaultIMData `json:"imdata"`
+}
+
+// FaultIMData ...
+type FaultIMData struct {
+	Fault Fault `json:"faultInst"`
+}
+
+// Fault is the faultInst object raised on an ACI entity
+type Fault struct {
+	Attributes FaultAttributes `json:"attributes"`
+}
+
+// FaultAttributes are the attributes of faultInst object
+type FaultAttributes struct {
+	DN             string `json:"dn"`
+	Code           string `json:"code"`
+	Severity       string `json:"severity"`
+	Descr          string `json:"descr"`
+	Cause          string `json:"cause"`
+	Lc             string `json:"lc"`
+	Rule           string `json:"rule"`
+	Subject        string `json:"subject"`
+	Created        string `json:"created"`
+	LastTransition string `json:"lastTransition"`
+}
+
+// Validate checks that all the fault objects are valid
+func (f *FaultResponse) Validate() error {
+	for _, imdata := range f.IMData {
+		attributes := imdata.Fault.Attributes
+		if err := requireACIAttributes("faultInst", attributes.DN, "dn", attributes.DN, "code", attributes.Code, "severity", attributes.Severity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
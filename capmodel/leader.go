@@ -0,0 +1,80 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+)
+
+// leaderKey is the key of the leadership entry in the leader table
+const leaderKey = "leader"
+
+// Leader is the leadership entry stored in the DB, it expires unless the leader keeps renewing it
+type Leader struct {
+	Instance  string    `json:"Instance"`
+	ElectedAt time.Time `json:"ElectedAt"`
+}
+
+// Leadership is the leadership held by this plugin instance
+type Leadership struct {
+	Leader
+	data string
+}
+
+// AcquireLeadership makes this plugin instance the leader for the lease time if there is no leader,
+// nil is returned when another instance is the leader
+func AcquireLeadership(lease time.Duration) (*Leadership, error) {
+	leader := Leader{
+		Instance:  InstanceID,
+		ElectedAt: time.Now(),
+	}
+	data, err := json.Marshal(leader)
+	if err != nil {
+		return nil, fmt.Errorf("while marshalling leader, got: %v", err)
+	}
+	ok, err := db.Connector.AcquireLock(db.TableLeader, leaderKey, string(data), lease)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &Leadership{Leader: leader, data: string(data)}, nil
+}
+
+// Renew extends the leadership for the lease time, false is returned when it was lost meanwhile
+func (l *Leadership) Renew(lease time.Duration) (bool, error) {
+	return db.Connector.RefreshLock(db.TableLeader, leaderKey, l.data, lease)
+}
+
+// Resign gives up the leadership so that another instance can take over right away
+func (l *Leadership) Resign() error {
+	_, err := db.Connector.ReleaseLock(db.TableLeader, leaderKey, l.data)
+	return err
+}
+
+// GetLeader returns the plugin instance currently elected as leader
+func GetLeader() (*Leader, error) {
+	data, err := db.Connector.Get(db.TableLeader, leaderKey)
+	if err != nil {
+		return nil, err
+	}
+	var leader Leader
+	if err = json.Unmarshal([]byte(data), &leader); err != nil {
+		return nil, fmt.Errorf("while unmarshalling leader, got: %v", err)
+	}
+	return &leader, nil
+}
@@ -0,0 +1,324 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lockPollMinInterval and lockPollMaxInterval bound the delay between the attempts to take a held lock
+	lockPollMinInterval = 20 * time.Millisecond
+	lockPollMaxInterval = 500 * time.Millisecond
+)
+
+// Using below variables as part of errors will enabling errors.Is() function
+var (
+	// ErrorLockTimeout is for identifing requests which gave up waiting for a lock held by another request
+	ErrorLockTimeout = errors.New("Timed out waiting for lock")
+	// ErrorLockLost is for identifing requests whose lock couldn't be renewed before its lease expired
+	ErrorLockLost = errors.New("Lock lost")
+)
+
+// Lock is the lock entry stored in the DB, Token identifies the acquisition so that
+// the lock is released only by its holder and not by whoever took it over after it expired.
+// ExpiresAt is the end of the first lease, the lease is renewed for as long as the lock is held
+type Lock struct {
+	Name       string    `json:"Name"`
+	Token      string    `json:"Token"`
+	Holder     string    `json:"Holder"`
+	AcquiredAt time.Time `json:"AcquiredAt"`
+	ExpiresAt  time.Time `json:"ExpiresAt"`
+}
+
+// HeldLocks is the set of locks acquired together by a request, their lease is renewed
+// in the background until they are released
+type HeldLocks struct {
+	locks []Lock
+	data  []string
+	stop  chan struct{}
+	done  chan struct{}
+	lost  chan struct{}
+	mux   sync.Mutex
+	err   error
+}
+
+// LockStats holds the lock counters of this plugin instance
+type LockStats struct {
+	Acquired  int64
+	Contended int64
+	TimedOut  int64
+	Expired   int64
+}
+
+var lockStats LockStats
+
+// FabricLockName returns the name of the lock serializing the modifications of the fabric
+func FabricLockName(fabricID string) string {
+	return "fabric:" + fabricID
+}
+
+// ACIObjectLockName returns the name of the lock serializing the modifications of the ACI object with the given DN,
+// the ACI objects under uni/infra are shared by all the fabrics
+func ACIObjectLockName(dn string) string {
+	return "aci:" + dn
+}
+
+// LockWaitTime returns the time a request waits for the locks held by other requests
+func LockWaitTime() time.Duration {
+	return time.Duration(getLockConf().WaitTimeInSeconds) * time.Second
+}
+
+func getLockConf() *config.LockConf {
+	if config.Data.LockConf == nil {
+		return config.NewLockConf()
+	}
+	return config.Data.LockConf
+}
+
+// AcquireLocks acquires all the named locks on behalf of operation, waiting for the ones held by other requests.
+// The locks are taken in the order of their names so that requests locking overlapping sets can't deadlock,
+// ErrorLockTimeout is returned when they couldn't all be acquired within the configured wait time
+func AcquireLocks(operation string, names ...string) (*HeldLocks, error) {
+	conf := getLockConf()
+	lease := time.Duration(conf.LeaseTimeInSeconds) * time.Second
+	deadline := time.Now().Add(time.Duration(conf.WaitTimeInSeconds) * time.Second)
+
+	sorted := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	held := &HeldLocks{}
+	for _, name := range sorted {
+		if err := held.acquire(name, operation, lease, deadline); err != nil {
+			held.Release()
+			return nil, err
+		}
+	}
+	atomic.AddInt64(&lockStats.Acquired, int64(len(sorted)))
+	held.stop = make(chan struct{})
+	held.done = make(chan struct{})
+	held.lost = make(chan struct{})
+	go held.renew(lease)
+	return held, nil
+}
+
+// renew renews the lease of the held locks at a third of the lease time until they are released,
+// a renewal failing on a DB error is retried at the next interval as long as the lease isn't over.
+// The locks are lost when one of them expired or was taken over
+func (h *HeldLocks) renew(lease time.Duration) {
+	defer close(h.done)
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case now := <-ticker.C:
+			err := h.refresh(lease)
+			if err == nil {
+				renewedAt = now
+				continue
+			}
+			if !errors.Is(err, ErrorLockLost) && now.Before(renewedAt.Add(lease)) {
+				log.Warn("failed to renew the held locks, retrying: " + err.Error())
+				continue
+			}
+			if !errors.Is(err, ErrorLockLost) {
+				err = fmt.Errorf("%w: %v", ErrorLockLost, err)
+			}
+			log.Error(err.Error())
+			h.mux.Lock()
+			h.err = err
+			h.mux.Unlock()
+			close(h.lost)
+			return
+		}
+	}
+}
+
+func (h *HeldLocks) refresh(lease time.Duration) error {
+	for i, lock := range h.locks {
+		refreshed, err := db.Connector.RefreshLock(db.TableLock, lock.Name, h.data[i], lease)
+		if err != nil {
+			return err
+		}
+		if !refreshed {
+			return fmt.Errorf("%w %s held by %s, it expired before it was renewed", ErrorLockLost, lock.Name, lock.Holder)
+		}
+	}
+	return nil
+}
+
+// Lost is closed when the held locks couldn't be renewed, the request holding them must not go on
+// modifying the fabric as another request may have taken them
+func (h *HeldLocks) Lost() <-chan struct{} {
+	if h == nil {
+		return nil
+	}
+	return h.lost
+}
+
+// Err returns the error wrapping ErrorLockLost once the held locks are lost, nil until then
+func (h *HeldLocks) Err() error {
+	if h == nil {
+		return nil
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.err
+}
+
+// stopRenewal stops renewing the lease of the held locks
+func (h *HeldLocks) stopRenewal() {
+	if h.stop == nil {
+		return
+	}
+	select {
+	case <-h.stop:
+	default:
+		close(h.stop)
+	}
+	<-h.done
+}
+
+func (h *HeldLocks) acquire(name, operation string, lease time.Duration, deadline time.Time) error {
+	interval := lockPollMinInterval
+	contended := false
+	for {
+		now := time.Now()
+		lock := Lock{
+			Name:       name,
+			Token:      uuid.NewV4().String(),
+			Holder:     InstanceID + " " + operation,
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(lease),
+		}
+		data, err := json.Marshal(lock)
+		if err != nil {
+			return fmt.Errorf("while marshalling lock %s, got: %v", name, err)
+		}
+		ok, err := db.Connector.AcquireLock(db.TableLock, name, string(data), lease)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.locks = append(h.locks, lock)
+			h.data = append(h.data, string(data))
+			return nil
+		}
+		if !contended {
+			contended = true
+			atomic.AddInt64(&lockStats.Contended, 1)
+		}
+		if now.Add(interval).After(deadline) {
+			atomic.AddInt64(&lockStats.TimedOut, 1)
+			return fmt.Errorf("%w %s, it is held by %s", ErrorLockTimeout, name, lockHolder(name))
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > lockPollMaxInterval {
+			interval = lockPollMaxInterval
+		}
+	}
+}
+
+// lockHolder describes the current holder of the lock for the error messages
+func lockHolder(name string) string {
+	data, err := db.Connector.Get(db.TableLock, name)
+	if err != nil {
+		return "unknown"
+	}
+	var lock Lock
+	if err = json.Unmarshal([]byte(data), &lock); err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s since %s", lock.Holder, lock.AcquiredAt.Format(time.RFC3339))
+}
+
+// Release releases all the held locks, a lock which expired before being released is only logged
+// as the modifications it protected might have interleaved with another request's
+func (h *HeldLocks) Release() {
+	if h == nil {
+		return
+	}
+	h.stopRenewal()
+	for i := len(h.locks) - 1; i >= 0; i-- {
+		lock := h.locks[i]
+		released, err := db.Connector.ReleaseLock(db.TableLock, lock.Name, h.data[i])
+		switch {
+		case err != nil:
+			log.Errorf("failed to release lock %s, it expires at %s: %s", lock.Name, lock.ExpiresAt.Format(time.RFC3339), err.Error())
+		case !released:
+			atomic.AddInt64(&lockStats.Expired, 1)
+			log.Warnf("lock %s held by %s expired before it was released, LeaseTimeInSeconds may be too short", lock.Name, lock.Holder)
+		}
+	}
+	h.locks = nil
+	h.data = nil
+}
+
+// GetAllLocks returns the locks currently held by all the plugin instances
+func GetAllLocks() ([]Lock, error) {
+	names, err := db.Connector.GetAllMatchingKeys(db.TableLock, "")
+	if err != nil {
+		return nil, err
+	}
+	locks := []Lock{}
+	for _, name := range names {
+		data, err := db.Connector.Get(db.TableLock, name)
+		if err != nil {
+			if errors.Is(err, db.ErrorKeyNotFound) {
+				// released meanwhile
+				continue
+			}
+			return nil, err
+		}
+		var lock Lock
+		if err = json.Unmarshal([]byte(data), &lock); err != nil {
+			return nil, fmt.Errorf("while unmarshalling lock %s, got: %v", name, err)
+		}
+		locks = append(locks, lock)
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Name < locks[j].Name })
+	return locks, nil
+}
+
+// GetLockStats returns the lock counters of this plugin instance
+func GetLockStats() LockStats {
+	return LockStats{
+		Acquired:  atomic.LoadInt64(&lockStats.Acquired),
+		Contended: atomic.LoadInt64(&lockStats.Contended),
+		TimedOut:  atomic.LoadInt64(&lockStats.TimedOut),
+		Expired:   atomic.LoadInt64(&lockStats.Expired),
+	}
+}
@@ -0,0 +1,167 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+)
+
+func setUpLockTest(t *testing.T, leaseTime, waitTime int) {
+	db.Connector = db.NewInMemoryConnector()
+	config.Data.LockConf = &config.LockConf{
+		LeaseTimeInSeconds: leaseTime,
+		WaitTimeInSeconds:  waitTime,
+	}
+	t.Cleanup(func() {
+		db.Connector = db.MockConnector{}
+		config.Data.LockConf = nil
+	})
+}
+
+func TestAcquireLocksSerializesHolders(t *testing.T) {
+	setUpLockTest(t, 10, 5)
+	var mux sync.Mutex
+	inside, maxInside := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locks, err := AcquireLocks("test", FabricLockName("fabric1"), ACIObjectLockName("uni/infra/lacplagp-policy"))
+			if err != nil {
+				t.Errorf("AcquireLocks() error = %v", err)
+				return
+			}
+			mux.Lock()
+			inside++
+			if inside > maxInside {
+				maxInside = inside
+			}
+			mux.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mux.Lock()
+			inside--
+			mux.Unlock()
+			locks.Release()
+		}()
+	}
+	wg.Wait()
+	if maxInside != 1 {
+		t.Errorf("%d requests held the locks at once, want 1", maxInside)
+	}
+	if locks, _ := GetAllLocks(); len(locks) != 0 {
+		t.Errorf("GetAllLocks() = %v after all the locks were released", locks)
+	}
+}
+
+func TestAcquireLocksTimeout(t *testing.T) {
+	setUpLockTest(t, 10, 1)
+	held, err := AcquireLocks("first", FabricLockName("fabric1"))
+	if err != nil {
+		t.Fatalf("AcquireLocks() error = %v", err)
+	}
+	defer held.Release()
+
+	timedOut := GetLockStats().TimedOut
+	// the free lock taken before waiting for the held one must be given back
+	_, err = AcquireLocks("second", FabricLockName("fabric1"), ACIObjectLockName("uni/infra/accportprof-x"))
+	if !errors.Is(err, ErrorLockTimeout) {
+		t.Fatalf("AcquireLocks() error = %v, want %v", err, ErrorLockTimeout)
+	}
+	if GetLockStats().TimedOut != timedOut+1 {
+		t.Errorf("TimedOut counter wasn't incremented")
+	}
+	locks, err := GetAllLocks()
+	if err != nil {
+		t.Fatalf("GetAllLocks() error = %v", err)
+	}
+	if len(locks) != 1 || locks[0].Name != FabricLockName("fabric1") || locks[0].Holder != InstanceID+" first" {
+		t.Errorf("GetAllLocks() = %v, want only the lock held by the first request", locks)
+	}
+}
+
+func TestExpiredLockIsTakenOver(t *testing.T) {
+	setUpLockTest(t, 1, 3)
+	first, err := AcquireLocks("first", FabricLockName("fabric1"))
+	if err != nil {
+		t.Fatalf("AcquireLocks() error = %v", err)
+	}
+	// the instance of the first holder stalls, its lock expires and is taken by the second request
+	first.stopRenewal()
+	second, err := AcquireLocks("second", FabricLockName("fabric1"))
+	if err != nil {
+		t.Fatalf("AcquireLocks() error = %v", err)
+	}
+	expired := GetLockStats().Expired
+	first.Release()
+	if GetLockStats().Expired != expired+1 {
+		t.Errorf("Expired counter wasn't incremented")
+	}
+	// releasing the expired lock must leave the second holder's lock alone
+	locks, _ := GetAllLocks()
+	if len(locks) != 1 || locks[0].Holder != InstanceID+" second" {
+		t.Errorf("GetAllLocks() = %v, want the lock held by the second request", locks)
+	}
+	second.Release()
+	if locks, _ := GetAllLocks(); len(locks) != 0 {
+		t.Errorf("GetAllLocks() = %v after all the locks were released", locks)
+	}
+}
+
+func TestHeldLocksAreRenewed(t *testing.T) {
+	setUpLockTest(t, 1, 0)
+	held, err := AcquireLocks("first", FabricLockName("fabric1"))
+	if err != nil {
+		t.Fatalf("AcquireLocks() error = %v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := AcquireLocks("second", FabricLockName("fabric1")); !errors.Is(err, ErrorLockTimeout) {
+		t.Errorf("AcquireLocks() error = %v, the renewed lock is expected to be still held", err)
+	}
+	if err := held.Err(); err != nil {
+		t.Errorf("Err() = %v for the renewed locks", err)
+	}
+	held.Release()
+	if locks, _ := GetAllLocks(); len(locks) != 0 {
+		t.Errorf("GetAllLocks() = %v after all the locks were released", locks)
+	}
+}
+
+func TestHeldLocksLost(t *testing.T) {
+	setUpLockTest(t, 1, 0)
+	held, err := AcquireLocks("first", FabricLockName("fabric1"))
+	if err != nil {
+		t.Fatalf("AcquireLocks() error = %v", err)
+	}
+	defer held.Release()
+	// another holder overwrites the lock as if it took it over after it expired
+	if err := db.Connector.Update(db.TableLock, FabricLockName("fabric1"), `{"Name":"fabric:fabric1","Token":"other"}`); err != nil {
+		t.Fatalf("failed to overwrite the lock: %v", err)
+	}
+	select {
+	case <-held.Lost():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Lost() wasn't closed after the lock was taken over")
+	}
+	if err := held.Err(); !errors.Is(err, ErrorLockLost) {
+		t.Errorf("Err() = %v, want ErrorLockLost", err)
+	}
+}
@@ -0,0 +1,44 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+)
+
+// migration is the record of a completed migration of the stored data
+type migration struct {
+	CompletedAt time.Time `json:"CompletedAt"`
+}
+
+// IsMigrationDone reports whether the named migration of the stored data was completed
+func IsMigrationDone(name string) (bool, error) {
+	_, err := db.Connector.Get(db.TableMigration, name)
+	switch {
+	case errors.Is(err, db.ErrorKeyNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// SetMigrationDone records the named migration of the stored data as completed
+func SetMigrationDone(name string) error {
+	return UpdateDbData(db.TableMigration, name, migration{CompletedAt: time.Now()})
+}
@@ -0,0 +1,211 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PortStatsResponse is the response of the subtree query of l1PhysIf for the interface counters
+// and the 5 minutes traffic stats of the port
+type PortStatsResponse struct {
+	TotalCount string            `json:"totalCount"`
+	IMData     []PortStatsIMData `json:"imdata"`
+}
+
+// PortStatsIMData holds one of the objects of the subtree query
+type PortStatsIMData struct {
+	EtherStats *RMONEtherStats `json:"rmonEtherStats,omitempty"`
+	IfIn       *RMONIfCounters `json:"rmonIfIn,omitempty"`
+	IfOut      *RMONIfCounters `json:"rmonIfOut,omitempty"`
+	Ingress    *EqptTotalStats `json:"eqptIngrTotal5min,omitempty"`
+	Egress     *EqptTotalStats `json:"eqptEgrTotal5min,omitempty"`
+}
+
+// RMONEtherStats is the rmonEtherStats object holding the ethernet counters of a port
+type RMONEtherStats struct {
+	Attributes RMONEtherStatsAttributes `json:"attributes"`
+}
+
+// RMONEtherStatsAttributes are the attributes of rmonEtherStats object
+type RMONEtherStatsAttributes struct {
+	DN             string `json:"dn"`
+	CRCAlignErrors string `json:"cRCAlignErrors"`
+	DropEvents     string `json:"dropEvents"`
+}
+
+// RMONIfCounters is the rmonIfIn or rmonIfOut object holding the received or the transmitted counters of a port
+type RMONIfCounters struct {
+	Attributes RMONIfCountersAttributes `json:"attributes"`
+}
+
+// RMONIfCountersAttributes are the attributes of rmonIfIn and rmonIfOut objects
+type RMONIfCountersAttributes struct {
+	DN            string `json:"dn"`
+	Octets        string `json:"octets"`
+	UcastPkts     string `json:"ucastPkts"`
+	MulticastPkts string `json:"multicastPkts"`
+	BroadcastPkts string `json:"broadcastPkts"`
+	Discards      string `json:"discards"`
+	Errors        string `json:"errors"`
+}
+
+// EqptTotalStats is the eqptIngrTotal5min or eqptEgrTotal5min object holding the traffic of a port
+// over the last 5 minutes
+type EqptTotalStats struct {
+	Attributes EqptTotalStatsAttributes `json:"attributes"`
+}
+
+// EqptTotalStatsAttributes are the attributes of eqptIngrTotal5min and eqptEgrTotal5min objects,
+// utilAvg is the average utilization of the port in percent
+type EqptTotalStatsAttributes struct {
+	DN      string `json:"dn"`
+	UtilAvg string `json:"utilAvg"`
+}
+
+// Validate drops the counters and the traffic stats objects with a non numeric value, they are logged
+// and skipped so that a bad object doesn't fail the stats of all the other ports
+func (p *PortStatsResponse) Validate() error {
+	valid := p.IMData[:0]
+	for _, imdata := range p.IMData {
+		if err := imdata.validate(); err != nil {
+			log.Warn("skipping the port stats: " + err.Error())
+			continue
+		}
+		valid = append(valid, imdata)
+	}
+	p.IMData = valid
+	return nil
+}
+
+// validate checks that the counters and the traffic stats of the object are numeric
+func (imdata *PortStatsIMData) validate() error {
+	if stats := imdata.EtherStats; stats != nil {
+		if err := requireCounters("rmonEtherStats", stats.Attributes.DN,
+			"cRCAlignErrors", stats.Attributes.CRCAlignErrors, "dropEvents", stats.Attributes.DropEvents); err != nil {
+			return err
+		}
+	}
+	for className, counters := range map[string]*RMONIfCounters{"rmonIfIn": imdata.IfIn, "rmonIfOut": imdata.IfOut} {
+		if counters == nil {
+			continue
+		}
+		attributes := counters.Attributes
+		if err := requireCounters(className, attributes.DN, "octets", attributes.Octets, "ucastPkts", attributes.UcastPkts,
+			"multicastPkts", attributes.MulticastPkts, "broadcastPkts", attributes.BroadcastPkts,
+			"discards", attributes.Discards, "errors", attributes.Errors); err != nil {
+			return err
+		}
+	}
+	for className, stats := range map[string]*EqptTotalStats{"eqptIngrTotal5min": imdata.Ingress, "eqptEgrTotal5min": imdata.Egress} {
+		if stats == nil {
+			continue
+		}
+		if _, err := strconv.ParseFloat(stats.Attributes.UtilAvg, 64); err != nil {
+			return fmt.Errorf("%w: %s %s has non numeric utilAvg attribute %q", ErrorInvalidACIObject, className, stats.Attributes.DN, stats.Attributes.UtilAvg)
+		}
+	}
+	return nil
+}
+
+// requireCounters checks that the given attributes of the object hold unsigned 64 bit counters
+func requireCounters(className, dn string, attributes ...string) error {
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if _, err := strconv.ParseUint(attributes[i+1], 10, 64); err != nil {
+			return fmt.Errorf("%w: %s %s has non numeric %s attribute %q", ErrorInvalidACIObject, className, dn, attributes[i], attributes[i+1])
+		}
+	}
+	return nil
+}
+
+// PhysIfDN returns the dn of the physical interface holding the stats object with dn,
+// it is empty for the stats of the other objects like the port channels
+func PhysIfDN(dn string) string {
+	start := strings.Index(dn, "/sys/phys-[")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(dn[start:], "]")
+	if end < 0 {
+		return ""
+	}
+	return dn[:start+end+1]
+}
+
+// PhysIfPort reads the pod, the node and the name of the port from the dn of a physical interface
+func PhysIfPort(dn string) (podID, nodeID, portName string) {
+	start := strings.Index(dn, "/sys/phys-[")
+	if start < 0 || !strings.HasSuffix(dn, "]") {
+		return "", "", ""
+	}
+	podID, nodeID = NodeOfDN(dn[:start])
+	return podID, nodeID, dn[start+len("/sys/phys-[") : len(dn)-1]
+}
+
+// PortStats are the counters and the traffic stats of a port, the objects APIC doesn't give are nil
+type PortStats struct {
+	EtherStats *RMONEtherStatsAttributes
+	IfIn       *RMONIfCountersAttributes
+	IfOut      *RMONIfCountersAttributes
+	Ingress    *EqptTotalStatsAttributes
+	Egress     *EqptTotalStatsAttributes
+}
+
+// PortMetrics is the Redfish PortMetrics resource of a port
+type PortMetrics struct {
+	ODataContext string                 `json:"@odata.context,omitempty"`
+	ODataID      string                 `json:"@odata.id"`
+	ODataType    string                 `json:"@odata.type"`
+	ID           string                 `json:"Id"`
+	Name         string                 `json:"Name"`
+	Description  string                 `json:"Description,omitempty"`
+	RXBytes      *uint64                `json:"RXBytes,omitempty"`
+	RXErrors     *uint64                `json:"RXErrors,omitempty"`
+	TXBytes      *uint64                `json:"TXBytes,omitempty"`
+	TXErrors     *uint64                `json:"TXErrors,omitempty"`
+	Networking   *PortMetricsNetworking `json:"Networking,omitempty"`
+	Oem          *PortMetricsOem        `json:"Oem,omitempty"`
+}
+
+// PortMetricsNetworking are the frame counters of the port
+type PortMetricsNetworking struct {
+	RXBroadcastFrames *uint64 `json:"RXBroadcastFrames,omitempty"`
+	RXCRCErrors       *uint64 `json:"RXCRCErrors,omitempty"`
+	RXDiscards        *uint64 `json:"RXDiscards,omitempty"`
+	RXFrames          *uint64 `json:"RXFrames,omitempty"`
+	RXMulticastFrames *uint64 `json:"RXMulticastFrames,omitempty"`
+	RXUnicastFrames   *uint64 `json:"RXUnicastFrames,omitempty"`
+	TXBroadcastFrames *uint64 `json:"TXBroadcastFrames,omitempty"`
+	TXDiscards        *uint64 `json:"TXDiscards,omitempty"`
+	TXFrames          *uint64 `json:"TXFrames,omitempty"`
+	TXMulticastFrames *uint64 `json:"TXMulticastFrames,omitempty"`
+	TXUnicastFrames   *uint64 `json:"TXUnicastFrames,omitempty"`
+}
+
+// PortMetricsOem holds the metrics of the port Redfish has no property for
+type PortMetricsOem struct {
+	Cisco *CiscoPortMetricsOem `json:"Cisco,omitempty"`
+}
+
+// CiscoPortMetricsOem is the average utilization of the port in percent over the last 5 minutes
+type CiscoPortMetricsOem struct {
+	RXUtilizationPercent *float64 `json:"RXUtilizationPercent,omitempty"`
+	TXUtilizationPercent *float64 `json:"TXUtilizationPercent,omitempty"`
+}
@@ -0,0 +1,155 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+)
+
+// protocols the port neighbors are discovered with
+const (
+	// NeighborProtocolLLDP is the protocol of the neighbors given by lldpAdjEp
+	NeighborProtocolLLDP = "LLDP"
+	// NeighborProtocolCDP is the protocol of the neighbors given by cdpAdjEp
+	NeighborProtocolCDP = "CDP"
+)
+
+// CDPAdjacencyResponse ...
+type CDPAdjacencyResponse struct {
+	TotalCount string               `json:"totalCount"`
+	IMData     []CDPAdjacencyIMData `json:"imdata"`
+}
+
+// CDPAdjacencyIMData ...
+type CDPAdjacencyIMData struct {
+	CDPAdjacency CDPAdjacency `json:"cdpAdjEp"`
+}
+
+// CDPAdjacency is the cdpAdjEp object of a neighbor seen with CDP on a port of a switch
+type CDPAdjacency struct {
+	Attributes CDPAdjacencyAttributes `json:"attributes"`
+}
+
+// CDPAdjacencyAttributes are the attributes of cdpAdjEp object
+type CDPAdjacencyAttributes struct {
+	DN      string `json:"dn"`
+	DevID   string `json:"devId"`
+	PortID  string `json:"portId"`
+	SysName string `json:"sysName"`
+	PlatID  string `json:"platId"`
+}
+
+// Validate checks that all the CDP adjacency objects are valid
+func (c *CDPAdjacencyResponse) Validate() error {
+	for _, imdata := range c.IMData {
+		attributes := imdata.CDPAdjacency.Attributes
+		if err := requireACIAttributes("cdpAdjEp", attributes.DN, "dn", attributes.DN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LocalPort returns the pod, the node and the name of the port the neighbor is seen on, read from the dn
+// like topology/pod-1/node-101/sys/cdp/inst/if-[eth1/3]/adj-1
+func (c *CDPAdjacencyAttributes) LocalPort() (podID, nodeID, portName string) {
+	return adjacencyLocalPort(c.DN)
+}
+
+// SystemName returns the name the neighbor advertises, devId when the neighbor gives no system name
+func (c *CDPAdjacencyAttributes) SystemName() string {
+	if c.SysName != "" {
+		return c.SysName
+	}
+	return c.DevID
+}
+
+// adjacencyLocalPort reads the pod, the node and the name of the port from the dn of an LLDP or CDP adjacency
+func adjacencyLocalPort(dn string) (podID, nodeID, portName string) {
+	if start, end := strings.Index(dn, "/if-["), strings.LastIndex(dn, "]"); start >= 0 && end > start {
+		portName = dn[start+len("/if-[") : end]
+		dn = dn[:start]
+	}
+	podID, nodeID = NodeOfDN(dn)
+	return podID, nodeID, portName
+}
+
+// NodeOfDN reads the pod and the node from the dn of an object of a fabric node
+func NodeOfDN(dn string) (podID, nodeID string) {
+	for _, rn := range strings.Split(dn, "/") {
+		switch {
+		case strings.HasPrefix(rn, "pod-"):
+			podID = strings.TrimPrefix(rn, "pod-")
+		case strings.HasPrefix(rn, "node-"):
+			nodeID = strings.TrimPrefix(rn, "node-")
+		}
+	}
+	return podID, nodeID
+}
+
+// PortOem is the Oem property of the port
+type PortOem struct {
+	Cisco *CiscoPortOem `json:"Cisco,omitempty"`
+}
+
+// CiscoPortOem holds the ACI properties of the port
+type CiscoPortOem struct {
+	// Neighbors are the devices seen with LLDP or CDP on the port
+	Neighbors []PortNeighbor `json:"Neighbors,omitempty"`
+	// SuggestedConnectedPorts are the ODIM ethernet interfaces matching the neighbors
+	SuggestedConnectedPorts []model.Link `json:"SuggestedConnectedPorts,omitempty"`
+	// SupportedSpeeds are the speeds the port supports, read from APIC along with the port
+	SupportedSpeeds []string `json:"SupportedSpeeds,omitempty"`
+	// TransceiverDOM holds the DOM readings of the transceiver, read from APIC along with the port
+	TransceiverDOM *TransceiverDOM `json:"TransceiverDOM,omitempty"`
+}
+
+// PortNeighbor is a device seen on the port
+type PortNeighbor struct {
+	Protocol   string `json:"Protocol"`
+	ChassisID  string `json:"ChassisId,omitempty"`
+	PortID     string `json:"PortId,omitempty"`
+	SystemName string `json:"SystemName,omitempty"`
+}
+
+// GetPortOem returns the Oem property of the stored port
+func GetPortOem(port *model.Port) PortOem {
+	var oem PortOem
+	if port.Oem == nil {
+		return oem
+	}
+	// the Oem read from the DB is a generic map
+	data, err := json.Marshal(port.Oem)
+	if err == nil {
+		json.Unmarshal(data, &oem)
+	}
+	return oem
+}
+
+// ODIMEthernetInterface is an ethernet interface of a system managed by ODIM
+type ODIMEthernetInterface struct {
+	// URI is the uri of the interface in the form used by the plugin, like /ODIM/v1/Systems/{id}/EthernetInterfaces/{id}
+	URI                 string
+	ID                  string
+	Name                string
+	MACAddress          string
+	PermanentMACAddress string
+	// HostName is the host name of the system of the interface
+	HostName string
+}
@@ -0,0 +1,60 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package capmodel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	uuid "github.com/satori/go.uuid"
+)
+
+// resourceNamespace is the namespace of the UUIDs of the resources of the plugin,
+// it is the fabric UUID so that the IDs of the same ACI objects differ across deployments
+func resourceNamespace() uuid.UUID {
+	namespace, err := uuid.FromString(config.Data.RootServiceUUID)
+	if err != nil {
+		return uuid.NewV5(uuid.NamespaceOID, config.Data.RootServiceUUID)
+	}
+	return namespace
+}
+
+// SwitchID returns the ID of the switch of the fabric node, it is derived from the identity of the node
+// so that the switch keeps its URI when it is discovered again, the node ID is kept as the suffix
+func SwitchID(podID, serial, nodeID string) string {
+	name := fmt.Sprintf("topology/pod-%s/serial-%s", podID, serial)
+	return uuid.NewV5(resourceNamespace(), name).String() + ":" + nodeID
+}
+
+// ControllerID returns the ID of the manager of the APIC controller, it is derived from the identity
+// of the controller node like the switch IDs and the node ID is kept as the suffix
+func ControllerID(podID, serial, nodeID string) string {
+	name := fmt.Sprintf("topology/pod-%s/serial-%s/controller", podID, serial)
+	return uuid.NewV5(resourceNamespace(), name).String() + ":" + nodeID
+}
+
+// ChassisID returns the ID of the chassis of the fabric node, chassisID is the id of the eqptCh object
+func ChassisID(podID, serial, chassisID string) string {
+	name := fmt.Sprintf("topology/pod-%s/serial-%s/sys/ch", podID, serial)
+	return uuid.NewV5(resourceNamespace(), name).String() + ":" + chassisID
+}
+
+// PortID returns the ID of the port, it is derived from the DN of the interface and the port name
+// with the slashes replaced is kept as the suffix
+func PortID(podID, nodeID, portName string) string {
+	interfaceDN := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]", podID, nodeID, portName)
+	return uuid.NewV5(resourceNamespace(), interfaceDN).String() + ":" + strings.Replace(portName, "/", "-", -1)
+}
@@ -0,0 +1,47 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+)
+
+// Status is the Redfish status of a resource along with the conditions, which model.Status doesn't support
+type Status struct {
+	State        string      `json:"State,omitempty"`
+	Health       string      `json:"Health,omitempty"`
+	HealthRollup string      `json:"HealthRollup,omitempty"`
+	Conditions   []Condition `json:"Conditions,omitempty"`
+}
+
+// Condition is a Redfish condition which reports a fault affecting the health of the resource
+type Condition struct {
+	MessageID   string   `json:"MessageId"`
+	Message     string   `json:"Message,omitempty"`
+	MessageArgs []string `json:"MessageArgs,omitempty"`
+	Severity    string   `json:"Severity,omitempty"`
+	Timestamp   string   `json:"Timestamp,omitempty"`
+	Resolution  string   `json:"Resolution,omitempty"`
+}
+
+// DMTFStatus returns the status without the conditions for the resources which are stored as lib-dmtf models
+func (s *Status) DMTFStatus() *model.Status {
+	return &model.Status{
+		State:        s.State,
+		Health:       s.Health,
+		HealthRollup: s.HealthRollup,
+	}
+}
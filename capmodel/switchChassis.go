@@ -34,9 +34,32 @@ type SwitchChassisIMData struct {
 	SwitchChassisData SwitchChassisData `json:"eqptCh"`
 }
 
-// SwitchChassisData ...
+// SwitchChassisData is the eqptCh object of a switch
 type SwitchChassisData struct {
-	Attributes map[string]interface{} `json:"attributes"`
+	Attributes SwitchChassisAttributes `json:"attributes"`
+}
+
+// SwitchChassisAttributes are the attributes of eqptCh object
+type SwitchChassisAttributes struct {
+	DN     string `json:"dn"`
+	ID     string `json:"id"`
+	Descr  string `json:"descr"`
+	Model  string `json:"model"`
+	OperSt string `json:"operSt"`
+	Rev    string `json:"rev"`
+	Ser    string `json:"ser"`
+	Vendor string `json:"vendor"`
+}
+
+// Validate checks that all the eqptCh objects are valid
+func (s *SwitchChassis) Validate() error {
+	for _, imdata := range s.IMData {
+		attributes := imdata.SwitchChassisData.Attributes
+		if err := requireACIAttributes("eqptCh", attributes.DN, "id", attributes.ID, "model", attributes.Model, "ser", attributes.Ser); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetSwitch collects the switch data from the DB
@@ -57,6 +80,51 @@ func SaveSwitch(switchID string, data *model.Switch) error {
 	return SaveToDB(db.TableSwitch, switchID, *data)
 }
 
+// UpdateSwitch updates the switch data stored in the DB
+func UpdateSwitch(switchID string, data *model.Switch) error {
+	return UpdateDbData(db.TableSwitch, switchID, *data)
+}
+
+// DeleteSwitch deletes the switch data from the DB
+func DeleteSwitch(switchID string) error {
+	return db.Connector.Delete(db.TableSwitch, switchID)
+}
+
+// SwitchOem is the Oem property of the switch
+type SwitchOem struct {
+	Cisco *CiscoSwitchOem `json:"Cisco,omitempty"`
+}
+
+// CiscoSwitchOem holds the ACI properties of the switch
+type CiscoSwitchOem struct {
+	// Role is the role of the fabric node, leaf or spine
+	Role string `json:"Role,omitempty"`
+	// NodeType tells the kinds of leaves apart, like remote-leaf-wan for a remote leaf
+	NodeType string `json:"NodeType,omitempty"`
+}
+
+// GetSwitchOem returns the Oem property of the stored switch
+func GetSwitchOem(switchData *model.Switch) SwitchOem {
+	var oem SwitchOem
+	if switchData.Oem == nil {
+		return oem
+	}
+	// the Oem read from the DB is a generic map
+	data, err := json.Marshal(switchData.Oem)
+	if err == nil {
+		json.Unmarshal(data, &oem)
+	}
+	return oem
+}
+
+// GetSwitchRole returns the role of the fabric node of the switch
+func GetSwitchRole(switchData *model.Switch) string {
+	if oem := GetSwitchOem(switchData); oem.Cisco != nil {
+		return oem.Cisco.Role
+	}
+	return ""
+}
+
 // GetSwitchChassis collects the switch chassis data from the DB
 func GetSwitchChassis(chassisID string) (model.Chassis, error) {
 	var chassis model.Chassis
@@ -91,3 +159,13 @@ func GetAllSwitchChassis(pattern string) (map[string]model.Chassis, error) {
 func SaveSwitchChassis(chassisID string, data *model.Chassis) error {
 	return SaveToDB(db.TableSwitchChassis, chassisID, *data)
 }
+
+// UpdateSwitchChassis updates the switch chassis data stored in the DB
+func UpdateSwitchChassis(chassisID string, data *model.Chassis) error {
+	return UpdateDbData(db.TableSwitchChassis, chassisID, *data)
+}
+
+// DeleteSwitchChassis deletes the switch chassis data from the DB
+func DeleteSwitchChassis(chassisID string) error {
+	return db.Connector.Delete(db.TableSwitchChassis, chassisID)
+}
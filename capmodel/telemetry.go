@@ -0,0 +1,244 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// TelemetryServiceURI is the uri of the telemetry service of the plugin
+const TelemetryServiceURI = "/ODIM/v1/TelemetryService"
+
+// IDs of the metric report definitions, the metric report of a definition has the same ID
+const (
+	InterfaceUtilizationReport    = "InterfaceUtilization"
+	InterfaceErrorsReport         = "InterfaceErrors"
+	SwitchCPUUtilizationReport    = "SwitchCPUUtilization"
+	SwitchMemoryUtilizationReport = "SwitchMemoryUtilization"
+)
+
+// IDs of the metrics of the metric reports
+const (
+	RXUtilizationMetric     = "RXUtilizationPercent"
+	TXUtilizationMetric     = "TXUtilizationPercent"
+	RXErrorsMetric          = "RXErrors"
+	TXErrorsMetric          = "TXErrors"
+	RXCRCErrorsMetric       = "RXCRCErrors"
+	CPUUtilizationMetric    = "CPUUtilizationPercent"
+	MemoryUtilizationMetric = "MemoryUtilizationPercent"
+)
+
+// metricReportDefinition describes the metrics of a metric report
+type metricReportDefinition struct {
+	name    string
+	metrics []model.Metric
+}
+
+const (
+	portMetricsProperty = "/ODIM/v1/Fabrics/{FabricId}/Switches/{SwitchId}/Ports/{PortId}/Metrics#"
+	switchProperty      = "/ODIM/v1/Fabrics/{FabricId}/Switches/{SwitchId}"
+)
+
+var metricReportDefinitions = map[string]metricReportDefinition{
+	InterfaceUtilizationReport: {
+		name: "Interface utilization",
+		metrics: []model.Metric{
+			{MetricID: RXUtilizationMetric, MetricProperties: []string{portMetricsProperty + "/Oem/Cisco/RXUtilizationPercent"}},
+			{MetricID: TXUtilizationMetric, MetricProperties: []string{portMetricsProperty + "/Oem/Cisco/TXUtilizationPercent"}},
+		},
+	},
+	InterfaceErrorsReport: {
+		name: "Interface errors",
+		metrics: []model.Metric{
+			{MetricID: RXErrorsMetric, MetricProperties: []string{portMetricsProperty + "/RXErrors"}},
+			{MetricID: TXErrorsMetric, MetricProperties: []string{portMetricsProperty + "/TXErrors"}},
+			{MetricID: RXCRCErrorsMetric, MetricProperties: []string{portMetricsProperty + "/Networking/RXCRCErrors"}},
+		},
+	},
+	SwitchCPUUtilizationReport: {
+		name: "Switch CPU utilization",
+		metrics: []model.Metric{
+			{MetricID: CPUUtilizationMetric, MetricProperties: []string{switchProperty}},
+		},
+	},
+	SwitchMemoryUtilizationReport: {
+		name: "Switch memory utilization",
+		metrics: []model.Metric{
+			{MetricID: MemoryUtilizationMetric, MetricProperties: []string{switchProperty}},
+		},
+	},
+}
+
+// MetricReportDefinitionIDs are the IDs of the metric report definitions in the order they are listed
+var MetricReportDefinitionIDs = []string{
+	InterfaceUtilizationReport,
+	InterfaceErrorsReport,
+	SwitchCPUUtilizationReport,
+	SwitchMemoryUtilizationReport,
+}
+
+// GetMetricReportDefinition returns the metric report definition with the ID, the report is sent
+// as an event and stored at each interval, false is returned for an unknown ID
+func GetMetricReportDefinition(definitionID string, intervalInSeconds int) (*model.MetricReportDefinitions, bool) {
+	definition, ok := metricReportDefinitions[definitionID]
+	if !ok {
+		return nil, false
+	}
+	return &model.MetricReportDefinitions{
+		ODataID:                       TelemetryServiceURI + "/MetricReportDefinitions/" + definitionID,
+		ODataType:                     "#MetricReportDefinition.v1_3_0.MetricReportDefinition",
+		ID:                            definitionID,
+		Name:                          definition.name,
+		Description:                   definition.name + " of the fabric read from APIC",
+		MetricReport:                  model.Oid{ODataID: TelemetryServiceURI + "/MetricReports/" + definitionID},
+		MetricReportDefinitionEnabled: true,
+		MetricReportDefinitionType:    "Periodic",
+		Metrics:                       definition.metrics,
+		ReportActions:                 []string{"RedfishEvent", "LogToMetricReportsCollection"},
+		ReportUpdates:                 "Overwrite",
+		Schedule: model.Schedule{
+			RecurrenceInterval: "PT" + strconv.Itoa(intervalInSeconds) + "S",
+		},
+		Status: model.Status{
+			State:  "Enabled",
+			Health: "OK",
+		},
+	}, true
+}
+
+// ProcSysResponse is the response of the class query of procSysCPU5min or procSysMem5min
+type ProcSysResponse struct {
+	TotalCount string          `json:"totalCount"`
+	IMData     []ProcSysIMData `json:"imdata"`
+}
+
+// ProcSysIMData holds one of the objects of the class query
+type ProcSysIMData struct {
+	CPU    *ProcSysCPU `json:"procSysCPU5min,omitempty"`
+	Memory *ProcSysMem `json:"procSysMem5min,omitempty"`
+}
+
+// ProcSysCPU is the procSysCPU5min object giving the CPU usage of a switch over the last 5 minutes
+type ProcSysCPU struct {
+	Attributes ProcSysCPUAttributes `json:"attributes"`
+}
+
+// ProcSysCPUAttributes are the attributes of procSysCPU5min object, the usage is in percent
+type ProcSysCPUAttributes struct {
+	DN        string `json:"dn"`
+	UserAvg   string `json:"userAvg"`
+	KernelAvg string `json:"kernelAvg"`
+}
+
+// ProcSysMem is the procSysMem5min object giving the memory usage of a switch over the last 5 minutes
+type ProcSysMem struct {
+	Attributes ProcSysMemAttributes `json:"attributes"`
+}
+
+// ProcSysMemAttributes are the attributes of procSysMem5min object, the memory is in KB
+type ProcSysMemAttributes struct {
+	DN       string `json:"dn"`
+	UsedAvg  string `json:"usedAvg"`
+	TotalAvg string `json:"totalAvg"`
+}
+
+// Validate drops the procSysCPU5min and procSysMem5min objects with non numeric usages,
+// they are logged and skipped so that a bad object doesn't fail the usages of all the switches
+func (p *ProcSysResponse) Validate() error {
+	valid := p.IMData[:0]
+	for _, imdata := range p.IMData {
+		if err := imdata.validate(); err != nil {
+			log.Warn("skipping the switch usage: " + err.Error())
+			continue
+		}
+		valid = append(valid, imdata)
+	}
+	p.IMData = valid
+	return nil
+}
+
+// validate checks that the usages of the object are numeric
+func (imdata *ProcSysIMData) validate() error {
+	var className, dn string
+	var attributes []string
+	switch {
+	case imdata.CPU != nil:
+		className, dn = "procSysCPU5min", imdata.CPU.Attributes.DN
+		attributes = []string{"userAvg", imdata.CPU.Attributes.UserAvg, "kernelAvg", imdata.CPU.Attributes.KernelAvg}
+	case imdata.Memory != nil:
+		className, dn = "procSysMem5min", imdata.Memory.Attributes.DN
+		attributes = []string{"usedAvg", imdata.Memory.Attributes.UsedAvg, "totalAvg", imdata.Memory.Attributes.TotalAvg}
+	default:
+		return nil
+	}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if _, err := strconv.ParseFloat(attributes[i+1], 64); err != nil {
+			return fmt.Errorf("%w: %s %s has non numeric %s attribute %q", ErrorInvalidACIObject, className, dn, attributes[i], attributes[i+1])
+		}
+	}
+	return nil
+}
+
+// CPUUtilization is the percentage of the CPU used by the user and the kernel processes
+func (p *ProcSysCPUAttributes) CPUUtilization() float64 {
+	// the usages are validated as numeric
+	user, _ := strconv.ParseFloat(p.UserAvg, 64)
+	kernel, _ := strconv.ParseFloat(p.KernelAvg, 64)
+	return user + kernel
+}
+
+// MemoryUtilization is the percentage of the memory used, it is 0 when the total memory isn't known
+func (p *ProcSysMemAttributes) MemoryUtilization() float64 {
+	used, _ := strconv.ParseFloat(p.UsedAvg, 64)
+	total, _ := strconv.ParseFloat(p.TotalAvg, 64)
+	if total <= 0 {
+		return 0
+	}
+	return used * 100 / total
+}
+
+// GetMetricReport collects the last metric report of the metric report definition from the DB
+func GetMetricReport(reportID string) (*model.MetricReports, error) {
+	var report model.MetricReports
+	data, err := db.Connector.Get(db.TableMetricReport, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(data), &report); err != nil {
+		return nil, fmt.Errorf("while trying to unmarshal metric report data, got: %v", err)
+	}
+	return &report, nil
+}
+
+// GetMetricReportIDs collects the IDs of the stored metric reports from the DB
+func GetMetricReportIDs() ([]string, error) {
+	reportIDs, err := db.Connector.GetAllMatchingKeys(db.TableMetricReport, "")
+	if err != nil {
+		return nil, fmt.Errorf("while trying to collect all metric report data, got: %w", err)
+	}
+	return reportIDs, nil
+}
+
+// UpdateMetricReport stores the metric report in the DB, replacing the previous report of its definition
+func UpdateMetricReport(report *model.MetricReports) error {
+	return UpdateDbData(db.TableMetricReport, report.ID, *report)
+}
@@ -0,0 +1,151 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capmodel ...
+package capmodel
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DOM sensor classes of the transceivers, they are the children of ethpmDOMStats
+const (
+	DOMTemperatureSensorClass = "ethpmDOMTempSensor"
+	DOMVoltageSensorClass     = "ethpmDOMVoltSensor"
+	DOMCurrentSensorClass     = "ethpmDOMCurrentSensor"
+	DOMTxPowerSensorClass     = "ethpmDOMTxPwrSensor"
+	DOMRxPowerSensorClass     = "ethpmDOMRxPwrSensor"
+)
+
+// PortTransceiverResponse is the response of the subtree query of ethpmPhysIf for the transceiver,
+// the capabilities and the DOM sensors of the port
+type PortTransceiverResponse struct {
+	TotalCount string                  `json:"totalCount"`
+	IMData     []PortTransceiverIMData `json:"imdata"`
+}
+
+// PortTransceiverIMData holds one of the objects of the subtree query
+type PortTransceiverIMData struct {
+	Fcot        *Fcot      `json:"ethpmFcot,omitempty"`
+	PortCap     *PortCap   `json:"ethpmPortCap,omitempty"`
+	Temperature *DOMSensor `json:"ethpmDOMTempSensor,omitempty"`
+	Voltage     *DOMSensor `json:"ethpmDOMVoltSensor,omitempty"`
+	Current     *DOMSensor `json:"ethpmDOMCurrentSensor,omitempty"`
+	TxPower     *DOMSensor `json:"ethpmDOMTxPwrSensor,omitempty"`
+	RxPower     *DOMSensor `json:"ethpmDOMRxPwrSensor,omitempty"`
+}
+
+// Fcot is the ethpmFcot object of the transceiver plugged in a port
+type Fcot struct {
+	Attributes FcotAttributes `json:"attributes"`
+}
+
+// FcotAttributes are the attributes of ethpmFcot object, the gui attributes hold the Cisco
+// identity of the transceiver and the vendor attributes the identity given by its vendor
+type FcotAttributes struct {
+	DN            string `json:"dn"`
+	IsFcotPresent string `json:"isFcotPresent"`
+	State         string `json:"state"`
+	TypeName      string `json:"typeName"`
+	GuiName       string `json:"guiName"`
+	GuiPN         string `json:"guiPN"`
+	GuiSN         string `json:"guiSN"`
+	GuiRev        string `json:"guiRev"`
+	VendorName    string `json:"vendorName"`
+	VendorPn      string `json:"vendorPn"`
+	VendorSn      string `json:"vendorSn"`
+}
+
+// PortCap is the ethpmPortCap object giving the capabilities of a port
+type PortCap struct {
+	Attributes PortCapAttributes `json:"attributes"`
+}
+
+// PortCapAttributes are the attributes of ethpmPortCap object, speed lists the speeds the port supports
+type PortCapAttributes struct {
+	DN    string `json:"dn"`
+	Speed string `json:"speed"`
+}
+
+// DOMSensor is a DOM sensor object of the transceiver
+type DOMSensor struct {
+	Attributes DOMSensorAttributes `json:"attributes"`
+}
+
+// DOMSensorAttributes are the attributes of the DOM sensor objects, the power is in dBm
+type DOMSensorAttributes struct {
+	DN      string `json:"dn"`
+	Value   string `json:"value"`
+	HiAlarm string `json:"hiAlarm"`
+	LoAlarm string `json:"loAlarm"`
+	HiWarn  string `json:"hiWarn"`
+	LoWarn  string `json:"loWarn"`
+}
+
+// Validate checks that all the objects of the subtree query are valid
+func (p *PortTransceiverResponse) Validate() error {
+	for _, imdata := range p.IMData {
+		if imdata.Fcot != nil {
+			if err := requireACIAttributes("ethpmFcot", imdata.Fcot.Attributes.DN, "dn", imdata.Fcot.Attributes.DN); err != nil {
+				return err
+			}
+		}
+		for className, sensor := range imdata.sensors() {
+			if sensor == nil {
+				continue
+			}
+			if err := requireACIAttributes(className, sensor.Attributes.DN, "value", sensor.Attributes.Value); err != nil {
+				return err
+			}
+			if _, err := strconv.ParseFloat(sensor.Attributes.Value, 64); err != nil {
+				return fmt.Errorf("%w: %s %s has non numeric value attribute %q", ErrorInvalidACIObject, className, sensor.Attributes.DN, sensor.Attributes.Value)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *PortTransceiverIMData) sensors() map[string]*DOMSensor {
+	return map[string]*DOMSensor{
+		DOMTemperatureSensorClass: p.Temperature,
+		DOMVoltageSensorClass:     p.Voltage,
+		DOMCurrentSensorClass:     p.Current,
+		DOMTxPowerSensorClass:     p.TxPower,
+		DOMRxPowerSensorClass:     p.RxPower,
+	}
+}
+
+// PortTransceiver is the transceiver of a port along with its DOM readings and the speeds the port supports
+type PortTransceiver struct {
+	// Fcot is nil when APIC gives no ethpmFcot for the port
+	Fcot            *FcotAttributes
+	SupportedSpeeds []string
+	DOM             *TransceiverDOM
+}
+
+// TransceiverDOM holds the digital optical monitoring readings of the transceiver,
+// the readings the transceiver doesn't give are nil
+type TransceiverDOM struct {
+	TemperatureCelsius     *float64 `json:"TemperatureCelsius,omitempty"`
+	SupplyVoltage          *float64 `json:"SupplyVoltage,omitempty"`
+	TXBiasCurrentMilliAmps *float64 `json:"TXBiasCurrentMilliAmps,omitempty"`
+	TXOutputPowerDBm       *float64 `json:"TXOutputPowerDBm,omitempty"`
+	RXInputPowerDBm        *float64 `json:"RXInputPowerDBm,omitempty"`
+}
+
+// IsPresent tells if a transceiver is plugged in the port
+func (f *FcotAttributes) IsPresent() bool {
+	return f.IsFcotPresent == "yes" || f.IsFcotPresent == "true"
+}
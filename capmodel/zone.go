@@ -16,6 +16,7 @@ package capmodel
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/ODIM-Project/ODIM/lib-dmtf/model"
@@ -25,16 +26,22 @@ import (
 
 // GetZone collects the zone data from the DB
 func GetZone(fabricID, zoneURI string) (model.Zone, error) {
+	zone, _, err := GetZoneWithETag(fabricID, zoneURI)
+	return zone, err
+}
+
+// GetZoneWithETag collects the zone data from the DB along with its entity tag
+func GetZoneWithETag(fabricID, zoneURI string) (model.Zone, string, error) {
 	var zone model.Zone
 	key := fmt.Sprintf("%s:%s", fabricID, zoneURI)
 	data, err := db.Connector.Get(db.TableZone, key)
 	if err != nil {
-		return zone, fmt.Errorf("while trying to collect zone data, got: %w", err)
+		return zone, "", fmt.Errorf("while trying to collect zone data, got: %w", err)
 	}
 	if err = json.Unmarshal([]byte(data), &zone); err != nil {
-		return zone, fmt.Errorf("while trying to unmarshal zone data, got: %v", err)
+		return zone, "", fmt.Errorf("while trying to unmarshal zone data, got: %v", err)
 	}
-	return zone, nil
+	return zone, ETag(data), nil
 }
 
 // GetZoneDomain collects the ZoneToDomainDN data from the DB
@@ -95,6 +102,29 @@ func UpdateZone(fabricID, zoneURI string, data *model.Zone) error {
 	return UpdateDbData(db.TableZone, key, *data)
 }
 
+// UpdateZoneIfMatch updates the zone data stored in the DB if its entity tag is still etag
+func UpdateZoneIfMatch(fabricID, zoneURI, etag string, data *model.Zone) (string, error) {
+	key := fmt.Sprintf("%s:%s", fabricID, zoneURI)
+	return UpdateDbDataIfMatch(db.TableZone, key, etag, *data)
+}
+
+// ModifyZone applies modify on the zone data stored in the DB and stores it back,
+// the zone is read and modified again when another request updated it in between
+func ModifyZone(fabricID, zoneURI string, modify func(zone *model.Zone)) error {
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		zone, etag, getErr := GetZoneWithETag(fabricID, zoneURI)
+		if getErr != nil {
+			return getErr
+		}
+		modify(&zone)
+		if _, err = UpdateZoneIfMatch(fabricID, zoneURI, etag, &zone); !errors.Is(err, db.ErrorDataChanged) {
+			return err
+		}
+	}
+	return err
+}
+
 // DeleteZone deletes the zone data stored in the DB
 func DeleteZone(fabricID, zoneURI string) error {
 	key := fmt.Sprintf("%s:%s", fabricID, zoneURI)
@@ -15,6 +15,7 @@
 package capmodel
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -110,3 +111,54 @@ func TestGetAllZones(t *testing.T) {
 		})
 	}
 }
+
+// racingConnector updates the stored data on behalf of another request right before the first swap
+type racingConnector struct {
+	db.InMemoryConnector
+	race func()
+}
+
+func (r *racingConnector) CompareAndSwap(table, resourceID, oldData, newData string) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.InMemoryConnector.CompareAndSwap(table, resourceID, oldData, newData)
+}
+
+func TestModifyZone(t *testing.T) {
+	connector := &racingConnector{InMemoryConnector: db.NewInMemoryConnector()}
+	db.Connector = connector
+	defer func() {
+		db.Connector = db.MockConnector{}
+	}()
+	zoneURI := "/ODIM/v1/Fabrics/validID/Zones/defaultZone"
+	if err := SaveZone("validID", zoneURI, &model.Zone{ID: "defaultZone", Links: &model.ZoneLinks{}}); err != nil {
+		t.Fatalf("SaveZone() error = %v", err)
+	}
+	addZone := func(oid string) func(zone *model.Zone) {
+		return func(zone *model.Zone) {
+			zone.Links.ContainsZones = append(zone.Links.ContainsZones, model.Link{Oid: oid})
+		}
+	}
+	connector.race = func() {
+		if err := ModifyZone("validID", zoneURI, addZone("zoneA")); err != nil {
+			t.Errorf("ModifyZone() of the racing request error = %v", err)
+		}
+	}
+	if err := ModifyZone("validID", zoneURI, addZone("zoneB")); err != nil {
+		t.Fatalf("ModifyZone() error = %v", err)
+	}
+
+	zone, err := GetZone("validID", zoneURI)
+	if err != nil {
+		t.Fatalf("GetZone() error = %v", err)
+	}
+	want := []model.Link{{Oid: "zoneA"}, {Oid: "zoneB"}}
+	if !reflect.DeepEqual(zone.Links.ContainsZones, want) {
+		t.Errorf("ModifyZone() lost an update, got %v, want %v", zone.Links.ContainsZones, want)
+	}
+	if err = ModifyZone("validID", "missingZone", addZone("zoneC")); !errors.Is(err, db.ErrorKeyNotFound) {
+		t.Errorf("ModifyZone() on missing zone error = %v, want %v", err, db.ErrorKeyNotFound)
+	}
+}
@@ -16,10 +16,23 @@
 package capresponse
 
 import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/ODIM/lib-utilities/response"
 	iris "github.com/kataras/iris/v12"
 )
 
+// ServiceTemporarilyUnavailable is the message of the error returned when the request can't be served for a while
+const ServiceTemporarilyUnavailable = response.BaseVersion + "ServiceTemporarilyUnavailable"
+
+// PreconditionFailed is the message of the error returned when the If-Match header doesn't match the resource
+const PreconditionFailed = response.BaseVersion + "PreconditionFailed"
+
 // SetErrorResponse will accepts the iris context, error string and status code
 // it will set error resopnse to ctx
 func SetErrorResponse(ctx iris.Context, statusCode int32, statusMsg, errMsg string, msgArgs []interface{}) {
@@ -28,3 +41,56 @@ func SetErrorResponse(ctx iris.Context, statusCode int32, statusMsg, errMsg stri
 	common.SetResponseHeader(ctx, resp.Header)
 	ctx.JSON(resp.Body)
 }
+
+// NewServiceTemporarilyUnavailable returns the ServiceTemporarilyUnavailable error response
+// asking to retry the request after retryAfter
+func NewServiceTemporarilyUnavailable(retryAfter time.Duration, errMsg string) response.CommonError {
+	seconds := retryAfterSeconds(retryAfter)
+	return response.CommonError{
+		Error: response.ErrorClass{
+			Code:    response.GeneralError,
+			Message: errMsg,
+			MessageExtendedInfo: []response.Msg{
+				response.Msg{
+					OdataType:   response.ErrorMessageOdataType,
+					MessageID:   ServiceTemporarilyUnavailable,
+					Message:     fmt.Sprintf("The service is temporarily unavailable.  Retry in %s seconds.", seconds),
+					Severity:    "Critical",
+					MessageArgs: []interface{}{seconds},
+					Resolution:  "Wait for the indicated retry duration and retry the operation.",
+				},
+			},
+		},
+	}
+}
+
+// SetServiceTemporarilyUnavailableResponse sets the ServiceTemporarilyUnavailable error response
+// along with the Retry-After header to ctx
+func SetServiceTemporarilyUnavailableResponse(ctx iris.Context, retryAfter time.Duration, errMsg string) {
+	ctx.Header("Retry-After", retryAfterSeconds(retryAfter))
+	ctx.StatusCode(http.StatusServiceUnavailable)
+	ctx.JSON(NewServiceTemporarilyUnavailable(retryAfter, errMsg))
+}
+
+// NewPreconditionFailed returns the PreconditionFailed error response
+func NewPreconditionFailed(errMsg string) response.CommonError {
+	return response.CommonError{
+		Error: response.ErrorClass{
+			Code:    response.GeneralError,
+			Message: errMsg,
+			MessageExtendedInfo: []response.Msg{
+				response.Msg{
+					OdataType:  response.ErrorMessageOdataType,
+					MessageID:  PreconditionFailed,
+					Message:    "The ETag supplied did not match the ETag required to change this resource.",
+					Severity:   "Critical",
+					Resolution: "Try the operation again using the appropriate ETag.",
+				},
+			},
+		},
+	}
+}
+
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
@@ -22,6 +22,34 @@ type PluginStatusResponse struct {
 	Version         string          `json:"Version"`
 	Status          Status          `json:"Status"`
 	EventMessageBus EventMessageBus `json:"EventMessageBus"`
+	Locks           Locks           `json:"Locks"`
+	Leadership      Leadership      `json:"Leadership"`
+}
+
+// Leadership holds the plugin instance elected as leader among the replicas and the role of the answering instance,
+// only the leader runs the background tasks and sends the fabric events
+type Leadership struct {
+	Instance  string `json:"Instance"`
+	Role      string `json:"Role"`
+	Leader    string `json:"Leader,omitempty"`
+	ElectedAt string `json:"ElectedAt,omitempty"`
+}
+
+// Locks holds the locks currently held by all the plugin instances and the lock counters of this instance
+type Locks struct {
+	HeldLocks []HeldLock `json:"HeldLocks,omitempty"`
+	Acquired  int64      `json:"Acquired"`
+	Contended int64      `json:"Contended"`
+	TimedOut  int64      `json:"TimedOut"`
+	Expired   int64      `json:"Expired"`
+}
+
+// HeldLock holds the information of a lock and of the request holding it
+type HeldLock struct {
+	Name       string `json:"Name"`
+	Holder     string `json:"Holder"`
+	AcquiredAt string `json:"AcquiredAt"`
+	ExpiresAt  string `json:"ExpiresAt"`
 }
 
 // Status holds information of Plugin Status
@@ -42,3 +70,12 @@ type EmbQueue struct {
 	QueueName string `json:"EmbQueueName"`
 	QueueDesc string `json:"EmbQueueDesc"`
 }
+
+// PluginStartupResponse holds what changed while reconciling the plugin with the startup data of ODIM
+type PluginStartupResponse struct {
+	RequestType           string            `json:"RequestType"`
+	AddedFabrics          []string          `json:"AddedFabrics"`
+	UnknownDevices        []string          `json:"UnknownDevices"`
+	ResyncedSubscriptions []string          `json:"ResyncedSubscriptions"`
+	FailedSubscriptions   map[string]string `json:"FailedSubscriptions,omitempty"`
+}
@@ -0,0 +1,34 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package capresponse ...
+package capresponse
+
+import (
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+)
+
+// TelemetryService is the telemetry service of the plugin
+type TelemetryService struct {
+	OdataContext            string            `json:"@odata.context"`
+	OdataID                 string            `json:"@odata.id"`
+	OdataType               string            `json:"@odata.type"`
+	ID                      string            `json:"Id"`
+	Name                    string            `json:"Name"`
+	Description             string            `json:"Description"`
+	ServiceEnabled          bool              `json:"ServiceEnabled"`
+	MetricReportDefinitions *dmtfmodel.Link   `json:"MetricReportDefinitions"`
+	MetricReports           *dmtfmodel.Link   `json:"MetricReports"`
+	Status                  *dmtfmodel.Status `json:"Status"`
+}
@@ -16,11 +16,12 @@
 package caputilities
 
 import (
+	"crypto/tls"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	lutilconf "github.com/ODIM-Project/ODIM/lib-utilities/config"
 	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
@@ -29,125 +30,206 @@ import (
 	"github.com/ciscoecosystem/aci-go-client/models"
 )
 
-// ACIHTTPClient struct definition of HTTP wraper clinet used to communicate with ACI
-type ACIHTTPClient struct {
-	httpClient *http.Client
-}
-
 var aciClient *client.Client
 var aciServiceManager *client.ServiceManager
 
+// APICTransport when set is used for sending all the requests to APIC, it's meant for
+// replaying the recorded APIC responses in tests
+var APICTransport http.RoundTripper
+
+// newACIClient returns a new client for APIC
+func newACIClient() *client.Client {
+	return client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName,
+		client.Password(config.Data.APICConf.Password),
+		client.Insecure(true),
+		client.HttpClient(&http.Client{Transport: getAPICTransport(nil)}),
+	)
+}
+
+// getAPICHTTPClient returns the http client used for the APIC queries which are not supported by the aci client
+func getAPICHTTPClient() (*http.Client, error) {
+	httpConf := &lutilconf.HTTPConfig{
+		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
+	}
+	httpClient, err := httpConf.GetHTTPClientObj()
+	if err != nil {
+		return nil, err
+	}
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{Transport: getAPICTransport(transport), Timeout: httpClient.Timeout}, nil
+}
+
+// getAPICTLSConfig returns the TLS configuration of the APIC REST client built from the root CA and the TLSConf,
+// it is used for the connections which are not made by the http client
+func getAPICTLSConfig() (*tls.Config, error) {
+	httpConf := &lutilconf.HTTPConfig{
+		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
+	}
+	tlsConfig := &tls.Config{}
+	if err := httpConf.LoadCertificates(tlsConfig); err != nil {
+		return nil, err
+	}
+	lutilconf.TLSConfMutex.RLock()
+	lutilconf.Client.SetTLSConfig(tlsConfig)
+	lutilconf.TLSConfMutex.RUnlock()
+	return tlsConfig, nil
+}
+
+// getAPICTransport wraps the given transport for retrying the failed APIC calls and for recording
+// the APIC exchanges when RecordPath is configured, nil transport stands for the insecure transport used by the aci client
+func getAPICTransport(transport http.RoundTripper) http.RoundTripper {
+	switch {
+	case APICTransport != nil:
+		transport = APICTransport
+	case transport == nil:
+		transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	if APICTransport == nil && config.Data.APICConf.RecordPath != "" {
+		transport = &RecordingTransport{
+			Transport: transport,
+			Path:      config.Data.APICConf.RecordPath,
+		}
+	}
+	return &RetryTransport{
+		Transport: transport,
+	}
+}
+
 // GetClient returns a new connection client to APIC
 func GetClient() *client.Client {
-	aciClient = client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
+	aciClient = newACIClient()
 	return aciClient
 }
 
 // GetConnection returns a new connection to APIC
 func GetConnection() *client.ServiceManager {
-	aciClient = client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
+	aciClient = newACIClient()
 	aciServiceManager = client.NewServiceManager(client.DefaultMOURL, aciClient)
 	return aciServiceManager
 }
 
 // GetFabricNodeData collects the all switch and fabric  details from the aci
 func GetFabricNodeData() ([]*models.FabricNodeMember, error) {
-	aciClient = client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
+	aciClient = newACIClient()
 	aciServiceManager = client.NewServiceManager(client.DefaultMOURL, aciClient)
 	return aciServiceManager.ListFabricNodeMember()
 
 }
 
-// GetPortData collects the all port data for the given switch
-func GetPortData(podID, ACISwitchID string) (*capmodel.PortCollectionResponse, error) {
-	endpoint := fmt.Sprintf("https://%s/api/node/class/topology/pod-%s/node-%s/l1PhysIf.json", config.Data.APICConf.APICHost, podID, ACISwitchID)
-
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
+// GetFabricNodes collects the fabricNode objects of all the nodes registered in the fabric
+func GetFabricNodes() ([]capmodel.FabricNode, error) {
+	var fabricNodeData capmodel.FabricNodeResponse
+	if err := QueryACIClass("", "fabricNode", nil, &fabricNodeData); err != nil {
 		return nil, err
 	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
-	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
-		return nil, err
+	nodes := make([]capmodel.FabricNode, 0, len(fabricNodeData.IMData))
+	for _, imdata := range fabricNodeData.IMData {
+		nodes = append(nodes, imdata.FabricNode)
 	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	req.Close = true
+	return nodes, nil
+}
 
-	resp, err := newClient.httpClient.Do(req)
-	if err != nil {
+// GetFabricLinks collects the fabricLink objects of the links between the leaves and the spines
+func GetFabricLinks() ([]capmodel.FabricLinkAttributes, error) {
+	var fabricLinkData capmodel.FabricLinkResponse
+	if err := QueryACIClass("", "fabricLink", nil, &fabricLinkData); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	links := make([]capmodel.FabricLinkAttributes, 0, len(fabricLinkData.IMData))
+	for _, imdata := range fabricLinkData.IMData {
+		links = append(links, imdata.FabricLink.Attributes)
+	}
+	return links, nil
+}
+
+// GetLLDPAdjacencies collects the neighbors seen with LLDP on the ports of all the switches
+func GetLLDPAdjacencies() ([]capmodel.LLDPAdjacencyAttributes, error) {
+	var adjacencyData capmodel.LLDPAdjacencyResponse
+	if err := QueryACIClass("", "lldpAdjEp", nil, &adjacencyData); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, fmt.Errorf(errMsg)
+	adjacencies := make([]capmodel.LLDPAdjacencyAttributes, 0, len(adjacencyData.IMData))
+	for _, imdata := range adjacencyData.IMData {
+		adjacencies = append(adjacencies, imdata.LLDPAdjacency.Attributes)
 	}
-
-	var portResponseData capmodel.PortCollectionResponse
-	json.Unmarshal(body, &portResponseData)
-	return &portResponseData, nil
-
+	return adjacencies, nil
 }
 
-// GetFabricHealth queries the fabric for it's Health from ACI
-func GetFabricHealth(podID string) (*capmodel.FabricHealth, error) {
-	aciClient := client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
-	// Get the port data for given switch using the uri /api/node/mo/topology/{pod_id}/health.json
-	err := aciClient.Authenticate()
-	if err != nil {
+// GetCDPAdjacencies collects the neighbors seen with CDP on the ports of all the switches
+func GetCDPAdjacencies() ([]capmodel.CDPAdjacencyAttributes, error) {
+	var adjacencyData capmodel.CDPAdjacencyResponse
+	if err := QueryACIClass("", "cdpAdjEp", nil, &adjacencyData); err != nil {
 		return nil, err
 	}
-	endpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/health.json", config.Data.APICConf.APICHost, podID)
+	adjacencies := make([]capmodel.CDPAdjacencyAttributes, 0, len(adjacencyData.IMData))
+	for _, imdata := range adjacencyData.IMData {
+		adjacencies = append(adjacencies, imdata.CDPAdjacency.Attributes)
+	}
+	return adjacencies, nil
+}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
+// GetControllerSystem collects the topSystem of the given APIC controller
+func GetControllerSystem(podID, nodeID string) (*capmodel.TopSystemAttributes, error) {
+	var topSystemData capmodel.TopSystemResponse
+	if err := QueryACIMO(fmt.Sprintf("topology/pod-%s/node-%s/sys", podID, nodeID), nil, &topSystemData); err != nil {
 		return nil, err
 	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
+	if len(topSystemData.IMData) == 0 {
+		return nil, fmt.Errorf("%w: no topSystem found for controller node-%s of pod-%s", capmodel.ErrorACIObjectNotFound, nodeID, podID)
 	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
+	return &topSystemData.IMData[0].TopSystem.Attributes, nil
+}
+
+// GetClusterNodes collects the APIC cluster members as seen by the given APIC controller
+func GetClusterNodes(podID, nodeID string) ([]capmodel.InfraWiNodeAttributes, error) {
+	var clusterData capmodel.InfraWiNodeResponse
+	if err := QueryACIClass(fmt.Sprintf("topology/pod-%s/node-%s", podID, nodeID), "infraWiNode", nil, &clusterData); err != nil {
 		return nil, err
 	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	req.Close = true
+	nodes := make([]capmodel.InfraWiNodeAttributes, 0, len(clusterData.IMData))
+	for _, imdata := range clusterData.IMData {
+		nodes = append(nodes, imdata.InfraWiNode.Attributes)
+	}
+	return nodes, nil
+}
 
-	resp, err := newClient.httpClient.Do(req)
-	if err != nil {
+// GetPortData collects the all port data for the given switch
+func GetPortData(podID, ACISwitchID string) ([]capmodel.PhysicalInterface, error) {
+	var portResponseData capmodel.PortCollectionResponse
+	if err := QueryACIClass(fmt.Sprintf("topology/pod-%s/node-%s", podID, ACISwitchID), "l1PhysIf", nil, &portResponseData); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	ports := make([]capmodel.PhysicalInterface, 0, len(portResponseData.IMData))
+	for _, imdata := range portResponseData.IMData {
+		ports = append(ports, imdata.PhysicalInterface)
+	}
+	return ports, nil
+}
+
+// GetFabricHealth queries the fabric for it's Health from ACI
+func GetFabricHealth(podID string, policy CachePolicy) (*capmodel.FabricHealthData, error) {
+	dn := fmt.Sprintf("topology/pod-%s/health", podID)
+	object, err := readCachedACIMO(dn, policy, func() (interface{}, error) {
+		var fabricHealthData capmodel.FabricHealth
+		if err := QueryACIMO(dn, nil, &fabricHealthData); err != nil {
+			return nil, err
+		}
+		if len(fabricHealthData.IMData) == 0 {
+			return nil, fmt.Errorf("%w: no fabricHealthTotal found for pod-%s", capmodel.ErrorACIObjectNotFound, podID)
+		}
+		return fabricHealthData.IMData[0].FabricHealthData, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, fmt.Errorf(errMsg)
-	}
-
-	var fabricHealthData capmodel.FabricHealth
-	json.Unmarshal(body, &fabricHealthData)
-	return &fabricHealthData, nil
-
+	fabricHealth := object.(capmodel.FabricHealthData)
+	return &fabricHealth, nil
 }
 
 // GetSwitchInfo collects the given switch data from the aci
@@ -157,217 +239,391 @@ func GetSwitchInfo(podID, ACISwitchID int) (*models.System, error) {
 }
 
 // GetSwitchChassisInfo collects the given switch chassis data from the aci
-func GetSwitchChassisInfo(podID, ACISwitchID string) (*capmodel.SwitchChassis, *capmodel.Health, error) {
-	endpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/node-%s/sys/ch.json", config.Data.APICConf.APICHost, podID, ACISwitchID)
-
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
+func GetSwitchChassisInfo(podID, ACISwitchID string) (*capmodel.SwitchChassisData, error) {
+	var switchChassisData capmodel.SwitchChassis
+	if err := QueryACIMO(fmt.Sprintf("topology/pod-%s/node-%s/sys/ch", podID, ACISwitchID), nil, &switchChassisData); err != nil {
+		return nil, err
 	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
-		return nil, nil, err
+	if len(switchChassisData.IMData) == 0 {
+		return nil, fmt.Errorf("%w: no eqptCh found for node-%s of pod-%s", capmodel.ErrorACIObjectNotFound, ACISwitchID, podID)
 	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	req.Close = true
+	return &switchChassisData.IMData[0].SwitchChassisData, nil
+}
 
-	resp, err := newClient.httpClient.Do(req)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, err
-	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, nil, fmt.Errorf(errMsg)
-	}
+// GetSwitchChassisHealth queries the chassis of the switch for its Health from ACI
+func GetSwitchChassisHealth(podID, ACISwitchID string, policy CachePolicy) (*capmodel.HealthData, error) {
+	dn := fmt.Sprintf("topology/pod-%s/node-%s/sys/ch/health", podID, ACISwitchID)
+	return getCachedHealth(dn, policy, fmt.Sprintf("chassis of node-%s of pod-%s", ACISwitchID, podID))
+}
 
-	var switchChassisData capmodel.SwitchChassis
-	var chassisHealth capmodel.Health
-	json.Unmarshal(body, &switchChassisData)
-	healthEndpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/node-%s/sys/ch/health.json", config.Data.APICConf.APICHost, podID, ACISwitchID)
+// GetSwitchHealth queries the switch for it's Health from ACI
+func GetSwitchHealth(podID, ACISwitchID string, policy CachePolicy) (*capmodel.HealthData, error) {
+	dn := fmt.Sprintf("topology/pod-%s/node-%s/sys/health", podID, ACISwitchID)
+	return getCachedHealth(dn, policy, fmt.Sprintf("node-%s of pod-%s", ACISwitchID, podID))
+}
 
-	healthReq, err := http.NewRequest("GET", healthEndpoint, nil)
+// GetPortInfo collects the dat for  given port
+func GetPortInfo(podID, ACISwitchID, portID string, policy CachePolicy) (*capmodel.PortInfo, error) {
+	dn := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]/phys", podID, ACISwitchID, portID)
+	object, err := readCachedACIMO(dn, policy, func() (interface{}, error) {
+		var portResponseData capmodel.PortInfoResponse
+		if err := QueryACIMO(dn, nil, &portResponseData); err != nil {
+			return nil, err
+		}
+		if len(portResponseData.IMData) == 0 {
+			return nil, fmt.Errorf("%w: no ethpmPhysIf found for port %s of node-%s of pod-%s", capmodel.ErrorACIObjectNotFound, portID, ACISwitchID, podID)
+		}
+		return portResponseData.IMData[0].PortInfo, nil
+	})
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	healthReq.Close = true
-	healthReq.Header.Set("Accept", "application/json")
-	healthReq.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	healthReq.Close = true
+	portInfo := object.(capmodel.PortInfo)
+	return &portInfo, nil
+}
 
-	healthResp, err := newClient.httpClient.Do(healthReq)
-	if err != nil {
-		return nil, nil, err
+// SetPortInService takes the port out of service by blacklisting its path, or back into service
+// by removing the blacklisting
+func SetPortInService(podID, ACISwitchID, portID string, inService bool) error {
+	oosPath := capmodel.NewOutOfServicePath(podID, ACISwitchID, portID)
+	if inService {
+		return GetConnection().DeleteByDn(oosPath.DN(), capmodel.OutOfServicePathClass)
 	}
-	defer healthResp.Body.Close()
-	healthBody, err := ioutil.ReadAll(healthResp.Body)
+	return GetConnection().Save(oosPath)
+}
+
+// GetPortBasePolicyGroup returns the dn of the policy group given to the port by the access port selectors
+// of the interface profiles of the leaf profiles selecting the node, the dn is empty when the port isn't selected
+func GetPortBasePolicyGroup(ACISwitchID, portID string) (string, error) {
+	nodeID, err := strconv.Atoi(ACISwitchID)
 	if err != nil {
-		return nil, nil, err
+		return "", fmt.Errorf("malformed node id %s", ACISwitchID)
 	}
-	json.Unmarshal(healthBody, &chassisHealth)
-	return &switchChassisData, &chassisHealth, nil
+	port, err := capmodel.ParsePortNumbers(portID)
+	if err != nil {
+		return "", err
+	}
+	var nodeBlocks capmodel.AccessPolicyResponse
+	if err := QueryACIClass("uni/infra", capmodel.NodeBlockClass, nil, &nodeBlocks); err != nil {
+		return "", err
+	}
+	leafProfiles := make(map[string]bool)
+	for _, nodeBlock := range nodeBlocks.Objects(capmodel.NodeBlockClass) {
+		if nodeBlock.ContainsNode(nodeID) {
+			leafProfiles[capmodel.DNParent(nodeBlock.Attributes["dn"], "leaves-")] = true
+		}
+	}
+	if len(leafProfiles) == 0 {
+		return "", nil
+	}
+	var profileRelations capmodel.AccessPolicyResponse
+	if err := QueryACIClass("uni/infra", capmodel.InterfaceProfileRelationClass, nil, &profileRelations); err != nil {
+		return "", err
+	}
+	interfaceProfiles := make(map[string]bool)
+	for _, relation := range profileRelations.Objects(capmodel.InterfaceProfileRelationClass) {
+		if leafProfiles[capmodel.DNParent(relation.Attributes["dn"], "rsaccPortP-")] {
+			interfaceProfiles[relation.Attributes["tDn"]] = true
+		}
+	}
+	blockClass, blockPrefix := capmodel.PortBlockClass, "portblk-"
+	if port.SubPort != 0 {
+		blockClass, blockPrefix = capmodel.SubPortBlockClass, "subportblk-"
+	}
+	var portBlocks capmodel.AccessPolicyResponse
+	if err := QueryACIClass("uni/infra", blockClass, nil, &portBlocks); err != nil {
+		return "", err
+	}
+	for _, portBlock := range portBlocks.Objects(blockClass) {
+		dn := portBlock.Attributes["dn"]
+		if !interfaceProfiles[capmodel.DNParent(dn, "hports-")] || !portBlock.ContainsPort(port) {
+			continue
+		}
+		var groupRelation capmodel.AccessPolicyResponse
+		if err := QueryACIMO(capmodel.DNParent(dn, blockPrefix)+"/rsaccBaseGrp", nil, &groupRelation); err != nil {
+			return "", err
+		}
+		if relations := groupRelation.Objects(capmodel.PortSelectorGroupClass); len(relations) > 0 {
+			return relations[0].Attributes["tDn"], nil
+		}
+	}
+	return "", nil
 }
 
-// GetSwitchHealth queries the switch for it's Health from ACI
-func GetSwitchHealth(podID, ACISwitchID string) (*capmodel.Health, error) {
-	aciClient := client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
-	// Get the port data for given switch using the uri /api/node/mo/topology/{pod_id}/health.json
-	err := aciClient.Authenticate()
+// GetPolicyGroupRelations returns the relations of the access port policy group to its AEP and to its interface policies
+func GetPolicyGroupRelations(policyGroupDN string) ([]*capmodel.ACIRelation, error) {
+	var relations capmodel.AccessPolicyResponse
+	err := QueryACIMO(policyGroupDN, &APICQueryOptions{
+		QueryTarget:        QueryTargetSubtree,
+		TargetSubtreeClass: capmodel.PolicyGroupRelationClasses(),
+	}, &relations)
 	if err != nil {
 		return nil, err
 	}
-	endpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/node-%s/sys/health.json", config.Data.APICConf.APICHost, podID, ACISwitchID)
+	return relations.PolicyGroupRelations(policyGroupDN), nil
+}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+// GetMTUPolicy returns the MTU set by the l2InstPol of the name
+func GetMTUPolicy(name string) (int, error) {
+	dn := capmodel.MTUPolicyDN(name)
+	var policies capmodel.AccessPolicyResponse
+	if err := QueryACIMO(dn, nil, &policies); err != nil {
+		return 0, err
+	}
+	objects := policies.Objects(capmodel.MTUPolicyClass)
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("%w: no %s found with dn %s", capmodel.ErrorACIObjectNotFound, capmodel.MTUPolicyClass, dn)
+	}
+	mtu, err := strconv.Atoi(objects[0].Attributes["fabricMtu"])
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("%w: %s %s has non numeric fabricMtu attribute %q", capmodel.ErrorInvalidACIObject, capmodel.MTUPolicyClass, dn, objects[0].Attributes["fabricMtu"])
 	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
+	return mtu, nil
+}
+
+// GetInterfaceOverrideGroup returns the dn of the policy group the interface override of the port relates to,
+// the dn is empty when the port has no interface override or when the override doesn't change its policy group
+func GetInterfaceOverrideGroup(podID, ACISwitchID, portID string) (string, error) {
+	override := capmodel.NewInterfaceOverride(podID, ACISwitchID, portID)
+	var relation capmodel.AccessPolicyResponse
+	if err := QueryACIMO(override.GroupRelationDN(), nil, &relation); err != nil {
+		return "", err
 	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
-		return nil, err
+	if relations := relation.Objects(capmodel.InterfaceOverrideGroupClass); len(relations) > 0 {
+		return relations[0].Attributes["tDn"], nil
 	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	req.Close = true
+	return "", nil
+}
 
-	resp, err := newClient.httpClient.Do(req)
+// GetPortTransceiver collects the transceiver plugged in the given port along with its DOM readings
+// and the speeds supported by the port, the DOM readings are always read from APIC
+func GetPortTransceiver(podID, ACISwitchID, portID string, policy CachePolicy) (*capmodel.PortTransceiver, error) {
+	physDN := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]/phys", podID, ACISwitchID, portID)
+	object, err := readCachedACIMO(physDN+"/fcot", policy, func() (interface{}, error) {
+		var transceiverData capmodel.PortTransceiverResponse
+		err := QueryACIMO(physDN, &APICQueryOptions{
+			QueryTarget:        QueryTargetSubtree,
+			TargetSubtreeClass: []string{"ethpmFcot", "ethpmPortCap"},
+		}, &transceiverData)
+		if err != nil {
+			return nil, err
+		}
+		return parsePortTransceiver(transceiverData.IMData), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	transceiver := object.(capmodel.PortTransceiver)
+	if transceiver.Fcot == nil || !transceiver.Fcot.IsPresent() {
+		return &transceiver, nil
+	}
+	// the DOM readings change continuously and APIC doesn't notify their changes, so they aren't cached
+	var domData capmodel.PortTransceiverResponse
+	err = QueryACIMO(physDN, &APICQueryOptions{
+		QueryTarget: QueryTargetSubtree,
+		TargetSubtreeClass: []string{capmodel.DOMTemperatureSensorClass, capmodel.DOMVoltageSensorClass,
+			capmodel.DOMCurrentSensorClass, capmodel.DOMTxPowerSensorClass, capmodel.DOMRxPowerSensorClass},
+	}, &domData)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, fmt.Errorf(errMsg)
-	}
-
-	var switchHealthData capmodel.Health
-	json.Unmarshal(body, &switchHealthData)
-	return &switchHealthData, nil
+	transceiver.DOM = parsePortTransceiver(domData.IMData).DOM
+	return &transceiver, nil
+}
 
+func parsePortTransceiver(imdata []capmodel.PortTransceiverIMData) capmodel.PortTransceiver {
+	var transceiver capmodel.PortTransceiver
+	var dom capmodel.TransceiverDOM
+	domRead := false
+	reading := func(sensor *capmodel.DOMSensor) *float64 {
+		// the sensors are validated as numeric
+		value, _ := strconv.ParseFloat(sensor.Attributes.Value, 64)
+		domRead = true
+		return &value
+	}
+	for _, object := range imdata {
+		switch {
+		case object.Fcot != nil:
+			fcot := object.Fcot.Attributes
+			transceiver.Fcot = &fcot
+		case object.PortCap != nil:
+			for _, speed := range strings.Split(object.PortCap.Attributes.Speed, ",") {
+				if speed = strings.TrimSpace(speed); speed != "" && speed != "auto" {
+					transceiver.SupportedSpeeds = append(transceiver.SupportedSpeeds, speed)
+				}
+			}
+		case object.Temperature != nil:
+			dom.TemperatureCelsius = reading(object.Temperature)
+		case object.Voltage != nil:
+			dom.SupplyVoltage = reading(object.Voltage)
+		case object.Current != nil:
+			dom.TXBiasCurrentMilliAmps = reading(object.Current)
+		case object.TxPower != nil:
+			dom.TXOutputPowerDBm = reading(object.TxPower)
+		case object.RxPower != nil:
+			dom.RXInputPowerDBm = reading(object.RxPower)
+		}
+	}
+	if domRead {
+		transceiver.DOM = &dom
+	}
+	return transceiver
 }
 
-// GetPortInfo collects the dat for  given port
-func GetPortInfo(podID, ACISwitchID, portID string) (*capmodel.PortInfoResponse, error) {
-	aciClient := client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
-	// Get the port data for given switch using the uri /api/node/mo/topology/{pod_id}/health.json
-	err := aciClient.Authenticate()
+// GetPortStats reads the interface counters and the 5 minutes traffic stats of the given port
+func GetPortStats(podID, ACISwitchID, portID string, policy CachePolicy) (*capmodel.PortStats, error) {
+	portDN := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]", podID, ACISwitchID, portID)
+	object, err := readCachedACIMO(portDN+"/stats", policy, func() (interface{}, error) {
+		var statsData capmodel.PortStatsResponse
+		err := QueryACIMO(portDN, &APICQueryOptions{
+			QueryTarget:        QueryTargetSubtree,
+			TargetSubtreeClass: []string{"rmonEtherStats", "rmonIfIn", "rmonIfOut", "eqptIngrTotal5min", "eqptEgrTotal5min"},
+		}, &statsData)
+		if err != nil {
+			return nil, err
+		}
+		var stats capmodel.PortStats
+		for _, imdata := range statsData.IMData {
+			switch {
+			case imdata.EtherStats != nil:
+				stats.EtherStats = &imdata.EtherStats.Attributes
+			case imdata.IfIn != nil:
+				stats.IfIn = &imdata.IfIn.Attributes
+			case imdata.IfOut != nil:
+				stats.IfOut = &imdata.IfOut.Attributes
+			case imdata.Ingress != nil:
+				stats.Ingress = &imdata.Ingress.Attributes
+			case imdata.Egress != nil:
+				stats.Egress = &imdata.Egress.Attributes
+			}
+		}
+		return stats, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	endpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/node-%s/sys/phys-[%s]/phys.json", config.Data.APICConf.APICHost, podID, ACISwitchID, portID)
+	stats := object.(capmodel.PortStats)
+	return &stats, nil
+}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
+// GetAllPortStats reads the interface counters and the 5 minutes traffic stats of the physical interfaces
+// of all the switches, the stats are keyed by the dn of their interface
+func GetAllPortStats() (map[string]*capmodel.PortStats, error) {
+	allStats := make(map[string]*capmodel.PortStats)
+	portStats := func(dn string) *capmodel.PortStats {
+		physIfDN := capmodel.PhysIfDN(dn)
+		if physIfDN == "" {
+			return nil
+		}
+		if allStats[physIfDN] == nil {
+			allStats[physIfDN] = &capmodel.PortStats{}
+		}
+		return allStats[physIfDN]
+	}
+	for _, className := range []string{"rmonIfIn", "rmonIfOut", "rmonEtherStats", "eqptIngrTotal5min", "eqptEgrTotal5min"} {
+		var statsData capmodel.PortStatsResponse
+		if err := QueryACIClass("", className, nil, &statsData); err != nil {
+			return nil, err
+		}
+		for _, imdata := range statsData.IMData {
+			switch {
+			case imdata.IfIn != nil:
+				if stats := portStats(imdata.IfIn.Attributes.DN); stats != nil {
+					stats.IfIn = &imdata.IfIn.Attributes
+				}
+			case imdata.IfOut != nil:
+				if stats := portStats(imdata.IfOut.Attributes.DN); stats != nil {
+					stats.IfOut = &imdata.IfOut.Attributes
+				}
+			case imdata.EtherStats != nil:
+				if stats := portStats(imdata.EtherStats.Attributes.DN); stats != nil {
+					stats.EtherStats = &imdata.EtherStats.Attributes
+				}
+			case imdata.Ingress != nil:
+				if stats := portStats(imdata.Ingress.Attributes.DN); stats != nil {
+					stats.Ingress = &imdata.Ingress.Attributes
+				}
+			case imdata.Egress != nil:
+				if stats := portStats(imdata.Egress.Attributes.DN); stats != nil {
+					stats.Egress = &imdata.Egress.Attributes
+				}
+			}
+		}
+	}
+	return allStats, nil
+}
+
+// GetSwitchCPUUsages reads the CPU usage over the last 5 minutes of all the switches
+func GetSwitchCPUUsages() ([]capmodel.ProcSysCPUAttributes, error) {
+	var usageData capmodel.ProcSysResponse
+	if err := QueryACIClass("", "procSysCPU5min", nil, &usageData); err != nil {
 		return nil, err
 	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
-	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
-		return nil, err
+	usages := make([]capmodel.ProcSysCPUAttributes, 0, len(usageData.IMData))
+	for _, imdata := range usageData.IMData {
+		if imdata.CPU != nil {
+			usages = append(usages, imdata.CPU.Attributes)
+		}
 	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
-	})
-	req.Close = true
+	return usages, nil
+}
 
-	resp, err := newClient.httpClient.Do(req)
-	if err != nil {
+// GetSwitchMemoryUsages reads the memory usage over the last 5 minutes of all the switches
+func GetSwitchMemoryUsages() ([]capmodel.ProcSysMemAttributes, error) {
+	var usageData capmodel.ProcSysResponse
+	if err := QueryACIClass("", "procSysMem5min", nil, &usageData); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, fmt.Errorf(errMsg)
+	usages := make([]capmodel.ProcSysMemAttributes, 0, len(usageData.IMData))
+	for _, imdata := range usageData.IMData {
+		if imdata.Memory != nil {
+			usages = append(usages, imdata.Memory.Attributes)
+		}
 	}
-
-	var portResponseData capmodel.PortInfoResponse
-	json.Unmarshal(body, &portResponseData)
-	return &portResponseData, nil
-
+	return usages, nil
 }
 
 // GetPortHealth collects the Health  for  given port
-func GetPortHealth(podID, ACISwitchID, portID string) (*capmodel.Health, error) {
-	aciClient := client.NewClient("https://"+config.Data.APICConf.APICHost, config.Data.APICConf.UserName, client.Password(config.Data.APICConf.Password), client.Insecure(true))
-	// Get the port data for given switch using the uri /api/node/mo/topology/{pod_id}/health.json
-	err := aciClient.Authenticate()
-	if err != nil {
-		return nil, err
-	}
-	endpoint := fmt.Sprintf("https://%s/api/node/mo/topology/pod-%s/node-%s/sys/phys-[%s]/phys/health.json", config.Data.APICConf.APICHost, podID, ACISwitchID, portID)
+func GetPortHealth(podID, ACISwitchID, portID string, policy CachePolicy) (*capmodel.HealthData, error) {
+	dn := fmt.Sprintf("topology/pod-%s/node-%s/sys/phys-[%s]/phys/health", podID, ACISwitchID, portID)
+	return getCachedHealth(dn, policy, fmt.Sprintf("port %s of node-%s of pod-%s", portID, ACISwitchID, podID))
+}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	newClient := ACIHTTPClient{}
-	httpConf := &lutilconf.HTTPConfig{
-		CACertificate: &config.Data.KeyCertConf.RootCACertificate,
-	}
-	if newClient.httpClient, err = httpConf.GetHTTPClientObj(); err != nil {
-		return nil, err
-	}
-	req.Close = true
-	req.Header.Set("Accept", "application/json")
-	req.AddCookie(&http.Cookie{
-		Name:  "APIC-Cookie",
-		Value: aciClient.AuthToken.Token,
+// getCachedHealth reads the healthInst object with dn, entity describes the object in the errors
+func getCachedHealth(dn string, policy CachePolicy, entity string) (*capmodel.HealthData, error) {
+	object, err := readCachedACIMO(dn, policy, func() (interface{}, error) {
+		var healthData capmodel.Health
+		if err := QueryACIMO(dn, nil, &healthData); err != nil {
+			return nil, err
+		}
+		if len(healthData.IMData) == 0 {
+			return nil, fmt.Errorf("%w: no healthInst found for %s", capmodel.ErrorACIObjectNotFound, entity)
+		}
+		return healthData.IMData[0].HealthData, nil
 	})
-	req.Close = true
-
-	resp, err := newClient.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	health := object.(capmodel.HealthData)
+	return &health, nil
+}
+
+// GetFaults collects the active faults raised on the given object and its children
+func GetFaults(dn string) ([]capmodel.Fault, error) {
+	var faultResponseData capmodel.FaultResponse
+	err := QueryACIMO(dn, &APICQueryOptions{
+		QueryTarget:        QueryTargetSubtree,
+		TargetSubtreeClass: []string{"faultInst"},
+		Filter:             FilterAnd(FilterNe("faultInst.severity", "cleared"), FilterNe("faultInst.severity", "info")),
+	}, &faultResponseData)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 300 {
-		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
-		return nil, fmt.Errorf(errMsg)
+	faults := make([]capmodel.Fault, 0, len(faultResponseData.IMData))
+	for _, imdata := range faultResponseData.IMData {
+		faults = append(faults, imdata.Fault)
 	}
-
-	var portResponseData capmodel.Health
-	json.Unmarshal(body, &portResponseData)
-	return &portResponseData, nil
-
+	return faults, nil
 }
 
 // GetPortPolicyGroup collects all policy group for given fabric and  switch
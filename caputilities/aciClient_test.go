@@ -0,0 +1,74 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedAPICQueries(t *testing.T) {
+	config.SetUpMockConfig(t)
+	apic, err := StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	defer apic.Close()
+	apic.AddObject("fabricNode", map[string]interface{}{
+		"dn":     "topology/pod-1/node-101",
+		"id":     "101",
+		"role":   "leaf",
+		"serial": "SAL101",
+	})
+	apic.AddObject("fabricNode", map[string]interface{}{
+		"dn":     "topology/pod-1/node-201",
+		"id":     "201",
+		"role":   "spine",
+		"serial": "SAL201",
+	})
+	apic.AddObject("ethpmPhysIf", map[string]interface{}{
+		"dn":        "topology/pod-1/node-101/sys/phys-[eth1/1]/phys",
+		"operSt":    "up",
+		"operSpeed": "10G",
+	})
+	apic.AddObject("healthInst", map[string]interface{}{
+		"dn":  "topology/pod-1/node-101/sys/phys-[eth1/1]/phys/health",
+		"cur": "unknown",
+	})
+
+	nodes, err := GetFabricNodes()
+	assert.Nil(t, err, "fabricNode query should not fail")
+	if assert.Len(t, nodes, 2) {
+		assert.Equal(t, "leaf", nodes[0].Attributes.Role)
+		assert.Equal(t, "spine", nodes[1].Attributes.Role)
+	}
+
+	portInfo, err := GetPortInfo("1", "101", "eth1/1", UseCache)
+	assert.Nil(t, err, "ethpmPhysIf query should not fail")
+	if assert.NotNil(t, portInfo) {
+		assert.Equal(t, "up", portInfo.Attributes.OperSt)
+		assert.Equal(t, "10G", portInfo.Attributes.OperSpeed)
+	}
+
+	_, err = GetPortHealth("1", "101", "eth1/1", UseCache)
+	assert.True(t, errors.Is(err, capmodel.ErrorInvalidACIObject), "malformed healthInst should fail validation")
+
+	_, err = GetPortInfo("1", "101", "eth1/2", UseCache)
+	assert.True(t, errors.Is(err, capmodel.ErrorACIObjectNotFound), "missing ethpmPhysIf should be reported")
+}
@@ -0,0 +1,149 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+// CachePolicy tells whether the objects read from APIC can be served from the cache
+type CachePolicy int
+
+const (
+	// UseCache serves the object from the cache when present, the cache is filled on a miss
+	UseCache CachePolicy = iota
+	// BypassCache reads the object from APIC and refreshes the cache with it
+	BypassCache
+)
+
+// apicObjectCache keeps the frequently read APIC objects by their dn
+var apicObjectCache = &apicCache{
+	entries: make(map[string]apicCacheEntry),
+}
+
+// apicCache is a read-through cache whose entries expire after the configured TTL,
+// the entries are also invalidated as soon as APIC notifies a change of the object
+type apicCache struct {
+	mux     sync.Mutex
+	entries map[string]apicCacheEntry
+	// generation is incremented on every invalidation, so that a read which was
+	// in progress during an invalidation doesn't store an outdated object
+	generation uint64
+}
+
+type apicCacheEntry struct {
+	object    interface{}
+	expiresAt time.Time
+}
+
+func (c *apicCache) get(dn string) (interface{}, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	entry, ok := c.entries[dn]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.object, true
+}
+
+func (c *apicCache) set(dn string, object interface{}, generation uint64, ttl time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if generation != c.generation {
+		return
+	}
+	c.entries[dn] = apicCacheEntry{
+		object:    object,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+func (c *apicCache) currentGeneration() uint64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.generation
+}
+
+// invalidate drops the entries of the object with dn and of all the objects under it
+func (c *apicCache) invalidate(dn string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.generation++
+	for key := range c.entries {
+		if key == dn || strings.HasPrefix(key, dn+"/") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// ClearAPICCache drops all the cached APIC objects
+func ClearAPICCache() {
+	apicObjectCache.clear()
+}
+
+func (c *apicCache) clear() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.generation++
+	c.entries = make(map[string]apicCacheEntry)
+}
+
+// InvalidateAPICCache drops the cached APIC objects with the dn and all the objects under it
+func InvalidateAPICCache(dn string) {
+	apicObjectCache.invalidate(dn)
+}
+
+// readCachedACIMO returns the cached object with dn as per the policy,
+// read is used for getting the object from APIC when it is not cached
+func readCachedACIMO(dn string, policy CachePolicy, read func() (interface{}, error)) (interface{}, error) {
+	ttl := getAPICCacheTTL()
+	if ttl <= 0 {
+		return read()
+	}
+	if policy == UseCache {
+		if object, ok := apicObjectCache.get(dn); ok {
+			return object, nil
+		}
+	}
+	generation := apicObjectCache.currentGeneration()
+	object, err := read()
+	if err != nil {
+		return nil, err
+	}
+	apicObjectCache.set(dn, object, generation, ttl)
+	return object, nil
+}
+
+// APICCacheEnabled tells if the objects read from APIC are cached, they are when CacheEnabled is not set
+func APICCacheEnabled() bool {
+	return config.Data.APICConf == nil || config.Data.APICConf.CacheEnabled == nil || *config.Data.APICConf.CacheEnabled
+}
+
+// getAPICCacheTTL returns the time for which the APIC objects are cached, it is 0 when the cache is disabled
+func getAPICCacheTTL() time.Duration {
+	if !APICCacheEnabled() {
+		return 0
+	}
+	ttl := config.DefaultAPICCacheTTL
+	if config.Data.APICConf != nil && config.Data.APICConf.CacheTTLInSeconds > 0 {
+		ttl = config.Data.APICConf.CacheTTLInSeconds
+	}
+	return time.Duration(ttl) * time.Second
+}
@@ -0,0 +1,172 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// moCountingTransport counts the MO queries sent to APIC
+type moCountingTransport struct {
+	mux     sync.Mutex
+	queries int
+}
+
+func (c *moCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, "/api/node/mo/") {
+		c.mux.Lock()
+		c.queries++
+		c.mux.Unlock()
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	return transport.RoundTrip(req)
+}
+
+func (c *moCountingTransport) count() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.queries
+}
+
+func startCachedMockAPIC(t *testing.T) (*MockAPIC, *moCountingTransport) {
+	config.SetUpMockConfig(t)
+	apic, err := StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	t.Cleanup(apic.Close)
+	apic.AddObject("healthInst", map[string]interface{}{
+		"dn":  "topology/pod-1/node-101/sys/health",
+		"cur": "100",
+	})
+	apic.AddObject("ethpmPhysIf", map[string]interface{}{
+		"dn":        "topology/pod-1/node-101/sys/phys-[eth1/1]/phys",
+		"operSt":    "up",
+		"operSpeed": "10G",
+	})
+	transport := &moCountingTransport{}
+	APICTransport = transport
+	t.Cleanup(func() { APICTransport = nil })
+	return apic, transport
+}
+
+func TestAPICCache(t *testing.T) {
+	apic, transport := startCachedMockAPIC(t)
+
+	health, err := GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err, "switch health query should not fail")
+	assert.Equal(t, "100", health.Attributes.Cur)
+	health.Attributes.Cur = "0"
+	health, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err, "cached switch health should be returned")
+	assert.Equal(t, "100", health.Attributes.Cur, "cached object should not be modified by the callers")
+	assert.Equal(t, 1, transport.count(), "second read should be served from the cache")
+
+	apic.mux.Lock()
+	apic.objects["topology/pod-1/node-101/sys/health"].Attributes["cur"] = "60"
+	apic.mux.Unlock()
+	health, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err)
+	assert.Equal(t, "100", health.Attributes.Cur, "unnotified change is not seen before the TTL")
+	health, err = GetSwitchHealth("1", "101", BypassCache)
+	assert.Nil(t, err, "live switch health query should not fail")
+	assert.Equal(t, "60", health.Attributes.Cur, "BypassCache should read from APIC")
+	health, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err)
+	assert.Equal(t, "60", health.Attributes.Cur, "live read should refresh the cache")
+	assert.Equal(t, 2, transport.count())
+
+	// expire the entry
+	apicObjectCache.mux.Lock()
+	entry := apicObjectCache.entries["topology/pod-1/node-101/sys/health"]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	apicObjectCache.entries["topology/pod-1/node-101/sys/health"] = entry
+	apicObjectCache.mux.Unlock()
+	_, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, transport.count(), "expired entry should be read again")
+
+	cacheEnabled := false
+	config.Data.APICConf.CacheEnabled = &cacheEnabled
+	GetSwitchHealth("1", "101", UseCache)
+	GetSwitchHealth("1", "101", UseCache)
+	assert.Equal(t, 5, transport.count(), "CacheEnabled false should disable the cache")
+}
+
+func TestWatchAPICChanges(t *testing.T) {
+	apic, transport := startCachedMockAPIC(t)
+	stop := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		WatchAPICChanges(stop)
+		close(watcherDone)
+	}()
+	waitFor(t, "subscriptions", func() bool {
+		return len(apic.SubscribedClasses()) == len(cachedAPICClasses)
+	})
+	assert.Equal(t, []string{"ethpmFcot", "ethpmPhysIf", "fabricHealthTotal", "healthInst"}, apic.SubscribedClasses())
+
+	portInfo, err := GetPortInfo("1", "101", "eth1/1", UseCache)
+	assert.Nil(t, err, "port info query should not fail")
+	assert.Equal(t, "up", portInfo.Attributes.OperSt)
+	_, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err, "switch health query should not fail")
+	queries := transport.count()
+
+	apic.AddObject("ethpmPhysIf", map[string]interface{}{
+		"dn":     "topology/pod-1/node-101/sys/phys-[eth1/1]/phys",
+		"operSt": "down",
+	})
+	waitFor(t, "invalidation of the port", func() bool {
+		_, cached := apicObjectCache.get("topology/pod-1/node-101/sys/phys-[eth1/1]/phys")
+		return !cached
+	})
+	portInfo, err = GetPortInfo("1", "101", "eth1/1", UseCache)
+	assert.Nil(t, err, "port info query should not fail")
+	assert.Equal(t, "down", portInfo.Attributes.OperSt, "notified change should be read from APIC")
+	_, err = GetSwitchHealth("1", "101", UseCache)
+	assert.Nil(t, err)
+	assert.Equal(t, queries+1, transport.count(), "only the changed object should be read again")
+
+	// subscriptions are made again after the connection is lost
+	apic.CloseSockets()
+	waitFor(t, "resubscription", func() bool {
+		return len(apic.SubscribedClasses()) == len(cachedAPICClasses)
+	})
+	close(stop)
+	select {
+	case <-watcherDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher didn't stop")
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
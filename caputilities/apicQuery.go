@@ -0,0 +1,266 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+// defaultAPICPageSize is the number of objects fetched in a page by the class queries
+const defaultAPICPageSize = 1000
+
+// QueryTarget is the set of objects on which an APIC query is applied
+type QueryTarget string
+
+// ResponseSubtree is the set of child objects returned along with the objects of an APIC query
+type ResponseSubtree string
+
+// QueryFilter is the query-target-filter or rsp-subtree-filter expression of an APIC query
+type QueryFilter string
+
+const (
+	// QueryTargetSelf applies the query to the object itself
+	QueryTargetSelf QueryTarget = "self"
+	// QueryTargetChildren applies the query to the children of the object
+	QueryTargetChildren QueryTarget = "children"
+	// QueryTargetSubtree applies the query to the object and all of its children
+	QueryTargetSubtree QueryTarget = "subtree"
+
+	// ResponseSubtreeNo returns only the objects
+	ResponseSubtreeNo ResponseSubtree = "no"
+	// ResponseSubtreeChildren returns the objects with their children
+	ResponseSubtreeChildren ResponseSubtree = "children"
+	// ResponseSubtreeFull returns the objects with their complete subtree
+	ResponseSubtreeFull ResponseSubtree = "full"
+)
+
+// APICQueryOptions are the options of a class or MO query, zero values are left out of the query
+type APICQueryOptions struct {
+	QueryTarget        QueryTarget
+	TargetSubtreeClass []string
+	Filter             QueryFilter
+	RspSubtree         ResponseSubtree
+	RspSubtreeClass    []string
+	RspSubtreeFilter   QueryFilter
+	// OrderBy holds the properties in the form class.property|asc or class.property|desc
+	OrderBy []string
+	// PageSize when set makes the objects to be fetched page by page,
+	// class queries are paged with defaultAPICPageSize when it is not set
+	PageSize int
+}
+
+// FilterEq returns a filter matching the objects whose property is equal to value,
+// property is in the form class.property
+func FilterEq(property, value string) QueryFilter {
+	return QueryFilter(fmt.Sprintf("eq(%s,%q)", property, value))
+}
+
+// FilterNe returns a filter matching the objects whose property is not equal to value
+func FilterNe(property, value string) QueryFilter {
+	return QueryFilter(fmt.Sprintf("ne(%s,%q)", property, value))
+}
+
+// FilterWcard returns a filter matching the objects whose property contains the regular expression
+func FilterWcard(property, expression string) QueryFilter {
+	return QueryFilter(fmt.Sprintf("wcard(%s,%q)", property, expression))
+}
+
+// FilterAnd returns a filter matching the objects which are matched by all the filters
+func FilterAnd(filters ...QueryFilter) QueryFilter {
+	return joinFilters("and", filters)
+}
+
+// FilterOr returns a filter matching the objects which are matched by any of the filters
+func FilterOr(filters ...QueryFilter) QueryFilter {
+	return joinFilters("or", filters)
+}
+
+func joinFilters(operator string, filters []QueryFilter) QueryFilter {
+	expressions := make([]string, len(filters))
+	for i, filter := range filters {
+		expressions[i] = string(filter)
+	}
+	return QueryFilter(operator + "(" + strings.Join(expressions, ",") + ")")
+}
+
+func (o *APICQueryOptions) values() url.Values {
+	values := url.Values{}
+	if o == nil {
+		return values
+	}
+	if o.QueryTarget != "" {
+		values.Set("query-target", string(o.QueryTarget))
+	}
+	if len(o.TargetSubtreeClass) > 0 {
+		values.Set("target-subtree-class", strings.Join(o.TargetSubtreeClass, ","))
+	}
+	if o.Filter != "" {
+		values.Set("query-target-filter", string(o.Filter))
+	}
+	if o.RspSubtree != "" {
+		values.Set("rsp-subtree", string(o.RspSubtree))
+	}
+	if len(o.RspSubtreeClass) > 0 {
+		values.Set("rsp-subtree-class", strings.Join(o.RspSubtreeClass, ","))
+	}
+	if o.RspSubtreeFilter != "" {
+		values.Set("rsp-subtree-filter", string(o.RspSubtreeFilter))
+	}
+	if len(o.OrderBy) > 0 {
+		values.Set("order-by", strings.Join(o.OrderBy, ","))
+	}
+	return values
+}
+
+// APICResponse is implemented by the typed APIC responses which can validate their objects
+type APICResponse interface {
+	Validate() error
+}
+
+// QueryACIClass collects the objects of the class present under the scope dn into response,
+// objects from the whole fabric are collected when scope is empty
+func QueryACIClass(scope, className string, options *APICQueryOptions, response APICResponse) error {
+	path := "/api/node/class/" + className + ".json"
+	if scope != "" {
+		path = "/api/node/class/" + scope + "/" + className + ".json"
+	}
+	queryOptions := APICQueryOptions{}
+	if options != nil {
+		queryOptions = *options
+	}
+	if queryOptions.PageSize == 0 {
+		queryOptions.PageSize = defaultAPICPageSize
+	}
+	// the pages are consistent only when the objects are sorted
+	if len(queryOptions.OrderBy) == 0 {
+		queryOptions.OrderBy = []string{className + ".dn|asc"}
+	}
+	return queryAPIC(path, &queryOptions, response)
+}
+
+// QueryACIMO collects the object with the given dn into response
+func QueryACIMO(dn string, options *APICQueryOptions, response APICResponse) error {
+	return queryAPIC("/api/node/mo/"+dn+".json", options, response)
+}
+
+// apicPage is a page of the APIC query response with the objects kept undecoded
+type apicPage struct {
+	TotalCount string            `json:"totalCount"`
+	IMData     []json.RawMessage `json:"imdata"`
+}
+
+// queryAPIC makes the query on path, iterates over all the pages when paging is requested
+// and decodes all the collected objects into response
+func queryAPIC(path string, options *APICQueryOptions, response APICResponse) error {
+	aciClient := newACIClient()
+	if err := aciClient.Authenticate(); err != nil {
+		return err
+	}
+	httpClient, err := getAPICHTTPClient()
+	if err != nil {
+		return err
+	}
+	values := options.values()
+	pageSize := 0
+	if options != nil {
+		pageSize = options.PageSize
+	}
+	var imdata []json.RawMessage
+	for page := 0; ; page++ {
+		if pageSize > 0 {
+			values.Set("page", strconv.Itoa(page))
+			values.Set("page-size", strconv.Itoa(pageSize))
+		}
+		endpoint := fmt.Sprintf("https://%s%s", config.Data.APICConf.APICHost, path)
+		if len(values) > 0 {
+			endpoint += "?" + values.Encode()
+		}
+		body, err := getAPICPage(httpClient, endpoint, aciClient.AuthToken.Token)
+		if err != nil {
+			return err
+		}
+		var pageData apicPage
+		if err = json.Unmarshal(body, &pageData); err != nil {
+			return fmt.Errorf("while trying to unmarshal the response of %s, got: %v", endpoint, err)
+		}
+		imdata = append(imdata, pageData.IMData...)
+		if pageSize == 0 || len(pageData.IMData) < pageSize {
+			break
+		}
+		if totalCount, err := strconv.Atoi(pageData.TotalCount); err == nil && len(imdata) >= totalCount {
+			break
+		}
+	}
+	if imdata == nil {
+		imdata = []json.RawMessage{}
+	}
+	data, err := json.Marshal(apicPage{
+		TotalCount: strconv.Itoa(len(imdata)),
+		IMData:     imdata,
+	})
+	if err != nil {
+		return err
+	}
+	return decodeAPICResponse(path, data, response)
+}
+
+// getAPICPage makes a GET request on the endpoint and returns the response body
+func getAPICPage(httpClient *http.Client, endpoint, token string) ([]byte, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Close = true
+	req.Header.Set("Accept", "application/json")
+	req.AddCookie(&http.Cookie{
+		Name:  "APIC-Cookie",
+		Value: token,
+	})
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		errMsg := fmt.Sprintf("Get on the URL %s is giving response with status code %d with response body %s", endpoint, resp.StatusCode, string(body))
+		return nil, fmt.Errorf(errMsg)
+	}
+	return body, nil
+}
+
+// decodeAPICResponse unmarshals the response body of the APIC query made on endpoint and validates the objects in it
+func decodeAPICResponse(endpoint string, body []byte, data APICResponse) error {
+	if err := json.Unmarshal(body, data); err != nil {
+		return fmt.Errorf("while trying to unmarshal the response of %s, got: %v", endpoint, err)
+	}
+	if err := data.Validate(); err != nil {
+		return fmt.Errorf("while trying to validate the response of %s, got: %w", endpoint, err)
+	}
+	return nil
+}
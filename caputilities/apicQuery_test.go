@@ -0,0 +1,110 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTransport keeps the URLs of the class queries sent to APIC
+type countingTransport struct {
+	classQueries []string
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, "/api/node/class/") {
+		c.classQueries = append(c.classQueries, req.URL.RawQuery)
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	return transport.RoundTrip(req)
+}
+
+func TestQueryACIClassPaging(t *testing.T) {
+	config.SetUpMockConfig(t)
+	apic, err := StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	defer apic.Close()
+	for i := 1; i <= 5; i++ {
+		apic.AddObject("l1PhysIf", map[string]interface{}{
+			"dn":  fmt.Sprintf("topology/pod-1/node-101/sys/phys-[eth1/%d]", i),
+			"id":  fmt.Sprintf("eth1/%d", i),
+			"mtu": "9000",
+		})
+	}
+	transport := &countingTransport{}
+	APICTransport = transport
+	defer func() { APICTransport = nil }()
+
+	var ports capmodel.PortCollectionResponse
+	err = QueryACIClass("topology/pod-1/node-101", "l1PhysIf", &APICQueryOptions{
+		OrderBy:  []string{"l1PhysIf.id|desc"},
+		PageSize: 2,
+	}, &ports)
+	assert.Nil(t, err, "paged class query should not fail")
+	assert.Equal(t, "5", ports.TotalCount)
+	if assert.Len(t, ports.IMData, 5) {
+		assert.Equal(t, "eth1/5", ports.IMData[0].PhysicalInterface.Attributes.ID)
+		assert.Equal(t, "eth1/1", ports.IMData[4].PhysicalInterface.Attributes.ID)
+	}
+	assert.Equal(t, []string{
+		"order-by=l1PhysIf.id%7Cdesc&page=0&page-size=2",
+		"order-by=l1PhysIf.id%7Cdesc&page=1&page-size=2",
+		"order-by=l1PhysIf.id%7Cdesc&page=2&page-size=2",
+	}, transport.classQueries)
+
+	transport.classQueries = nil
+	var filteredPorts capmodel.PortCollectionResponse
+	err = QueryACIClass("", "l1PhysIf", &APICQueryOptions{
+		Filter: FilterOr(FilterEq("l1PhysIf.id", "eth1/2"), FilterWcard("l1PhysIf.id", "eth1/[45]")),
+	}, &filteredPorts)
+	assert.Nil(t, err, "filtered class query should not fail")
+	if assert.Len(t, filteredPorts.IMData, 3) {
+		assert.Equal(t, "eth1/2", filteredPorts.IMData[0].PhysicalInterface.Attributes.ID)
+		assert.Equal(t, "eth1/5", filteredPorts.IMData[2].PhysicalInterface.Attributes.ID)
+	}
+	assert.Len(t, transport.classQueries, 1, "all the filtered objects fit in the default page")
+}
+
+func TestAPICQueryOptions(t *testing.T) {
+	options := &APICQueryOptions{
+		QueryTarget:        QueryTargetSubtree,
+		TargetSubtreeClass: []string{"l1PhysIf", "ethpmPhysIf"},
+		Filter:             FilterAnd(FilterEq("l1PhysIf.adminSt", "up"), FilterNe("l1PhysIf.usage", "fabric")),
+		RspSubtree:         ResponseSubtreeChildren,
+		RspSubtreeClass:    []string{"ethpmPhysIf"},
+		OrderBy:            []string{"l1PhysIf.id|asc"},
+	}
+	values := options.values()
+	assert.Equal(t, "subtree", values.Get("query-target"))
+	assert.Equal(t, "l1PhysIf,ethpmPhysIf", values.Get("target-subtree-class"))
+	assert.Equal(t, `and(eq(l1PhysIf.adminSt,"up"),ne(l1PhysIf.usage,"fabric"))`, values.Get("query-target-filter"))
+	assert.Equal(t, "children", values.Get("rsp-subtree"))
+	assert.Equal(t, "ethpmPhysIf", values.Get("rsp-subtree-class"))
+	assert.Equal(t, "l1PhysIf.id|asc", values.Get("order-by"))
+	assert.Empty(t, values.Get("page-size"), "paging is applied by the query")
+
+	var nilOptions *APICQueryOptions
+	assert.Empty(t, nilOptions.values())
+}
@@ -0,0 +1,233 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces the values of sensitive attributes in the recorded APIC exchanges
+const redactedValue = "REDACTED"
+
+// sensitiveAPICAttributes are the attributes of APIC payloads which are never written to disk
+var sensitiveAPICAttributes = map[string]bool{
+	"pwd":       true,
+	"token":     true,
+	"sessionId": true,
+	"urlToken":  true,
+}
+
+var fixtureNameReplacer = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// APICExchange is a sanitized request made to APIC along with its response
+type APICExchange struct {
+	Method       string          `json:"Method"`
+	URL          string          `json:"URL"`
+	RequestBody  json.RawMessage `json:"RequestBody,omitempty"`
+	StatusCode   int             `json:"StatusCode"`
+	ResponseBody json.RawMessage `json:"ResponseBody,omitempty"`
+	ResponseText string          `json:"ResponseText,omitempty"`
+}
+
+// RecordingTransport sends the requests using Transport and stores the sanitized
+// request and response pairs as APICExchange files under Path
+type RecordingTransport struct {
+	Transport http.RoundTripper
+	Path      string
+	mux       sync.Mutex
+}
+
+// RoundTrip sends the request to APIC and records the exchange, failure to record is only logged
+func (r *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	exchange := APICExchange{
+		Method:     req.Method,
+		URL:        req.URL.RequestURI(),
+		StatusCode: resp.StatusCode,
+	}
+	if len(requestBody) > 0 {
+		exchange.RequestBody = sanitizeAPICPayload(requestBody)
+	}
+	if json.Valid(responseBody) {
+		exchange.ResponseBody = sanitizeAPICPayload(responseBody)
+	} else {
+		exchange.ResponseText = string(responseBody)
+	}
+	if err := r.save(&exchange); err != nil {
+		log.Error("failed to record APIC request " + req.Method + " " + exchange.URL + ": " + err.Error())
+	}
+	return resp, nil
+}
+
+func (r *RecordingTransport) save(exchange *APICExchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if err := os.MkdirAll(r.Path, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.Path, exchange.fileName()), append(data, '\n'), 0600)
+}
+
+// key identifies the exchange, requests with a body are told apart by the hash of the sanitized body
+func (e *APICExchange) key() string {
+	if len(e.RequestBody) == 0 {
+		return e.Method + " " + e.URL
+	}
+	var body bytes.Buffer
+	json.Compact(&body, e.RequestBody)
+	hash := fnv.New32a()
+	hash.Write(body.Bytes())
+	return fmt.Sprintf("%s %s %08x", e.Method, e.URL, hash.Sum32())
+}
+
+func (e *APICExchange) fileName() string {
+	return fixtureNameReplacer.ReplaceAllString(strings.Replace(e.key(), ".json", "", 1), "_") + ".json"
+}
+
+// ReplayTransport answers the requests with the APICExchanges recorded by RecordingTransport
+type ReplayTransport struct {
+	exchanges map[string]APICExchange
+}
+
+// NewReplayTransport loads all the APICExchange files present under the given path
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded APIC exchanges found in %s", path)
+	}
+	r := &ReplayTransport{
+		exchanges: make(map[string]APICExchange),
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var exchange APICExchange
+		if err := json.Unmarshal(data, &exchange); err != nil {
+			return nil, fmt.Errorf("while trying to unmarshal recorded APIC exchange %s, got: %v", file, err)
+		}
+		r.exchanges[exchange.key()] = exchange
+	}
+	return r, nil
+}
+
+// RoundTrip returns the recorded response for the request
+func (r *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := APICExchange{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			request.RequestBody = sanitizeAPICPayload(body)
+		}
+	}
+	exchange, ok := r.exchanges[request.key()]
+	if !ok {
+		return nil, fmt.Errorf("no recorded APIC response found for %s", request.key())
+	}
+	body := []byte(exchange.ResponseText)
+	if len(exchange.ResponseBody) > 0 {
+		body = exchange.ResponseBody
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// sanitizeAPICPayload masks the credentials and tokens present in the APIC payload
+func sanitizeAPICPayload(payload []byte) json.RawMessage {
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil
+	}
+	sanitized, err := json.Marshal(redactAPICAttributes(data))
+	if err != nil {
+		return nil
+	}
+	return sanitized
+}
+
+func redactAPICAttributes(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if sensitiveAPICAttributes[key] {
+				value[key] = redactedValue
+				continue
+			}
+			value[key] = redactAPICAttributes(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactAPICAttributes(item)
+		}
+	}
+	return data
+}
@@ -0,0 +1,103 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplayAPICExchanges(t *testing.T) {
+	config.SetUpMockConfig(t)
+	apic, err := StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	apic.AddObject("fabricNodeIdentP", map[string]interface{}{
+		"dn":       "uni/controller/nodeidentpol/nodep-SAL101",
+		"nodeId":   "101",
+		"serial":   "SAL101",
+		"name":     "leaf-101",
+		"podId":    "1",
+		"fabricId": "1",
+	})
+	apic.AddObject("l1PhysIf", map[string]interface{}{
+		"dn":  "topology/pod-1/node-101/sys/phys-[eth1/1]",
+		"id":  "eth1/1",
+		"mtu": "9000",
+	})
+	recordPath := t.TempDir()
+	config.Data.APICConf.RecordPath = recordPath
+
+	nodes, err := GetFabricNodeData()
+	assert.Nil(t, err, "recording fabric nodes should not fail")
+	ports, err := GetPortData("1", "101")
+	assert.Nil(t, err, "recording ports should not fail")
+	apic.Close()
+
+	files, _ := filepath.Glob(filepath.Join(recordPath, "*.json"))
+	assert.NotEmpty(t, files, "APIC exchanges should be recorded")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		assert.NotContains(t, string(data), mockAPICToken, "token should not be recorded in "+file)
+		assert.NotContains(t, string(data), `"password"`, "password should not be recorded in "+file)
+		if strings.Contains(file, "aaaLogin") {
+			assert.Contains(t, string(data), redactedValue, "credentials should be redacted in "+file)
+		}
+	}
+
+	replay, err := NewReplayTransport(recordPath)
+	if err != nil {
+		t.Fatalf("failed to load recorded APIC exchanges: %v", err)
+	}
+	APICTransport = replay
+	defer func() { APICTransport = nil }()
+	config.Data.APICConf.RecordPath = ""
+
+	replayedNodes, err := GetFabricNodeData()
+	assert.Nil(t, err, "replaying fabric nodes should not fail")
+	if assert.Len(t, replayedNodes, len(nodes)) {
+		assert.Equal(t, nodes[0].NodeId, replayedNodes[0].NodeId)
+		assert.Equal(t, nodes[0].Serial, replayedNodes[0].Serial)
+	}
+	replayedPorts, err := GetPortData("1", "101")
+	assert.Nil(t, err, "replaying ports should not fail")
+	assert.Equal(t, ports, replayedPorts)
+
+	_, err = GetPortData("1", "102")
+	assert.NotNil(t, err, "request which was not recorded should fail")
+}
+
+func TestReplayTransportWithoutRecordings(t *testing.T) {
+	_, err := NewReplayTransport(t.TempDir())
+	assert.NotNil(t, err, "loading an empty directory should fail")
+
+	replay, err := NewReplayTransport(filepath.Join("testdata", "apic", "5.2.1g"))
+	if err != nil {
+		t.Fatalf("failed to load recorded APIC exchanges: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://apic.example.com/api/node/class/fvTenant.json", nil)
+	_, err = replay.RoundTrip(req)
+	assert.NotNil(t, err, "request which was not recorded should fail")
+}
@@ -0,0 +1,305 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxAPICRetryAfter is the upper limit of the wait requested by APIC in the Retry-After header
+const maxAPICRetryAfter = time.Minute
+
+// ErrorAPICUnavailable is for identifing the APIC calls which failed as APIC is unreachable or overloaded
+var ErrorAPICUnavailable = errors.New("APIC is temporarily unavailable")
+
+// apicCircuitBreaker is shared by all the APIC calls of the plugin
+var apicCircuitBreaker = &circuitBreaker{}
+
+// circuitBreaker refuses the calls for a while once the consecutive failures reach the threshold,
+// after that a single trial call is let through which closes the breaker on success
+type circuitBreaker struct {
+	mux                 sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	trialInProgress     bool
+}
+
+// allow tells whether a call can be made, when it can't the time after which calls are allowed is returned
+func (c *circuitBreaker) allow(retryConf *config.APICRetryConf) (bool, time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.consecutiveFailures < retryConf.BreakerFailureThreshold {
+		return true, 0
+	}
+	if wait := time.Until(c.openUntil); wait > 0 {
+		return false, wait
+	}
+	if c.trialInProgress {
+		return false, time.Duration(retryConf.BreakerOpenTimeInSeconds) * time.Second
+	}
+	c.trialInProgress = true
+	return true, 0
+}
+
+func (c *circuitBreaker) recordSuccess(retryConf *config.APICRetryConf) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.consecutiveFailures >= retryConf.BreakerFailureThreshold {
+		log.Info("APIC is reachable again, closing the circuit breaker")
+	}
+	c.consecutiveFailures = 0
+	c.trialInProgress = false
+}
+
+func (c *circuitBreaker) recordFailure(retryConf *config.APICRetryConf) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.consecutiveFailures++
+	c.trialInProgress = false
+	if c.consecutiveFailures >= retryConf.BreakerFailureThreshold {
+		if time.Now().After(c.openUntil) {
+			log.Warn(fmt.Sprintf("APIC failed %d consecutive times, opening the circuit breaker for %d seconds", c.consecutiveFailures, retryConf.BreakerOpenTimeInSeconds))
+		}
+		c.openUntil = time.Now().Add(time.Duration(retryConf.BreakerOpenTimeInSeconds) * time.Second)
+	}
+}
+
+// reset closes the circuit breaker
+func (c *circuitBreaker) reset() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.consecutiveFailures = 0
+	c.openUntil = time.Time{}
+	c.trialInProgress = false
+}
+
+// IsAPICCircuitBreakerOpen tells whether the APIC calls are being refused as APIC is down,
+// the time after which APIC calls are tried again is returned along with it
+func IsAPICCircuitBreakerOpen() (bool, time.Duration) {
+	apicCircuitBreaker.mux.Lock()
+	defer apicCircuitBreaker.mux.Unlock()
+	if apicCircuitBreaker.consecutiveFailures < getAPICRetryConf().BreakerFailureThreshold {
+		return false, 0
+	}
+	wait := time.Until(apicCircuitBreaker.openUntil)
+	return wait > 0, wait
+}
+
+func getAPICRetryConf() *config.APICRetryConf {
+	if config.Data.APICConf == nil || config.Data.APICConf.RetryConf == nil {
+		return config.NewAPICRetryConf()
+	}
+	return config.Data.APICConf.RetryConf
+}
+
+// RetryTransport sends the requests to APIC using Transport, the requests which are safe to repeat are retried
+// with exponential backoff when APIC is unreachable, overloaded or throttling the requests
+type RetryTransport struct {
+	Transport http.RoundTripper
+}
+
+// RoundTrip sends the request to APIC and retries it on transient failures
+func (r *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryConf := getAPICRetryConf()
+	if allowed, wait := apicCircuitBreaker.allow(retryConf); !allowed {
+		return nil, &APICUnavailableError{
+			RetryAfter: wait,
+			Reason:     "circuit breaker is open after consecutive failures",
+		}
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	idempotent := isIdempotentAPICRequest(req, body)
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if req.Body != nil {
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := r.Transport.RoundTrip(attemptReq)
+		if err != nil && req.Context().Err() != nil {
+			return nil, err
+		}
+		throttled := err == nil && resp.StatusCode == http.StatusTooManyRequests
+		if err == nil && !throttled && !isTransientAPICStatus(resp.StatusCode) {
+			apicCircuitBreaker.recordSuccess(retryConf)
+			return resp, nil
+		}
+		// throttled requests are refused by APIC before processing, so they are safe to repeat
+		if !throttled {
+			apicCircuitBreaker.recordFailure(retryConf)
+		}
+		reason := describeAPICFailure(resp, err)
+		canRetry := (idempotent || throttled) && attempt < retryConf.MaxRetries
+		if canRetry {
+			if allowed, _ := apicCircuitBreaker.allow(retryConf); !allowed {
+				canRetry = false
+			}
+		}
+		if !canRetry {
+			retryAfter := time.Duration(retryConf.BreakerOpenTimeInSeconds) * time.Second
+			if resp != nil {
+				if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					retryAfter = wait
+				}
+				resp.Body.Close()
+			}
+			return nil, &APICUnavailableError{
+				RetryAfter: retryAfter,
+				Reason:     fmt.Sprintf("%s %s failed after %d attempts: %s", req.Method, req.URL.Path, attempt+1, reason),
+			}
+		}
+		delay := backoffDelay(retryConf, attempt)
+		if resp != nil {
+			if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && wait > delay {
+				delay = wait
+			}
+			resp.Body.Close()
+		}
+		log.Warn(fmt.Sprintf("%s %s failed with %s, retrying in %v", req.Method, req.URL.Path, reason, delay))
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// APICUnavailableError is returned when APIC couldn't serve the request, it wraps ErrorAPICUnavailable
+type APICUnavailableError struct {
+	RetryAfter time.Duration
+	Reason     string
+}
+
+func (e *APICUnavailableError) Error() string {
+	return ErrorAPICUnavailable.Error() + ": " + e.Reason
+}
+
+// Unwrap makes errors.Is to match the error with ErrorAPICUnavailable
+func (e *APICUnavailableError) Unwrap() error {
+	return ErrorAPICUnavailable
+}
+
+// isTransientAPICStatus tells whether the status code is returned when APIC or its proxy is temporarily unable to serve
+func isTransientAPICStatus(statusCode int) bool {
+	return statusCode == http.StatusBadGateway || statusCode == http.StatusServiceUnavailable || statusCode == http.StatusGatewayTimeout
+}
+
+// isIdempotentAPICRequest tells whether the request can be repeated without changing the outcome,
+// POST on APIC creates or modifies the objects so it's safe unless an object is posted with status created,
+// which fails when the object already exists
+func isIdempotentAPICRequest(req *http.Request, body []byte) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodPut:
+		return true
+	case http.MethodPost:
+		if strings.HasPrefix(req.URL.Path, "/api/aaa") || len(body) == 0 {
+			return true
+		}
+		var payload interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return false
+		}
+		return !hasCreateOnlyStatus(payload)
+	}
+	return false
+}
+
+func hasCreateOnlyStatus(data interface{}) bool {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if status, ok := item.(string); ok && key == "status" && strings.TrimSpace(status) == "created" {
+				return true
+			}
+			if hasCreateOnlyStatus(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if hasCreateOnlyStatus(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backoffDelay returns the exponentially growing delay for the attempt with a random jitter of up to half of it
+func backoffDelay(retryConf *config.APICRetryConf, attempt int) time.Duration {
+	delay := time.Duration(retryConf.BaseDelayInMilliSeconds) * time.Millisecond
+	maxDelay := time.Duration(retryConf.MaxDelayInMilliSeconds) * time.Millisecond
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter reads the Retry-After header which is either in seconds or a HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxAPICRetryAfter {
+		wait = maxAPICRetryAfter
+	}
+	return wait, true
+}
+
+func describeAPICFailure(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "status code " + strconv.Itoa(resp.StatusCode)
+}
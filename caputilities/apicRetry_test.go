@@ -0,0 +1,150 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyTransport answers the requests with the scripted status codes, once they run out 200 is returned
+type flakyTransport struct {
+	statusCodes []int
+	header      http.Header
+	requests    []string
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		data, _ := ioutil.ReadAll(req.Body)
+		body = string(data)
+	}
+	f.requests = append(f.requests, req.Method+" "+body)
+	statusCode := http.StatusOK
+	if len(f.statusCodes) > 0 {
+		statusCode, f.statusCodes = f.statusCodes[0], f.statusCodes[1:]
+	}
+	header := http.Header{}
+	if statusCode != http.StatusOK {
+		header = f.header
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"imdata":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+func setUpRetryConf(t *testing.T) {
+	config.SetUpMockConfig(t)
+	config.Data.APICConf.RetryConf = &config.APICRetryConf{
+		MaxRetries:               2,
+		BaseDelayInMilliSeconds:  1,
+		MaxDelayInMilliSeconds:   5,
+		BreakerFailureThreshold:  3,
+		BreakerOpenTimeInSeconds: 30,
+	}
+	apicCircuitBreaker.reset()
+	t.Cleanup(apicCircuitBreaker.reset)
+}
+
+func sendAPICRequest(transport http.RoundTripper, method, body string) (*http.Response, error) {
+	req, _ := http.NewRequest(method, "https://apic/api/node/mo/uni/tn-test.json", strings.NewReader(body))
+	return transport.RoundTrip(req)
+}
+
+func TestRetryTransport(t *testing.T) {
+	setUpRetryConf(t)
+
+	flaky := &flakyTransport{statusCodes: []int{http.StatusServiceUnavailable, http.StatusBadGateway}}
+	resp, err := sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodGet, "")
+	assert.Nil(t, err, "GET should succeed after retrying")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, flaky.requests, 3)
+
+	flaky = &flakyTransport{statusCodes: []int{http.StatusServiceUnavailable}}
+	resp, err = sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodPost, `{"fvTenant":{"attributes":{"name":"test"}}}`)
+	assert.Nil(t, err, "POST without status created should be retried")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{
+		`POST {"fvTenant":{"attributes":{"name":"test"}}}`,
+		`POST {"fvTenant":{"attributes":{"name":"test"}}}`,
+	}, flaky.requests, "the request body should be sent again")
+
+	flaky = &flakyTransport{statusCodes: []int{http.StatusGatewayTimeout}}
+	_, err = sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodPost, `{"fvTenant":{"attributes":{"name":"test","status":"created"}}}`)
+	assert.True(t, errors.Is(err, ErrorAPICUnavailable), "create only POST should fail with ErrorAPICUnavailable")
+	assert.Len(t, flaky.requests, 1, "create only POST should not be retried")
+
+	flaky = &flakyTransport{
+		statusCodes: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		header:      http.Header{"Retry-After": []string{"7"}},
+	}
+	_, err = sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodPost, `{"fvTenant":{"attributes":{"status":"created"}}}`)
+	var unavailableErr *APICUnavailableError
+	if assert.True(t, errors.As(err, &unavailableErr), "throttled request should fail with APICUnavailableError") {
+		assert.Equal(t, 7*time.Second, unavailableErr.RetryAfter, "Retry-After of APIC should be passed on")
+	}
+	assert.Len(t, flaky.requests, 3, "throttled requests are retried even when they create objects")
+	open, _ := IsAPICCircuitBreakerOpen()
+	assert.False(t, open, "throttling should not open the circuit breaker")
+}
+
+func TestAPICCircuitBreaker(t *testing.T) {
+	setUpRetryConf(t)
+
+	flaky := &flakyTransport{statusCodes: []int{
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+	}}
+	_, err := sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodGet, "")
+	assert.True(t, errors.Is(err, ErrorAPICUnavailable), "GET should fail once retries are exhausted")
+	open, retryAfter := IsAPICCircuitBreakerOpen()
+	assert.True(t, open, "circuit breaker should open after the threshold")
+	assert.True(t, retryAfter > 0 && retryAfter <= 30*time.Second, "retry after should be within the open time")
+
+	_, err = sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodGet, "")
+	assert.True(t, errors.Is(err, ErrorAPICUnavailable), "GET should fail fast while the breaker is open")
+	assert.Len(t, flaky.requests, 3, "no request should reach APIC while the breaker is open")
+
+	// once the open time elapses a trial request is let through which closes the breaker
+	apicCircuitBreaker.mux.Lock()
+	apicCircuitBreaker.openUntil = time.Now().Add(-time.Second)
+	apicCircuitBreaker.mux.Unlock()
+	resp, err := sendAPICRequest(&RetryTransport{Transport: flaky}, http.MethodGet, "")
+	assert.Nil(t, err, "trial request should succeed")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	open, _ = IsAPICCircuitBreakerOpen()
+	assert.False(t, open, "circuit breaker should close after a successful trial")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("5")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, wait)
+	wait, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, maxAPICRetryAfter, wait, "Retry-After should be capped")
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
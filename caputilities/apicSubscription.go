@@ -0,0 +1,221 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// apicSubscriptionRefreshInterval is the interval at which the subscriptions and the session are refreshed,
+// APIC drops the subscriptions which are not refreshed within a minute
+var apicSubscriptionRefreshInterval = 30 * time.Second
+
+// cachedAPICClasses are the classes of the cached APIC objects whose changes are watched
+var cachedAPICClasses = []string{"fabricHealthTotal", "healthInst", "ethpmPhysIf", "ethpmFcot"}
+
+// apicEvent is the notification sent by APIC on the websocket when a subscribed object changes
+type apicEvent struct {
+	SubscriptionID []string                     `json:"subscriptionId"`
+	IMData         []map[string]apicEventObject `json:"imdata"`
+}
+
+type apicEventObject struct {
+	Attributes struct {
+		DN     string `json:"dn"`
+		Status string `json:"status"`
+	} `json:"attributes"`
+}
+
+// apicSubscriptionResponse is the response of a query made with subscription
+type apicSubscriptionResponse struct {
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// apicRefreshResponse is the response of the session refresh
+type apicRefreshResponse struct {
+	IMData []struct {
+		AAALogin struct {
+			Attributes struct {
+				Token string `json:"token"`
+			} `json:"attributes"`
+		} `json:"aaaLogin"`
+	} `json:"imdata"`
+}
+
+// WatchAPICChanges subscribes to the changes of the cached APIC objects and invalidates their cache entries,
+// the subscriptions are made again whenever the connection to APIC is lost, it returns once stop is closed
+func WatchAPICChanges(stop <-chan struct{}) {
+	WatchAPICClasses(stop, cachedAPICClasses, apicObjectCache.clear, apicObjectCache.invalidate)
+}
+
+// WatchAPICClasses subscribes to the changes of the APIC objects of the classes until stop is closed,
+// changed is called with the dn of each object whose change is notified and resync is called whenever
+// the changes may have been missed, that is once subscribed and once the subscriptions are lost.
+// The subscriptions are made again whenever the connection to APIC is lost
+func WatchAPICClasses(stop <-chan struct{}, classNames []string, resync func(), changed func(dn string)) {
+	for attempt := 0; ; attempt++ {
+		subscribedAt := time.Now()
+		err := watchAPICClasses(stop, classNames, resync, changed)
+		// the changes made while not subscribed are unknown
+		resync()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if time.Since(subscribedAt) > apicSubscriptionRefreshInterval {
+			attempt = 0
+		}
+		delay := backoffDelay(getAPICRetryConf(), attempt)
+		log.Warn(fmt.Sprintf("lost the subscriptions of APIC changes: %v, subscribing again in %v", err, delay))
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// watchAPICClasses subscribes to the APIC classes on a new websocket
+// and passes the objects notified on it to changed until the connection fails or stop is closed
+func watchAPICClasses(stop <-chan struct{}, classNames []string, resync func(), changed func(dn string)) error {
+	aciClient := newACIClient()
+	if err := aciClient.Authenticate(); err != nil {
+		return err
+	}
+	token := aciClient.AuthToken.Token
+	tlsConfig, err := getAPICTLSConfig()
+	if err != nil {
+		return err
+	}
+	dialer := websocket.Dialer{
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: 30 * time.Second,
+	}
+	conn, _, err := dialer.Dial(fmt.Sprintf("wss://%s/socket%s", config.Data.APICConf.APICHost, token), nil)
+	if err != nil {
+		return fmt.Errorf("unable to open the APIC websocket: %v", err)
+	}
+	defer conn.Close()
+
+	httpClient, err := getAPICHTTPClient()
+	if err != nil {
+		return err
+	}
+	var subscriptionIDs []string
+	for _, className := range classNames {
+		subscriptionID, err := subscribeAPICClass(httpClient, className, token)
+		if err != nil {
+			return err
+		}
+		subscriptionIDs = append(subscriptionIDs, subscriptionID)
+	}
+	// the objects may have changed before subscribing
+	resync()
+	log.Info(fmt.Sprintf("subscribed to the changes of the APIC objects of %v", classNames))
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readAPICEvents(conn, resync, changed)
+	}()
+	ticker := time.NewTicker(apicSubscriptionRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-readErr:
+			return err
+		case <-ticker.C:
+			if token, err = refreshAPICSubscriptions(httpClient, token, subscriptionIDs); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribeAPICClass makes a subscription for the changes of the objects of the class and returns its id
+func subscribeAPICClass(httpClient *http.Client, className, token string) (string, error) {
+	values := url.Values{}
+	values.Set("subscription", "yes")
+	values.Set("rsp-prop-include", "naming-only")
+	endpoint := fmt.Sprintf("https://%s/api/node/class/%s.json?%s", config.Data.APICConf.APICHost, className, values.Encode())
+	body, err := getAPICPage(httpClient, endpoint, token)
+	if err != nil {
+		return "", err
+	}
+	var subscription apicSubscriptionResponse
+	if err := json.Unmarshal(body, &subscription); err != nil {
+		return "", fmt.Errorf("while trying to unmarshal the subscription response of %s, got: %v", className, err)
+	}
+	if subscription.SubscriptionID == "" {
+		return "", fmt.Errorf("APIC didn't return the subscription id for %s", className)
+	}
+	return subscription.SubscriptionID, nil
+}
+
+// refreshAPICSubscriptions keeps the session and the subscriptions alive, the refreshed token is returned
+func refreshAPICSubscriptions(httpClient *http.Client, token string, subscriptionIDs []string) (string, error) {
+	body, err := getAPICPage(httpClient, fmt.Sprintf("https://%s/api/aaaRefresh.json", config.Data.APICConf.APICHost), token)
+	if err != nil {
+		return token, err
+	}
+	var refresh apicRefreshResponse
+	if err := json.Unmarshal(body, &refresh); err != nil {
+		return token, fmt.Errorf("while trying to unmarshal the session refresh response, got: %v", err)
+	}
+	if len(refresh.IMData) > 0 && refresh.IMData[0].AAALogin.Attributes.Token != "" {
+		token = refresh.IMData[0].AAALogin.Attributes.Token
+	}
+	for _, subscriptionID := range subscriptionIDs {
+		endpoint := fmt.Sprintf("https://%s/api/subscriptionRefresh.json?id=%s", config.Data.APICConf.APICHost, url.QueryEscape(subscriptionID))
+		if _, err := getAPICPage(httpClient, endpoint, token); err != nil {
+			return token, err
+		}
+	}
+	return token, nil
+}
+
+// readAPICEvents passes the objects notified on the websocket to changed until the read fails
+func readAPICEvents(conn *websocket.Conn, resync func(), changed func(dn string)) error {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var event apicEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			log.Warn("unable to decode the APIC event, the changes of all the objects are assumed: " + err.Error())
+			resync()
+			continue
+		}
+		for _, imdata := range event.IMData {
+			for _, object := range imdata {
+				if object.Attributes.DN != "" {
+					changed(object.Attributes.DN)
+				}
+			}
+		}
+	}
+}
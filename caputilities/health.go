@@ -0,0 +1,190 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// HealthResourceType identifies the resources having their own health thresholds in HealthConf
+type HealthResourceType string
+
+const (
+	// HealthResourceFabric is the resource type of fabrics
+	HealthResourceFabric HealthResourceType = "Fabric"
+	// HealthResourceSwitch is the resource type of switches
+	HealthResourceSwitch HealthResourceType = "Switch"
+	// HealthResourceChassis is the resource type of switch chassis
+	HealthResourceChassis HealthResourceType = "Chassis"
+	// HealthResourcePort is the resource type of switch ports
+	HealthResourcePort HealthResourceType = "Port"
+)
+
+// Redfish health values ordered from best to worst
+const (
+	healthOK       = "OK"
+	healthWarning  = "Warning"
+	healthCritical = "Critical"
+)
+
+var healthRank = map[string]int{
+	healthOK:       0,
+	healthWarning:  1,
+	healthCritical: 2,
+}
+
+// faultSeverityHealth maps the severity of the ACI faults to Redfish health,
+// faults of other severities like info and cleared don't affect the health
+var faultSeverityHealth = map[string]string{
+	"critical": healthCritical,
+	"major":    healthCritical,
+	"minor":    healthWarning,
+	"warning":  healthWarning,
+}
+
+// EvaluateHealth maps the ACI health score to Redfish health using the thresholds of the resource type,
+// when the health is not OK the faults raised under dn are reported as conditions and included in HealthRollup
+func EvaluateHealth(resourceType HealthResourceType, health *capmodel.HealthAttributes, dn string) (*capmodel.Status, error) {
+	score, err := strconv.Atoi(health.Cur)
+	if err != nil {
+		return nil, fmt.Errorf("invalid health score %s of %s: %v", health.Cur, dn, err)
+	}
+	status := &capmodel.Status{
+		Health: healthFromScore(score, getHealthThresholds(resourceType)),
+	}
+	status.HealthRollup = status.Health
+	if status.Health == healthOK {
+		return status, nil
+	}
+	faults, err := GetFaults(dn)
+	if err != nil {
+		log.Warn("Unable to get the faults of " + dn + ", health is reported without conditions: " + err.Error())
+		return status, nil
+	}
+	addFaultConditions(status, faults)
+	return status, nil
+}
+
+// CombineHealth returns the health of a resource made of parts having their own health, like a fabric spread
+// across pods, the resource is as healthy as its least healthy part and reports the conditions of all the parts
+func CombineHealth(statuses []*capmodel.Status) *capmodel.Status {
+	combined := &capmodel.Status{}
+	for _, status := range statuses {
+		if combined.Health == "" || healthRank[status.Health] > healthRank[combined.Health] {
+			combined.Health = status.Health
+		}
+		if combined.HealthRollup == "" || healthRank[status.HealthRollup] > healthRank[combined.HealthRollup] {
+			combined.HealthRollup = status.HealthRollup
+		}
+		combined.Conditions = append(combined.Conditions, status.Conditions...)
+	}
+	return combined
+}
+
+// ClusterHealth returns the health of the APIC cluster from the state of its members,
+// the cluster is OK only when all the members are available and fully fit
+func ClusterHealth(members []capmodel.InfraWiNodeAttributes) string {
+	health := healthOK
+	for _, member := range members {
+		memberHealth := healthCritical
+		switch {
+		case member.OperSt != "available":
+		case member.Health == "fully-fit":
+			memberHealth = healthOK
+		case strings.Contains(member.Health, "partially-"):
+			memberHealth = healthWarning
+		}
+		if healthRank[memberHealth] > healthRank[health] {
+			health = memberHealth
+		}
+	}
+	return health
+}
+
+func healthFromScore(score int, thresholds *config.HealthThresholds) string {
+	switch {
+	case score >= thresholds.OKScore:
+		return healthOK
+	case score >= thresholds.WarningScore:
+		return healthWarning
+	}
+	return healthCritical
+}
+
+// addFaultConditions reports the faults affecting the health as conditions, most severe ones first
+func addFaultConditions(status *capmodel.Status, faults []capmodel.Fault) {
+	sort.SliceStable(faults, func(i, j int) bool {
+		iRank := healthRank[faultSeverityHealth[faults[i].Attributes.Severity]]
+		jRank := healthRank[faultSeverityHealth[faults[j].Attributes.Severity]]
+		if iRank != jRank {
+			return iRank > jRank
+		}
+		return faults[i].Attributes.DN < faults[j].Attributes.DN
+	})
+	for _, fault := range faults {
+		attributes := fault.Attributes
+		severity, ok := faultSeverityHealth[attributes.Severity]
+		if !ok {
+			continue
+		}
+		if healthRank[severity] > healthRank[status.HealthRollup] {
+			status.HealthRollup = severity
+		}
+		timestamp := attributes.LastTransition
+		if timestamp == "" {
+			timestamp = attributes.Created
+		}
+		status.Conditions = append(status.Conditions, capmodel.Condition{
+			MessageID:   "CiscoACI.1.0." + attributes.Code,
+			Message:     attributes.Descr,
+			MessageArgs: []string{attributes.DN, attributes.Cause},
+			Severity:    severity,
+			Timestamp:   timestamp,
+		})
+	}
+}
+
+func getHealthThresholds(resourceType HealthResourceType) *config.HealthThresholds {
+	healthConf := config.Data.HealthConf
+	if healthConf == nil {
+		healthConf = config.NewHealthConf()
+	}
+	var thresholds *config.HealthThresholds
+	switch resourceType {
+	case HealthResourceFabric:
+		thresholds = healthConf.Fabric
+	case HealthResourceSwitch:
+		thresholds = healthConf.Switch
+	case HealthResourceChassis:
+		thresholds = healthConf.Chassis
+	case HealthResourcePort:
+		thresholds = healthConf.Port
+	}
+	if thresholds == nil {
+		return &config.HealthThresholds{
+			OKScore:      config.DefaultOKHealthScore,
+			WarningScore: config.DefaultWarningHealthScore,
+		}
+	}
+	return thresholds
+}
@@ -0,0 +1,139 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"testing"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthFromScore(t *testing.T) {
+	thresholds := &config.HealthThresholds{OKScore: 90, WarningScore: 30}
+	for score, want := range map[int]string{
+		100: "OK",
+		90:  "OK",
+		89:  "Warning",
+		50:  "Warning",
+		30:  "Warning",
+		29:  "Critical",
+		0:   "Critical",
+	} {
+		assert.Equal(t, want, healthFromScore(score, thresholds), "health of score %d", score)
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	config.SetUpMockConfig(t)
+	apic, err := StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	defer apic.Close()
+	switchDN := "topology/pod-1/node-101/sys"
+	apic.AddObject("faultInst", map[string]interface{}{
+		"dn":             switchDN + "/phys-[eth1/1]/fault-F1394",
+		"code":           "F1394",
+		"severity":       "minor",
+		"descr":          "Port is down, reason:notconnect(9)",
+		"cause":          "interface-physical-down",
+		"lastTransition": "2022-08-01T10:00:00.000+00:00",
+	})
+	apic.AddObject("faultInst", map[string]interface{}{
+		"dn":       switchDN + "/ch/psuslot-1/psu/fault-F1451",
+		"code":     "F1451",
+		"severity": "major",
+		"descr":    "Power supply shutdown",
+		"cause":    "equipment-psu-missing",
+		"created":  "2022-08-01T09:00:00.000+00:00",
+	})
+	apic.AddObject("faultInst", map[string]interface{}{
+		"dn":       switchDN + "/ch/fault-F0101",
+		"code":     "F0101",
+		"severity": "cleared",
+	})
+	apic.AddObject("faultInst", map[string]interface{}{
+		"dn":       "topology/pod-1/node-102/sys/fault-F0102",
+		"code":     "F0102",
+		"severity": "critical",
+	})
+
+	status, err := EvaluateHealth(HealthResourceSwitch, &capmodel.HealthAttributes{DN: switchDN + "/health", Cur: "55"}, switchDN)
+	assert.Nil(t, err, "health evaluation should not fail")
+	assert.Equal(t, "Warning", status.Health)
+	assert.Equal(t, "Critical", status.HealthRollup, "major fault should roll up as Critical")
+	if assert.Len(t, status.Conditions, 2, "only the active faults of the switch should be reported") {
+		assert.Equal(t, capmodel.Condition{
+			MessageID:   "CiscoACI.1.0.F1451",
+			Message:     "Power supply shutdown",
+			MessageArgs: []string{switchDN + "/ch/psuslot-1/psu/fault-F1451", "equipment-psu-missing"},
+			Severity:    "Critical",
+			Timestamp:   "2022-08-01T09:00:00.000+00:00",
+		}, status.Conditions[0])
+		assert.Equal(t, "CiscoACI.1.0.F1394", status.Conditions[1].MessageID)
+		assert.Equal(t, "Warning", status.Conditions[1].Severity)
+		assert.Equal(t, "2022-08-01T10:00:00.000+00:00", status.Conditions[1].Timestamp)
+	}
+
+	status, err = EvaluateHealth(HealthResourceSwitch, &capmodel.HealthAttributes{Cur: "95"}, switchDN)
+	assert.Nil(t, err, "health evaluation should not fail")
+	assert.Equal(t, &capmodel.Status{Health: "OK", HealthRollup: "OK"}, status, "faults are not reported when the score is OK")
+
+	config.Data.HealthConf = config.NewHealthConf()
+	config.Data.HealthConf.Port = &config.HealthThresholds{OKScore: 99, WarningScore: 96}
+	defer func() { config.Data.HealthConf = nil }()
+	status, err = EvaluateHealth(HealthResourcePort, &capmodel.HealthAttributes{Cur: "95"}, switchDN+"/phys-[eth1/1]")
+	assert.Nil(t, err, "health evaluation should not fail")
+	assert.Equal(t, "Critical", status.Health, "configured port thresholds should be used")
+	assert.Equal(t, "Critical", status.HealthRollup)
+	assert.Len(t, status.Conditions, 1)
+
+	_, err = EvaluateHealth(HealthResourceFabric, &capmodel.HealthAttributes{Cur: "unknown"}, "topology/pod-1")
+	assert.NotNil(t, err, "invalid score should fail")
+}
+
+func TestCombineHealth(t *testing.T) {
+	pod1 := &capmodel.Status{Health: "OK", HealthRollup: "OK"}
+	pod2 := &capmodel.Status{
+		Health:       "Warning",
+		HealthRollup: "Critical",
+		Conditions:   []capmodel.Condition{{MessageID: "CiscoACI.1.0.F1451", Severity: "Critical"}},
+	}
+	status := CombineHealth([]*capmodel.Status{pod1, pod2})
+	assert.Equal(t, "Warning", status.Health, "least healthy pod should give the health")
+	assert.Equal(t, "Critical", status.HealthRollup)
+	assert.Equal(t, pod2.Conditions, status.Conditions, "conditions of all the pods should be reported")
+
+	assert.Equal(t, &capmodel.Status{}, CombineHealth(nil), "no health should be reported without parts")
+}
+
+func TestClusterHealth(t *testing.T) {
+	member := func(health, operSt string) capmodel.InfraWiNodeAttributes {
+		return capmodel.InfraWiNodeAttributes{Health: health, OperSt: operSt}
+	}
+	for _, tt := range []struct {
+		members []capmodel.InfraWiNodeAttributes
+		want    string
+	}{
+		{[]capmodel.InfraWiNodeAttributes{member("fully-fit", "available"), member("fully-fit", "available")}, "OK"},
+		{[]capmodel.InfraWiNodeAttributes{member("fully-fit", "available"), member("data-layer-partially-diverged", "available")}, "Warning"},
+		{[]capmodel.InfraWiNodeAttributes{member("data-layer-partially-diverged", "available"), member("data-layer-diverged", "available")}, "Critical"},
+		{[]capmodel.InfraWiNodeAttributes{member("fully-fit", "available"), member("fully-fit", "unavailable")}, "Critical"},
+	} {
+		assert.Equal(t, tt.want, ClusterHealth(tt.members), "health of cluster %+v", tt.members)
+	}
+}
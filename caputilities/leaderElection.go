@@ -0,0 +1,122 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// elector campaigns for the leadership of a plugin instance
+type elector struct {
+	mux        sync.RWMutex
+	leadership *capmodel.Leadership
+}
+
+// pluginElector is the elector of this plugin instance
+var pluginElector = &elector{}
+
+// IsLeader reports whether this plugin instance is currently the leader among the replicas
+func IsLeader() bool {
+	return pluginElector.current() != nil
+}
+
+// RunLeaderElection campaigns for the leadership among the plugin instances sharing the DB until stop is closed.
+// Each time this instance is elected, onElected is started with a channel which is closed once the leadership is lost.
+// A leader which can't renew its leadership steps down before its lease expires, so that two instances never lead at once
+func RunLeaderElection(stop <-chan struct{}, onElected func(lost <-chan struct{})) {
+	pluginElector.run(stop, onElected)
+}
+
+func getElectionConf() *config.ElectionConf {
+	if config.Data.ElectionConf == nil {
+		return config.NewElectionConf()
+	}
+	return config.Data.ElectionConf
+}
+
+func (e *elector) current() *capmodel.Leadership {
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+	return e.leadership
+}
+
+func (e *elector) set(leadership *capmodel.Leadership) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.leadership = leadership
+}
+
+func (e *elector) run(stop <-chan struct{}, onElected func(lost <-chan struct{})) {
+	electionConf := getElectionConf()
+	lease := time.Duration(electionConf.LeaseTimeInSeconds) * time.Second
+	renewInterval := time.Duration(electionConf.RenewIntervalInSeconds) * time.Second
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	var lost chan struct{}
+	var renewedAt time.Time
+	stepDown := func() {
+		e.set(nil)
+		close(lost)
+	}
+	for {
+		if leadership := e.current(); leadership == nil {
+			leadership, err := capmodel.AcquireLeadership(lease)
+			if err != nil {
+				log.Error("failed to campaign for the leadership: " + err.Error())
+			}
+			if leadership != nil {
+				log.Info("plugin instance " + capmodel.InstanceID + " is elected as leader")
+				renewedAt = leadership.ElectedAt
+				lost = make(chan struct{})
+				e.set(leadership)
+				go onElected(lost)
+			}
+		} else {
+			attemptedAt := time.Now()
+			renewed, err := leadership.Renew(lease)
+			switch {
+			case err == nil && renewed:
+				renewedAt = attemptedAt
+			case err == nil:
+				log.Warn("plugin instance " + capmodel.InstanceID + " lost the leadership, it expired or was taken over before being renewed")
+				stepDown()
+			case attemptedAt.Add(renewInterval).Sub(renewedAt) >= lease:
+				// the lease would expire before the next attempt and another instance could take over
+				log.Error("plugin instance " + capmodel.InstanceID + " steps down as the leadership couldn't be renewed: " + err.Error())
+				stepDown()
+			default:
+				log.Error("failed to renew the leadership, it is attempted again: " + err.Error())
+			}
+		}
+		select {
+		case <-stop:
+			if leadership := e.current(); leadership != nil {
+				stepDown()
+				if err := leadership.Resign(); err != nil {
+					log.Error("failed to resign the leadership, it expires after the lease time: " + err.Error())
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,118 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package caputilities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// startElector runs the election of an instance and reports on elected each time it becomes the leader,
+// the returned stop ends the election and waits for it to return
+func startElector(e *elector) (stop func(), elected chan (<-chan struct{})) {
+	stopCh, done := make(chan struct{}), make(chan struct{})
+	elected = make(chan (<-chan struct{}), 1)
+	go func() {
+		defer close(done)
+		e.run(stopCh, func(lost <-chan struct{}) {
+			elected <- lost
+		})
+	}()
+	return func() {
+		close(stopCh)
+		<-done
+	}, elected
+}
+
+func TestLeaderElectionFailover(t *testing.T) {
+	db.Connector = db.NewInMemoryConnector()
+	config.Data.ElectionConf = &config.ElectionConf{LeaseTimeInSeconds: 2, RenewIntervalInSeconds: 1}
+	defer func() {
+		db.Connector = db.MockConnector{}
+		config.Data.ElectionConf = nil
+	}()
+
+	first, second := &elector{}, &elector{}
+	stopFirst, firstElected := startElector(first)
+	var firstLost <-chan struct{}
+	select {
+	case firstLost = <-firstElected:
+	case <-time.After(time.Second):
+		t.Fatal("the first instance wasn't elected")
+	}
+	stopSecond, secondElected := startElector(second)
+	defer stopSecond()
+
+	// the leadership is renewed beyond its lease time and the other instance stays a follower
+	select {
+	case <-secondElected:
+		t.Fatal("the second instance was elected while the first one is the leader")
+	case <-time.After(3 * time.Second):
+	}
+	assert.NotNil(t, first.current(), "the first instance should still be the leader")
+	leader, err := capmodel.GetLeader()
+	if assert.NoError(t, err) {
+		assert.Equal(t, first.current().ElectedAt.UnixNano(), leader.ElectedAt.UnixNano())
+	}
+
+	// the leader resigning hands the leadership over at the next campaign of the follower
+	stopFirst()
+	select {
+	case <-firstLost:
+	case <-time.After(time.Second):
+		t.Fatal("the first instance wasn't told about losing the leadership")
+	}
+	select {
+	case <-secondElected:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the second instance didn't take over the leadership")
+	}
+	assert.Nil(t, first.current())
+	assert.NotNil(t, second.current())
+}
+
+func TestLeaderStepsDownWhenLeadershipIsTakenOver(t *testing.T) {
+	db.Connector = db.NewInMemoryConnector()
+	config.Data.ElectionConf = &config.ElectionConf{LeaseTimeInSeconds: 2, RenewIntervalInSeconds: 1}
+	defer func() {
+		db.Connector = db.MockConnector{}
+		config.Data.ElectionConf = nil
+	}()
+
+	e := &elector{}
+	stop, elected := startElector(e)
+	defer stop()
+	var lost <-chan struct{}
+	select {
+	case lost = <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("the instance wasn't elected")
+	}
+	// another instance took over, e.g. after this one was paused for longer than the lease time
+	if err := db.Connector.Update(db.TableLeader, "leader", `{"Instance":"other"}`); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lost:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the instance didn't step down")
+	}
+	assert.Nil(t, e.current())
+}
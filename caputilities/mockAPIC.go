@@ -0,0 +1,585 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/gorilla/websocket"
+)
+
+const mockAPICToken = "mock-apic-token"
+
+// MockACIObject is a managed object stored by the MockAPIC
+type MockACIObject struct {
+	ClassName  string
+	Attributes map[string]interface{}
+}
+
+// MockAPIC is a stand-in for the APIC REST API, it keeps the ACI object tree in memory
+// and serves the login, mo and class queries used by the plugin
+type MockAPIC struct {
+	Server        *httptest.Server
+	mux           sync.Mutex
+	objects       map[string]MockACIObject
+	subscriptions map[string]string
+	sockets       []*websocket.Conn
+}
+
+// StartMockAPIC starts a TLS MockAPIC using the certificates from the plugin configuration
+// and points the APIC configuration of the plugin to it
+func StartMockAPIC() (*MockAPIC, error) {
+	cert, err := tls.X509KeyPair(config.Data.KeyCertConf.Certificate, config.Data.KeyCertConf.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key pair: %v", err)
+	}
+	capool := x509.NewCertPool()
+	if !capool.AppendCertsFromPEM(config.Data.KeyCertConf.RootCACertificate) {
+		return nil, fmt.Errorf("failed to load CA certificate")
+	}
+	m := &MockAPIC{
+		objects:       make(map[string]MockACIObject),
+		subscriptions: make(map[string]string),
+	}
+	m.Server = httptest.NewUnstartedServer(http.HandlerFunc(m.serveHTTP))
+	m.Server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      capool,
+		ClientCAs:    capool,
+	}
+	m.Server.StartTLS()
+
+	config.Data.APICConf = &config.APICConf{
+		APICHost: m.Host(),
+		UserName: "admin",
+		Password: "password",
+		RetryConf: &config.APICRetryConf{
+			MaxRetries:               2,
+			BaseDelayInMilliSeconds:  1,
+			MaxDelayInMilliSeconds:   5,
+			BreakerFailureThreshold:  5,
+			BreakerOpenTimeInSeconds: 30,
+		},
+	}
+	// a new APIC is reachable irrespective of the failures seen so far
+	apicCircuitBreaker.reset()
+	apicObjectCache.clear()
+	return m, nil
+}
+
+// Host returns the host:port on which the MockAPIC is listening
+func (m *MockAPIC) Host() string {
+	return m.Server.Listener.Addr().String()
+}
+
+// Close shuts down the MockAPIC
+func (m *MockAPIC) Close() {
+	m.CloseSockets()
+	m.Server.Close()
+}
+
+// CloseSockets drops the websocket connections and the subscriptions made on them
+func (m *MockAPIC) CloseSockets() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for _, conn := range m.sockets {
+		conn.Close()
+	}
+	m.sockets = nil
+	m.subscriptions = make(map[string]string)
+}
+
+// SubscribedClasses returns the classes for which subscriptions are made, sorted by name
+func (m *MockAPIC) SubscribedClasses() []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var classNames []string
+	for _, className := range m.subscriptions {
+		classNames = append(classNames, className)
+	}
+	sort.Strings(classNames)
+	return classNames
+}
+
+// AddObject stores the given object in the ACI object tree, dn of the object is read from its attributes
+func (m *MockAPIC) AddObject(className string, attributes map[string]interface{}) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.saveObject(className, attributes["dn"].(string), attributes)
+}
+
+// GetObject returns the object stored with the given dn
+func (m *MockAPIC) GetObject(dn string) (MockACIObject, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	object, ok := m.objects[dn]
+	return object, ok
+}
+
+// GetObjectsByClass returns all the objects of given class sorted by dn
+func (m *MockAPIC) GetObjectsByClass(className string) []MockACIObject {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.queryClass("", className)
+}
+
+// DNs returns the dn of all the objects present in the ACI object tree
+func (m *MockAPIC) DNs() []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var dns []string
+	for dn := range m.objects {
+		dns = append(dns, dn)
+	}
+	sort.Strings(dns)
+	return dns
+}
+
+func (m *MockAPIC) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	path := r.URL.Path
+	if path == "/api/aaaLogin.json" {
+		writeMockAPICResponse(w, http.StatusOK, []interface{}{
+			map[string]interface{}{
+				"aaaLogin": map[string]interface{}{
+					"attributes": map[string]interface{}{
+						"token":                 mockAPICToken,
+						"creationTime":          fmt.Sprintf("%d", time.Now().Unix()),
+						"refreshTimeoutSeconds": "600",
+					},
+				},
+			},
+		})
+		return
+	}
+	if path == "/socket"+mockAPICToken {
+		m.openSocket(w, r)
+		return
+	}
+	cookie, err := r.Cookie("APIC-Cookie")
+	if err != nil || cookie.Value != mockAPICToken {
+		writeMockAPICError(w, http.StatusForbidden, "403", "Token was invalid (Error: Token timeout)")
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	switch {
+	case path == "/api/aaaRefresh.json" && r.Method == http.MethodGet:
+		writeMockAPICResponse(w, http.StatusOK, []interface{}{
+			map[string]interface{}{
+				"aaaLogin": map[string]interface{}{
+					"attributes": map[string]interface{}{
+						"token": mockAPICToken,
+					},
+				},
+			},
+		})
+	case path == "/api/subscriptionRefresh.json" && r.Method == http.MethodGet:
+		if _, ok := m.subscriptions[r.URL.Query().Get("id")]; !ok {
+			writeMockAPICError(w, http.StatusBadRequest, "400", "subscription not found")
+			return
+		}
+		writeMockAPICResponse(w, http.StatusOK, []interface{}{})
+	case strings.HasPrefix(path, "/api/node/class/") && r.Method == http.MethodGet && r.URL.Query().Get("subscription") == "yes":
+		className := strings.TrimSuffix(strings.TrimPrefix(path, "/api/node/class/"), ".json")
+		subscriptionID := strconv.Itoa(len(m.subscriptions) + 1)
+		m.subscriptions[subscriptionID] = className
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"totalCount":     "0",
+			"subscriptionId": subscriptionID,
+			"imdata":         []interface{}{},
+		})
+	case strings.HasPrefix(path, "/api/node/class/") && r.Method == http.MethodGet:
+		query := strings.TrimSuffix(strings.TrimPrefix(path, "/api/node/class/"), ".json")
+		var scope, className = "", query
+		if index := strings.LastIndex(query, "/"); index != -1 {
+			scope, className = query[:index], query[index+1:]
+		}
+		objects, err := filterMockObjects(m.queryClass(scope, className), r.URL.Query())
+		if err != nil {
+			writeMockAPICError(w, http.StatusBadRequest, "400", err.Error())
+			return
+		}
+		writeMockAPICPage(w, objects, r.URL.Query())
+	case strings.HasPrefix(path, "/api/node/mo/") && r.Method == http.MethodGet:
+		dn := strings.TrimSuffix(strings.TrimPrefix(path, "/api/node/mo/"), ".json")
+		objects, err := filterMockObjects(m.queryMO(dn, r.URL.Query()), r.URL.Query())
+		if err != nil {
+			writeMockAPICError(w, http.StatusBadRequest, "400", err.Error())
+			return
+		}
+		writeMockAPICPage(w, objects, r.URL.Query())
+	case strings.HasPrefix(path, "/api/node/mo") && r.Method == http.MethodPost:
+		dn := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(path, "/api/node/mo"), "/"), ".json")
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeMockAPICError(w, http.StatusBadRequest, "400", err.Error())
+			return
+		}
+		var payload map[string]mockACIPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeMockAPICError(w, http.StatusBadRequest, "400", "malformed request body: "+err.Error())
+			return
+		}
+		if err := m.applyPayload(dn, "", payload); err != nil {
+			writeMockAPICError(w, http.StatusBadRequest, "400", err.Error())
+			return
+		}
+		writeMockAPICResponse(w, http.StatusOK, []interface{}{})
+	case strings.HasPrefix(path, "/api/node/mo/") && r.Method == http.MethodDelete:
+		m.deleteObject(strings.TrimSuffix(strings.TrimPrefix(path, "/api/node/mo/"), ".json"))
+		writeMockAPICResponse(w, http.StatusOK, []interface{}{})
+	default:
+		writeMockAPICError(w, http.StatusBadRequest, "400", "Request not supported by mock APIC: "+r.Method+" "+path)
+	}
+}
+
+// mockACIPayload is the body of an object in a POST request made to APIC
+type mockACIPayload struct {
+	Attributes map[string]interface{}      `json:"attributes"`
+	Children   []map[string]mockACIPayload `json:"children"`
+}
+
+// applyPayload creates, modifies or deletes the objects as per the POST payload
+func (m *MockAPIC) applyPayload(dn, parentDN string, payload map[string]mockACIPayload) error {
+	for className, object := range payload {
+		objectDN := dn
+		if value, ok := object.Attributes["dn"].(string); ok && value != "" {
+			objectDN = value
+		} else if rn, ok := object.Attributes["rn"].(string); ok && rn != "" && parentDN != "" {
+			objectDN = parentDN + "/" + rn
+		}
+		if objectDN == "" {
+			return fmt.Errorf("dn is missing for the object of class %s", className)
+		}
+		if status, _ := object.Attributes["status"].(string); status == "deleted" {
+			m.deleteObject(objectDN)
+			continue
+		}
+		m.saveObject(className, objectDN, object.Attributes)
+		for _, child := range object.Children {
+			if err := m.applyPayload("", objectDN, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MockAPIC) saveObject(className, dn string, attributes map[string]interface{}) {
+	object, ok := m.objects[dn]
+	if !ok || object.ClassName != className {
+		object = MockACIObject{
+			ClassName:  className,
+			Attributes: make(map[string]interface{}),
+		}
+	}
+	for key, value := range attributes {
+		if key != "status" {
+			object.Attributes[key] = value
+		}
+	}
+	object.Attributes["dn"] = dn
+	status := "modified"
+	if !ok {
+		status = "created"
+	}
+	m.objects[dn] = object
+	m.notify(className, dn, status)
+}
+
+// deleteObject removes the object and all of its children
+func (m *MockAPIC) deleteObject(dn string) {
+	for objectDN := range m.objects {
+		if objectDN == dn || strings.HasPrefix(objectDN, dn+"/") {
+			m.notify(m.objects[objectDN].ClassName, objectDN, "deleted")
+			delete(m.objects, objectDN)
+		}
+	}
+}
+
+// openSocket upgrades the request to the websocket on which the subscribed changes are notified
+func (m *MockAPIC) openSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	m.mux.Lock()
+	m.sockets = append(m.sockets, conn)
+	m.mux.Unlock()
+	// the reads are needed for processing the close messages of the client
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}()
+}
+
+// notify sends the change of the object to the websockets when its class is subscribed
+func (m *MockAPIC) notify(className, dn, status string) {
+	var subscriptionIDs []string
+	for subscriptionID, subscribedClass := range m.subscriptions {
+		if subscribedClass == className {
+			subscriptionIDs = append(subscriptionIDs, subscriptionID)
+		}
+	}
+	if len(subscriptionIDs) == 0 {
+		return
+	}
+	event := map[string]interface{}{
+		"subscriptionId": subscriptionIDs,
+		"imdata": []interface{}{
+			map[string]interface{}{
+				className: map[string]interface{}{
+					"attributes": map[string]interface{}{
+						"dn":     dn,
+						"status": status,
+					},
+				},
+			},
+		},
+	}
+	for _, conn := range m.sockets {
+		conn.WriteJSON(event)
+	}
+}
+
+// queryClass returns objects of given class which are under the scope dn
+func (m *MockAPIC) queryClass(scope, className string) []MockACIObject {
+	var dns []string
+	for dn, object := range m.objects {
+		if object.ClassName != className {
+			continue
+		}
+		if scope != "" && !strings.HasPrefix(dn, scope+"/") {
+			continue
+		}
+		dns = append(dns, dn)
+	}
+	sort.Strings(dns)
+	var objects []MockACIObject
+	for _, dn := range dns {
+		objects = append(objects, m.objects[dn])
+	}
+	return objects
+}
+
+// queryMO returns the object with the dn, or the objects under it of the target-subtree-class
+// when query-target is subtree
+func (m *MockAPIC) queryMO(dn string, query url.Values) []MockACIObject {
+	if query.Get("query-target") != string(QueryTargetSubtree) {
+		if object, ok := m.objects[dn]; ok {
+			return []MockACIObject{object}
+		}
+		return nil
+	}
+	classNames := make(map[string]bool)
+	for _, className := range strings.Split(query.Get("target-subtree-class"), ",") {
+		if className != "" {
+			classNames[className] = true
+		}
+	}
+	var dns []string
+	for objectDN, object := range m.objects {
+		if objectDN != dn && !strings.HasPrefix(objectDN, dn+"/") {
+			continue
+		}
+		if len(classNames) != 0 && !classNames[object.ClassName] {
+			continue
+		}
+		dns = append(dns, objectDN)
+	}
+	sort.Strings(dns)
+	var objects []MockACIObject
+	for _, objectDN := range dns {
+		objects = append(objects, m.objects[objectDN])
+	}
+	return objects
+}
+
+// filterMockObjects applies the query-target-filter and order-by of the query on the objects
+func filterMockObjects(objects []MockACIObject, query url.Values) ([]MockACIObject, error) {
+	if filter := query.Get("query-target-filter"); filter != "" {
+		var filtered []MockACIObject
+		for _, object := range objects {
+			matched, err := matchMockFilter(object, filter)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				filtered = append(filtered, object)
+			}
+		}
+		objects = filtered
+	}
+	if orderBy := query.Get("order-by"); orderBy != "" {
+		for _, order := range strings.Split(orderBy, ",") {
+			if order == "" {
+				continue
+			}
+			property, direction := order, "asc"
+			if index := strings.Index(order, "|"); index != -1 {
+				property, direction = order[:index], order[index+1:]
+			}
+			property = property[strings.Index(property, ".")+1:]
+			sort.SliceStable(objects, func(i, j int) bool {
+				left := fmt.Sprint(objects[i].Attributes[property])
+				right := fmt.Sprint(objects[j].Attributes[property])
+				if direction == "desc" {
+					return left > right
+				}
+				return left < right
+			})
+		}
+	}
+	return objects, nil
+}
+
+// matchMockFilter evaluates the eq, ne, wcard, and and or filter expressions on the object
+func matchMockFilter(object MockACIObject, filter string) (bool, error) {
+	index := strings.Index(filter, "(")
+	if index == -1 || !strings.HasSuffix(filter, ")") {
+		return false, fmt.Errorf("malformed filter %s", filter)
+	}
+	operator, arguments := filter[:index], splitMockFilterArguments(filter[index+1:len(filter)-1])
+	switch operator {
+	case "and", "or":
+		for _, argument := range arguments {
+			matched, err := matchMockFilter(object, argument)
+			if err != nil {
+				return false, err
+			}
+			if matched == (operator == "or") {
+				return matched, nil
+			}
+		}
+		return operator == "and", nil
+	case "eq", "ne", "wcard":
+		if len(arguments) != 2 {
+			return false, fmt.Errorf("malformed filter %s", filter)
+		}
+		property := arguments[0][strings.Index(arguments[0], ".")+1:]
+		value, err := strconv.Unquote(arguments[1])
+		if err != nil {
+			return false, fmt.Errorf("malformed value in filter %s", filter)
+		}
+		actual := fmt.Sprint(object.Attributes[property])
+		switch operator {
+		case "eq":
+			return actual == value, nil
+		case "ne":
+			return actual != value, nil
+		}
+		return regexp.MatchString(value, actual)
+	}
+	return false, fmt.Errorf("filter operator %s is not supported by mock APIC", operator)
+}
+
+// splitMockFilterArguments splits the comma separated arguments which aren't nested or quoted
+func splitMockFilterArguments(arguments string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i, char := range arguments {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			parts = append(parts, arguments[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, arguments[start:])
+}
+
+// writeMockAPICPage writes the page of objects requested with page and page-size,
+// totalCount is the count of all the objects like in APIC
+func writeMockAPICPage(w http.ResponseWriter, objects []MockACIObject, query url.Values) {
+	totalCount := len(objects)
+	if pageSize, err := strconv.Atoi(query.Get("page-size")); err == nil && pageSize > 0 {
+		page, _ := strconv.Atoi(query.Get("page"))
+		start, end := page*pageSize, (page+1)*pageSize
+		if start > len(objects) {
+			start = len(objects)
+		}
+		if end > len(objects) {
+			end = len(objects)
+		}
+		objects = objects[start:end]
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"totalCount": fmt.Sprintf("%d", totalCount),
+		"imdata":     toIMData(objects),
+	})
+}
+
+func toIMData(objects []MockACIObject) []interface{} {
+	imdata := []interface{}{}
+	for _, object := range objects {
+		imdata = append(imdata, map[string]interface{}{
+			object.ClassName: map[string]interface{}{
+				"attributes": object.Attributes,
+			},
+		})
+	}
+	return imdata
+}
+
+func writeMockAPICResponse(w http.ResponseWriter, statusCode int, imdata []interface{}) {
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"totalCount": fmt.Sprintf("%d", len(imdata)),
+		"imdata":     imdata,
+	})
+}
+
+func writeMockAPICError(w http.ResponseWriter, statusCode int, code, text string) {
+	writeMockAPICResponse(w, statusCode, []interface{}{
+		map[string]interface{}{
+			"error": map[string]interface{}{
+				"attributes": map[string]interface{}{
+					"code": code,
+					"text": text,
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,141 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+// Package caputilities ...
+package caputilities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+// odimLink is the link to a resource in an ODIM response
+type odimLink struct {
+	Oid string `json:"@odata.id"`
+}
+
+type odimCollection struct {
+	Members []odimLink `json:"Members"`
+}
+
+type odimSystem struct {
+	HostName           string   `json:"HostName"`
+	EthernetInterfaces odimLink `json:"EthernetInterfaces"`
+}
+
+type odimEthernetInterface struct {
+	ID                  string `json:"Id"`
+	Name                string `json:"Name"`
+	MACAddress          string `json:"MACAddress"`
+	PermanentMACAddress string `json:"PermanentMACAddress"`
+}
+
+// odimClient reads the resources of ODIM with the credentials of ODIMConf
+type odimClient struct {
+	httpClient *http.Client
+	password   string
+}
+
+// GetODIMEthernetInterfaces collects the ethernet interfaces of all the systems managed by ODIM,
+// the uris of the interfaces are translated to the form used by the plugin
+func GetODIMEthernetInterfaces() ([]capmodel.ODIMEthernetInterface, error) {
+	redfishClient, err := GetRedfishClient()
+	if err != nil {
+		return nil, err
+	}
+	enigma, err := NewEnigma(config.Data.KeyCertConf.RSAPrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	client := odimClient{
+		httpClient: redfishClient.httpClient,
+		password:   string(enigma.Decrypt(config.Data.ODIMConf.Password)),
+	}
+
+	var systems odimCollection
+	if err := client.get("/redfish/v1/Systems", &systems); err != nil {
+		return nil, err
+	}
+	var ethernetInterfaces []capmodel.ODIMEthernetInterface
+	for _, systemLink := range systems.Members {
+		var system odimSystem
+		if err := client.get(systemLink.Oid, &system); err != nil {
+			return nil, err
+		}
+		if system.EthernetInterfaces.Oid == "" {
+			continue
+		}
+		var interfaceLinks odimCollection
+		if err := client.get(system.EthernetInterfaces.Oid, &interfaceLinks); err != nil {
+			return nil, err
+		}
+		for _, interfaceLink := range interfaceLinks.Members {
+			var ethernetInterface odimEthernetInterface
+			if err := client.get(interfaceLink.Oid, &ethernetInterface); err != nil {
+				return nil, err
+			}
+			ethernetInterfaces = append(ethernetInterfaces, capmodel.ODIMEthernetInterface{
+				URI:                 translateURL(interfaceLink.Oid, config.Data.URLTranslation.NorthBoundURL),
+				ID:                  ethernetInterface.ID,
+				Name:                ethernetInterface.Name,
+				MACAddress:          ethernetInterface.MACAddress,
+				PermanentMACAddress: ethernetInterface.PermanentMACAddress,
+				HostName:            system.HostName,
+			})
+		}
+	}
+	return ethernetInterfaces, nil
+}
+
+// get reads the ODIM resource at uri into resp
+func (c *odimClient) get(uri string, resp interface{}) error {
+	reqURL := config.Data.ODIMConf.URL + translateURL(uri, config.Data.URLTranslation.SouthBoundURL)
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return err
+	}
+	auth := config.Data.ODIMConf.UserName + ":" + c.password
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	req.Header.Set("Accept", "application/json")
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ODIM responded to GET %s with %d: %s", uri, httpResp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("while trying to unmarshal the ODIM response of %s, got: %v", uri, err)
+	}
+	return nil
+}
+
+func translateURL(uri string, translation map[string]string) string {
+	for key, value := range translation {
+		uri = strings.Replace(uri, key, value, -1)
+	}
+	return uri
+}
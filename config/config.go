@@ -36,19 +36,25 @@ var Data configModel
 
 // configModel is for holding all the run time configurations for the svc-redfish-plugin
 type configModel struct {
-	FirmwareVersion         string            `json:"FirmwareVersion"` //FirmwareVersion of plugin of the plugin
-	RootServiceUUID         string            `json:"RootServiceUUID"`
-	SessionTimeoutInMinutes float64           `json:"SessionTimeoutInMinutes"` //plugin token time out in minutes
-	PluginConf              *PluginConf       `json:"PluginConf"`
-	LoadBalancerConf        *LoadBalancerConf `json:"LoadBalancerConf"`
-	EventConf               *EventConf        `json:"EventConf"`
-	MessageBusConf          *MessageBusConf   `json:"MessageBusConf"`
-	DBConf                  *DBConf           `json:"DBConf"`
-	KeyCertConf             *KeyCertConf      `json:"KeyCertConf"`
-	URLTranslation          *URLTranslation   `json:"URLTranslation"`
-	TLSConf                 *TLSConf          `json:"TLSConf"`
-	APICConf                *APICConf         `json:"APICConf"`
-	ODIMConf                *ODIMConf         `json:"ODIMConf"`
+	FirmwareVersion         string               `json:"FirmwareVersion"` //FirmwareVersion of plugin of the plugin
+	RootServiceUUID         string               `json:"RootServiceUUID"`
+	SessionTimeoutInMinutes float64              `json:"SessionTimeoutInMinutes"` //plugin token time out in minutes
+	PluginConf              *PluginConf          `json:"PluginConf"`
+	LoadBalancerConf        *LoadBalancerConf    `json:"LoadBalancerConf"`
+	EventConf               *EventConf           `json:"EventConf"`
+	MessageBusConf          *MessageBusConf      `json:"MessageBusConf"`
+	DBConf                  *DBConf              `json:"DBConf"`
+	KeyCertConf             *KeyCertConf         `json:"KeyCertConf"`
+	URLTranslation          *URLTranslation      `json:"URLTranslation"`
+	TLSConf                 *TLSConf             `json:"TLSConf"`
+	APICConf                *APICConf            `json:"APICConf"`
+	ODIMConf                *ODIMConf            `json:"ODIMConf"`
+	HealthConf              *HealthConf          `json:"HealthConf"`
+	LockConf                *LockConf            `json:"LockConf"`
+	ElectionConf            *ElectionConf        `json:"ElectionConf"`
+	InventoryConf           *InventoryConf       `json:"InventoryConf"`
+	HostCorrelationConf     *HostCorrelationConf `json:"HostCorrelationConf"`
+	TelemetryConf           *TelemetryConf       `json:"TelemetryConf"`
 }
 
 // DBConf holds all DB related configurations
@@ -121,12 +127,73 @@ type TLSConf struct {
 	PreferredCipherSuites []string `json:"PreferredCipherSuites"`
 }
 
-// APICConf is for holding all the cisco APIC related configurations
+// APICConf is for holding all the cisco APIC related configurations,
+// when RecordPath is set the sanitized APIC requests and responses are stored under it,
+// CacheEnabled turns the caching of the health and operational state read from APIC on or off, it is on
+// when not set, CacheTTLInSeconds is the time for which the cached objects are reused
 type APICConf struct {
-	APICHost   string            `json:"APICHost"`
-	UserName   string            `json:"UserName"`
-	Password   string            `json:"Password"`
-	DomainData map[string]string `json:"DomainData"`
+	APICHost          string            `json:"APICHost"`
+	UserName          string            `json:"UserName"`
+	Password          string            `json:"Password"`
+	DomainData        map[string]string `json:"DomainData"`
+	RecordPath        string            `json:"RecordPath"`
+	RetryConf         *APICRetryConf    `json:"RetryConf"`
+	CacheEnabled      *bool             `json:"CacheEnabled"`
+	CacheTTLInSeconds int               `json:"CacheTTLInSeconds"`
+}
+
+// HealthConf holds the thresholds used for mapping the ACI health score of each resource type to Redfish health
+type HealthConf struct {
+	Fabric  *HealthThresholds `json:"Fabric"`
+	Switch  *HealthThresholds `json:"Switch"`
+	Chassis *HealthThresholds `json:"Chassis"`
+	Port    *HealthThresholds `json:"Port"`
+}
+
+// HealthThresholds holds the lowest health scores reported as OK and Warning, lower scores are reported as Critical
+type HealthThresholds struct {
+	OKScore      int `json:"OKScore"`
+	WarningScore int `json:"WarningScore"`
+}
+
+// LockConf holds the configurations of the locks serializing the fabric modifications across the plugin replicas
+type LockConf struct {
+	LeaseTimeInSeconds int `json:"LeaseTimeInSeconds"`
+	WaitTimeInSeconds  int `json:"WaitTimeInSeconds"`
+}
+
+// ElectionConf holds the configurations of the election of the plugin instance running the background tasks
+type ElectionConf struct {
+	LeaseTimeInSeconds     int `json:"LeaseTimeInSeconds"`
+	RenewIntervalInSeconds int `json:"RenewIntervalInSeconds"`
+}
+
+// InventoryConf holds the configurations of the detection of the nodes removed from the fabric
+type InventoryConf struct {
+	NodeCheckIntervalInSeconds int `json:"NodeCheckIntervalInSeconds"`
+	PurgeGracePeriodInSeconds  int `json:"PurgeGracePeriodInSeconds"`
+}
+
+// HostCorrelationConf holds the configurations of the correlation of the host-facing ports
+// with the ethernet interfaces of the ODIM systems seen on them with LLDP or CDP
+type HostCorrelationConf struct {
+	// Mode is auto for setting the matching interfaces as the connected ports, suggest for only suggesting them
+	Mode              string `json:"Mode"`
+	IntervalInSeconds int    `json:"IntervalInSeconds"`
+}
+
+// TelemetryConf holds the configurations of the metric reports sent for the fabric counters
+type TelemetryConf struct {
+	ReportIntervalInSeconds int `json:"ReportIntervalInSeconds"`
+}
+
+// APICRetryConf holds the retry and circuit breaker configurations used for the APIC calls
+type APICRetryConf struct {
+	MaxRetries               int `json:"MaxRetries"`
+	BaseDelayInMilliSeconds  int `json:"BaseDelayInMilliSeconds"`
+	MaxDelayInMilliSeconds   int `json:"MaxDelayInMilliSeconds"`
+	BreakerFailureThreshold  int `json:"BreakerFailureThreshold"`
+	BreakerOpenTimeInSeconds int `json:"BreakerOpenTimeInSeconds"`
 }
 
 // ODIMConf hold the value of the ODIMConfiguration to plugin
@@ -193,6 +260,18 @@ func ValidateConfiguration() error {
 	if err := checkAPICConf(); err != nil {
 		return err
 	}
+	if err := checkHealthConf(); err != nil {
+		return err
+	}
+	checkLockConf()
+	if err := checkElectionConf(); err != nil {
+		return err
+	}
+	checkInventoryConf()
+	if err := checkHostCorrelationConf(); err != nil {
+		return err
+	}
+	checkTelemetryConf()
 	if err := checkDBConf(); err != nil {
 		return err
 	}
@@ -380,6 +459,239 @@ func checkAPICConf() error {
 	if Data.APICConf.Password == "" {
 		return fmt.Errorf("no value set for APIC Password")
 	}
+	if Data.APICConf.CacheEnabled == nil {
+		log.Info("no value set for APIC CacheEnabled, setting default value")
+		cacheEnabled := DefaultAPICCacheEnabled
+		Data.APICConf.CacheEnabled = &cacheEnabled
+	}
+	if Data.APICConf.CacheTTLInSeconds < 0 {
+		return fmt.Errorf("APIC CacheTTLInSeconds %d is negative, set CacheEnabled to false for disabling the cache", Data.APICConf.CacheTTLInSeconds)
+	}
+	if Data.APICConf.CacheTTLInSeconds == 0 {
+		log.Info("no value set for APIC CacheTTLInSeconds, setting default value")
+		Data.APICConf.CacheTTLInSeconds = DefaultAPICCacheTTL
+	}
+	checkAPICRetryConf()
+	return nil
+}
+
+// NewAPICRetryConf returns the APICRetryConf with the default values
+func NewAPICRetryConf() *APICRetryConf {
+	return &APICRetryConf{
+		MaxRetries:               DefaultAPICMaxRetries,
+		BaseDelayInMilliSeconds:  DefaultAPICRetryBaseDelay,
+		MaxDelayInMilliSeconds:   DefaultAPICRetryMaxDelay,
+		BreakerFailureThreshold:  DefaultAPICBreakerFailureThreshold,
+		BreakerOpenTimeInSeconds: DefaultAPICBreakerOpenTime,
+	}
+}
+
+func checkAPICRetryConf() {
+	if Data.APICConf.RetryConf == nil {
+		log.Info("no value set for APIC RetryConf, setting default value")
+		Data.APICConf.RetryConf = NewAPICRetryConf()
+		return
+	}
+	retryConf := Data.APICConf.RetryConf
+	if retryConf.MaxRetries <= 0 {
+		log.Info("no value set for APIC MaxRetries, setting default value")
+		retryConf.MaxRetries = DefaultAPICMaxRetries
+	}
+	if retryConf.BaseDelayInMilliSeconds <= 0 {
+		log.Info("no value set for APIC BaseDelayInMilliSeconds, setting default value")
+		retryConf.BaseDelayInMilliSeconds = DefaultAPICRetryBaseDelay
+	}
+	if retryConf.MaxDelayInMilliSeconds < retryConf.BaseDelayInMilliSeconds {
+		log.Info("no valid value set for APIC MaxDelayInMilliSeconds, setting default value")
+		retryConf.MaxDelayInMilliSeconds = DefaultAPICRetryMaxDelay
+		if retryConf.MaxDelayInMilliSeconds < retryConf.BaseDelayInMilliSeconds {
+			retryConf.MaxDelayInMilliSeconds = retryConf.BaseDelayInMilliSeconds
+		}
+	}
+	if retryConf.BreakerFailureThreshold <= 0 {
+		log.Info("no value set for APIC BreakerFailureThreshold, setting default value")
+		retryConf.BreakerFailureThreshold = DefaultAPICBreakerFailureThreshold
+	}
+	if retryConf.BreakerOpenTimeInSeconds <= 0 {
+		log.Info("no value set for APIC BreakerOpenTimeInSeconds, setting default value")
+		retryConf.BreakerOpenTimeInSeconds = DefaultAPICBreakerOpenTime
+	}
+}
+
+// NewHealthConf returns the HealthConf with the default thresholds for all the resource types
+func NewHealthConf() *HealthConf {
+	return &HealthConf{
+		Fabric:  newHealthThresholds(),
+		Switch:  newHealthThresholds(),
+		Chassis: newHealthThresholds(),
+		Port:    newHealthThresholds(),
+	}
+}
+
+func newHealthThresholds() *HealthThresholds {
+	return &HealthThresholds{
+		OKScore:      DefaultOKHealthScore,
+		WarningScore: DefaultWarningHealthScore,
+	}
+}
+
+func checkHealthConf() error {
+	if Data.HealthConf == nil {
+		log.Info("no value set for HealthConf, setting default value")
+		Data.HealthConf = NewHealthConf()
+		return nil
+	}
+	for resourceType, thresholds := range map[string]**HealthThresholds{
+		"Fabric":  &Data.HealthConf.Fabric,
+		"Switch":  &Data.HealthConf.Switch,
+		"Chassis": &Data.HealthConf.Chassis,
+		"Port":    &Data.HealthConf.Port,
+	} {
+		if *thresholds == nil {
+			log.Info("no value set for " + resourceType + " health thresholds, setting default value")
+			*thresholds = newHealthThresholds()
+			continue
+		}
+		if err := (*thresholds).validate(); err != nil {
+			return fmt.Errorf("invalid %s health thresholds: %v", resourceType, err)
+		}
+	}
+	return nil
+}
+
+// NewLockConf returns the LockConf with the default values
+func NewLockConf() *LockConf {
+	return &LockConf{
+		LeaseTimeInSeconds: DefaultLockLeaseTime,
+		WaitTimeInSeconds:  DefaultLockWaitTime,
+	}
+}
+
+func checkLockConf() {
+	if Data.LockConf == nil {
+		log.Info("no value set for LockConf, setting default value")
+		Data.LockConf = NewLockConf()
+		return
+	}
+	if Data.LockConf.LeaseTimeInSeconds <= 0 {
+		log.Info("no value set for LeaseTimeInSeconds, setting default value")
+		Data.LockConf.LeaseTimeInSeconds = DefaultLockLeaseTime
+	}
+	if Data.LockConf.WaitTimeInSeconds <= 0 {
+		log.Info("no value set for WaitTimeInSeconds, setting default value")
+		Data.LockConf.WaitTimeInSeconds = DefaultLockWaitTime
+	}
+}
+
+// NewElectionConf returns the ElectionConf with the default values
+func NewElectionConf() *ElectionConf {
+	return &ElectionConf{
+		LeaseTimeInSeconds:     DefaultLeaderLeaseTime,
+		RenewIntervalInSeconds: DefaultLeaderRenewInterval,
+	}
+}
+
+func checkElectionConf() error {
+	if Data.ElectionConf == nil {
+		log.Info("no value set for ElectionConf, setting default value")
+		Data.ElectionConf = NewElectionConf()
+		return nil
+	}
+	electionConf := Data.ElectionConf
+	if electionConf.LeaseTimeInSeconds <= 0 {
+		log.Info("no value set for leader LeaseTimeInSeconds, setting default value")
+		electionConf.LeaseTimeInSeconds = DefaultLeaderLeaseTime
+	}
+	if electionConf.RenewIntervalInSeconds <= 0 {
+		log.Info("no value set for leader RenewIntervalInSeconds, setting default value")
+		electionConf.RenewIntervalInSeconds = DefaultLeaderRenewInterval
+	}
+	if electionConf.RenewIntervalInSeconds >= electionConf.LeaseTimeInSeconds {
+		return fmt.Errorf("leader RenewIntervalInSeconds %d must be less than LeaseTimeInSeconds %d",
+			electionConf.RenewIntervalInSeconds, electionConf.LeaseTimeInSeconds)
+	}
+	return nil
+}
+
+// NewInventoryConf returns the InventoryConf with the default values
+func NewInventoryConf() *InventoryConf {
+	return &InventoryConf{
+		NodeCheckIntervalInSeconds: DefaultNodeCheckInterval,
+		PurgeGracePeriodInSeconds:  DefaultPurgeGracePeriod,
+	}
+}
+
+func checkInventoryConf() {
+	if Data.InventoryConf == nil {
+		log.Info("no value set for InventoryConf, setting default value")
+		Data.InventoryConf = NewInventoryConf()
+		return
+	}
+	if Data.InventoryConf.NodeCheckIntervalInSeconds <= 0 {
+		log.Info("no value set for NodeCheckIntervalInSeconds, setting default value")
+		Data.InventoryConf.NodeCheckIntervalInSeconds = DefaultNodeCheckInterval
+	}
+	if Data.InventoryConf.PurgeGracePeriodInSeconds <= 0 {
+		log.Info("no value set for PurgeGracePeriodInSeconds, setting default value")
+		Data.InventoryConf.PurgeGracePeriodInSeconds = DefaultPurgeGracePeriod
+	}
+}
+
+// NewHostCorrelationConf returns the HostCorrelationConf with the default values
+func NewHostCorrelationConf() *HostCorrelationConf {
+	return &HostCorrelationConf{
+		Mode:              DefaultHostCorrelationMode,
+		IntervalInSeconds: DefaultHostCorrelationInterval,
+	}
+}
+
+func checkHostCorrelationConf() error {
+	if Data.HostCorrelationConf == nil {
+		log.Info("no value set for HostCorrelationConf, setting default value")
+		Data.HostCorrelationConf = NewHostCorrelationConf()
+		return nil
+	}
+	if Data.HostCorrelationConf.Mode == "" {
+		log.Info("no value set for host correlation Mode, setting default value")
+		Data.HostCorrelationConf.Mode = DefaultHostCorrelationMode
+	}
+	if !AllowedHostCorrelationModes[Data.HostCorrelationConf.Mode] {
+		return fmt.Errorf("invalid value %s configured for host correlation Mode, allowed values are %s and %s",
+			Data.HostCorrelationConf.Mode, HostCorrelationAuto, HostCorrelationSuggest)
+	}
+	if Data.HostCorrelationConf.IntervalInSeconds <= 0 {
+		log.Info("no value set for host correlation IntervalInSeconds, setting default value")
+		Data.HostCorrelationConf.IntervalInSeconds = DefaultHostCorrelationInterval
+	}
+	return nil
+}
+
+// NewTelemetryConf returns the TelemetryConf with the default values
+func NewTelemetryConf() *TelemetryConf {
+	return &TelemetryConf{
+		ReportIntervalInSeconds: DefaultMetricReportInterval,
+	}
+}
+
+func checkTelemetryConf() {
+	if Data.TelemetryConf == nil {
+		log.Info("no value set for TelemetryConf, setting default value")
+		Data.TelemetryConf = NewTelemetryConf()
+		return
+	}
+	if Data.TelemetryConf.ReportIntervalInSeconds <= 0 {
+		log.Info("no value set for telemetry ReportIntervalInSeconds, setting default value")
+		Data.TelemetryConf.ReportIntervalInSeconds = DefaultMetricReportInterval
+	}
+}
+
+func (h *HealthThresholds) validate() error {
+	if h.OKScore <= 0 || h.OKScore > 100 {
+		return fmt.Errorf("OKScore %d is not within 1 and 100", h.OKScore)
+	}
+	if h.WarningScore < 0 || h.WarningScore > h.OKScore {
+		return fmt.Errorf("WarningScore %d is not within 0 and OKScore %d", h.WarningScore, h.OKScore)
+	}
 	return nil
 }
 
@@ -21,8 +21,54 @@ const (
 	DefaultDBPoolSize = 120
 	// DefaultDBMinIdleConns - default MinIdleConns value
 	DefaultDBMinIdleConns = 10
+	// DefaultAPICMaxRetries - default number of retries of a failed APIC call
+	DefaultAPICMaxRetries = 3
+	// DefaultAPICRetryBaseDelay - default delay in milliseconds before the first retry of an APIC call
+	DefaultAPICRetryBaseDelay = 200
+	// DefaultAPICRetryMaxDelay - default upper limit in milliseconds of the delay between the retries
+	DefaultAPICRetryMaxDelay = 5000
+	// DefaultAPICBreakerFailureThreshold - default count of consecutive APIC failures which opens the circuit breaker
+	DefaultAPICBreakerFailureThreshold = 5
+	// DefaultAPICBreakerOpenTime - default time in seconds for which APIC calls are refused once the circuit breaker opens
+	DefaultAPICBreakerOpenTime = 30
+	// DefaultAPICCacheEnabled - the objects read from APIC are cached by default
+	DefaultAPICCacheEnabled = true
+	// DefaultAPICCacheTTL - default time in seconds for which the objects read from APIC are cached
+	DefaultAPICCacheTTL = 60
+	// DefaultOKHealthScore - default lowest ACI health score reported as OK, only the scores above 90 are OK
+	DefaultOKHealthScore = 91
+	// DefaultWarningHealthScore - default lowest ACI health score reported as Warning
+	DefaultWarningHealthScore = 30
+	// DefaultLockLeaseTime - default time in seconds after which a lock not released by its holder expires
+	DefaultLockLeaseTime = 120
+	// DefaultLockWaitTime - default time in seconds a request waits for a lock held by another request
+	DefaultLockWaitTime = 30
+	// DefaultLeaderLeaseTime - default time in seconds after which the leadership of an instance which stopped renewing it expires
+	DefaultLeaderLeaseTime = 15
+	// DefaultLeaderRenewInterval - default interval in seconds at which the leader renews its leadership
+	DefaultLeaderRenewInterval = 5
+	// DefaultNodeCheckInterval - default interval in seconds at which the nodes removed from the fabric are looked for
+	DefaultNodeCheckInterval = 300
+	// DefaultPurgeGracePeriod - default time in seconds the resources of a node removed from the fabric are kept as absent before being deleted
+	DefaultPurgeGracePeriod = 86400
+	// DefaultHostCorrelationInterval - default interval in seconds at which the neighbors of the host-facing ports are matched with the ODIM systems
+	DefaultHostCorrelationInterval = 600
+	// HostCorrelationAuto - the matching ODIM ethernet interfaces are set as the connected ports of the host-facing ports
+	HostCorrelationAuto = "auto"
+	// HostCorrelationSuggest - the matching ODIM ethernet interfaces are only suggested on the host-facing ports
+	HostCorrelationSuggest = "suggest"
+	// DefaultHostCorrelationMode - default mode of the correlation of the host-facing ports with the ODIM systems
+	DefaultHostCorrelationMode = HostCorrelationSuggest
+	// DefaultMetricReportInterval - default interval in seconds at which the metric reports of the fabric counters are sent
+	DefaultMetricReportInterval = 300
 )
 
+// AllowedHostCorrelationModes are the modes of the correlation of the host-facing ports with the ODIM systems
+var AllowedHostCorrelationModes = map[string]bool{
+	HostCorrelationAuto:    true,
+	HostCorrelationSuggest: true,
+}
+
 // AllowedMessageBusTypes is for checking for message types are allowed
 var AllowedMessageBusTypes = map[string]bool{
 	"Kafka": true,
@@ -18,6 +18,10 @@ package constants
 const (
 	// ResourceCreatedMessageID holds the value Resource created MessageID
 	ResourceCreatedMessageID = "ResourceEvent.1.0.3.ResourceCreated"
+	// ResourceRemovedMessageID holds the value Resource removed MessageID
+	ResourceRemovedMessageID = "ResourceEvent.1.0.3.ResourceRemoved"
 	// EventODataType holds the supported version of Event type
 	EventODataType = "#Event.v1_5_0.Event"
+	// AbsentState holds the state of the switches, chassis and ports of a node removed from the fabric
+	AbsentState = "Absent"
 )
@@ -35,4 +35,14 @@ const (
 	TableEndPoint = "ACI-EndPoint"
 	// TableZoneDomain is the table for storing ZoneToDomainDN information
 	TableZoneDomain = "ACI-ZoneDomain"
+	// TableLock is the table for storing the locks serializing fabric modifications
+	TableLock = "ACI-Lock"
+	// TableLeader is the table for storing the plugin instance elected as leader
+	TableLeader = "ACI-Leader"
+	// TableMigration is the table for storing the completed migrations of the stored data
+	TableMigration = "ACI-Migration"
+	// TableController is the table for storing the managers of the APIC controllers
+	TableController = "ACI-Controller"
+	// TableMetricReport is the table for storing the last metric report of each metric report definition
+	TableMetricReport = "ACI-MetricReport"
 )
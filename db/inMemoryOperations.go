@@ -0,0 +1,222 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InMemoryConnector is a DB connector which keeps all the data in memory,
+// it mimics the behaviour of the redis connector and is meant for tests
+type InMemoryConnector struct {
+	mux     *sync.Mutex
+	data    map[string]string
+	expiry  map[string]time.Time
+	keySets map[string]map[string]bool
+}
+
+// NewInMemoryConnector returns an empty in-memory DB connector
+func NewInMemoryConnector() InMemoryConnector {
+	return InMemoryConnector{
+		mux:     &sync.Mutex{},
+		data:    make(map[string]string),
+		expiry:  make(map[string]time.Time),
+		keySets: make(map[string]map[string]bool),
+	}
+}
+
+// Create will create a new entry for the value with the given table and resourceID
+func (d InMemoryConnector) Create(table, resourceID, data string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	key := generateKey(table, resourceID)
+	if _, ok := d.data[key]; ok {
+		return fmt.Errorf(
+			"%w: %s",
+			ErrorKeyAlreadyExist,
+			fmt.Sprintf("An entry with resource id %s is already present in table %s", resourceID, table),
+		)
+	}
+	d.data[key] = data
+	return nil
+}
+
+// Update will update an entry with the value for the given table and resourceID
+func (d InMemoryConnector) Update(table, resourceID, data string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	key := generateKey(table, resourceID)
+	// like redis SET, overwriting a key drops its expiry
+	d.data[key] = data
+	delete(d.expiry, key)
+	return nil
+}
+
+// CompareAndSwap will update an entry with newData only if it still holds oldData
+func (d InMemoryConnector) CompareAndSwap(table, resourceID, oldData, newData string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	key := generateKey(table, resourceID)
+	val, ok := d.data[key]
+	switch {
+	case !ok:
+		return fmt.Errorf(
+			"%w: %s",
+			ErrorKeyNotFound,
+			fmt.Sprintf("Data with resource ID %s not found in table %s", resourceID, table),
+		)
+	case val != oldData:
+		return fmt.Errorf(
+			"%w: %s",
+			ErrorDataChanged,
+			fmt.Sprintf("Data with resource ID %s in table %s was updated by another request", resourceID, table),
+		)
+	}
+	d.data[key] = newData
+	return nil
+}
+
+// GetAllMatchingKeys will collect all the keys of provided table and pattern
+func (d InMemoryConnector) GetAllMatchingKeys(table, pattern string) ([]string, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	var allKeys []string
+	prefix := generateKey(table, pattern)
+	for key := range d.data {
+		if strings.HasPrefix(key, prefix) {
+			allKeys = append(allKeys, key)
+		}
+	}
+	sort.Strings(allKeys)
+	return trimTableFromKeys(table, allKeys), nil
+}
+
+// Get will collect the data associated with the given key from the given table
+func (d InMemoryConnector) Get(table, resourceID string) (string, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	val, ok := d.data[generateKey(table, resourceID)]
+	if !ok {
+		return "", fmt.Errorf(
+			"%w: %s",
+			ErrorKeyNotFound,
+			fmt.Sprintf("Data with resource ID %s not found in table %s", resourceID, table),
+		)
+	}
+	return val, nil
+}
+
+// UpdateKeySet will add passed member to the particular key set
+func (d InMemoryConnector) UpdateKeySet(key string, member string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if _, ok := d.keySets[key]; !ok {
+		d.keySets[key] = make(map[string]bool)
+	}
+	d.keySets[key][member] = true
+	return nil
+}
+
+// GetKeySetMembers will get the list of member in the particular key set
+func (d InMemoryConnector) GetKeySetMembers(key string) ([]string, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	list := []string{}
+	for member := range d.keySets[key] {
+		list = append(list, member)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// Delete will delete the data associated with the given key from the given table
+func (d InMemoryConnector) Delete(table, resourceID string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	// like redis DEL, removing a key which doesn't exist is not an error
+	key := generateKey(table, resourceID)
+	delete(d.data, key)
+	delete(d.expiry, key)
+	return nil
+}
+
+// DeleteKeySetMembers will delete the member from the particular key set
+func (d InMemoryConnector) DeleteKeySetMembers(key string, member string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	delete(d.keySets[key], member)
+	return nil
+}
+
+// AcquireLock will create the lock entry with the given data if nobody holds it,
+// the entry expires after the given duration
+func (d InMemoryConnector) AcquireLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	key := generateKey(table, resourceID)
+	if _, ok := d.data[key]; ok {
+		return false, nil
+	}
+	d.data[key] = data
+	d.expiry[key] = time.Now().Add(expiry)
+	return true, nil
+}
+
+// ReleaseLock will delete the lock entry if it still holds the given data
+func (d InMemoryConnector) ReleaseLock(table, resourceID, data string) (bool, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	key := generateKey(table, resourceID)
+	if val, ok := d.data[key]; !ok || val != data {
+		return false, nil
+	}
+	delete(d.data, key)
+	delete(d.expiry, key)
+	return true, nil
+}
+
+// RefreshLock will reset the expiry of the lock entry to the given duration if it still holds the given data
+func (d InMemoryConnector) RefreshLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.removeExpired()
+	key := generateKey(table, resourceID)
+	if val, ok := d.data[key]; !ok || val != data {
+		return false, nil
+	}
+	d.expiry[key] = time.Now().Add(expiry)
+	return true, nil
+}
+
+// removeExpired drops the entries whose expiry has passed, the caller must hold the mutex
+func (d InMemoryConnector) removeExpired() {
+	now := time.Now()
+	for key, expiry := range d.expiry {
+		if now.After(expiry) {
+			delete(d.data, key)
+			delete(d.expiry, key)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package db
 
 import (
 	"fmt"
+	"time"
 )
 
 // MockConnector is for mocking DB connector interface
@@ -31,6 +32,11 @@ func (d MockConnector) Update(table, resourceID, data string) error {
 	return nil
 }
 
+// CompareAndSwap is for mocking DB compare and swap operation
+func (d MockConnector) CompareAndSwap(table, resourceID, oldData, newData string) error {
+	return nil
+}
+
 // GetAllMatchingKeys is for mocking GetAllMatchingKeys operation
 func (d MockConnector) GetAllMatchingKeys(table, pattern string) ([]string, error) {
 	return []string{"validID"}, nil
@@ -75,3 +81,18 @@ func (d MockConnector) Delete(table, resourceID string) (err error) {
 func (d MockConnector) DeleteKeySetMembers(key string, member string) (err error) {
 	return nil
 }
+
+// AcquireLock is for mocking DB lock acquisition, the lock is always granted
+func (d MockConnector) AcquireLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	return true, nil
+}
+
+// ReleaseLock is for mocking DB lock release
+func (d MockConnector) ReleaseLock(table, resourceID, data string) (bool, error) {
+	return true, nil
+}
+
+// RefreshLock is for mocking DB lock refresh
+func (d MockConnector) RefreshLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	return true, nil
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-redis/redis"
 )
@@ -30,17 +31,23 @@ var (
 	ErrorKeyAlreadyExist = errors.New("Key already exist in DB")
 	// ErrorKeyNotFound is for identifing not found error
 	ErrorKeyNotFound = errors.New("Key not Found in DB")
+	// ErrorDataChanged is for identifing writes which lost the race with another writer
+	ErrorDataChanged = errors.New("Data changed in DB")
 )
 
 type dbCalls interface {
 	Create(table, resourceID, data string) (err error)
 	Update(table, resourceID, data string) (err error)
+	CompareAndSwap(table, resourceID, oldData, newData string) (err error)
 	GetAllMatchingKeys(table, pattern string) ([]string, error)
 	Get(table, resourceID string) (string, error)
 	UpdateKeySet(key string, member string) (err error)
 	GetKeySetMembers(key string) (list []string, err error)
 	Delete(table, resourceID string) (err error)
 	DeleteKeySetMembers(key string, member string) (err error)
+	AcquireLock(table, resourceID, data string, expiry time.Duration) (bool, error)
+	ReleaseLock(table, resourceID, data string) (bool, error)
+	RefreshLock(table, resourceID, data string, expiry time.Duration) (bool, error)
 }
 
 // Connector is the interface which connects the DB functions
@@ -88,6 +95,111 @@ func (d connector) Update(table, resourceID, data string) (err error) {
 	return nil
 }
 
+// CompareAndSwap will update an entry in DB with newData only if it still holds oldData,
+// the key is watched so that a concurrent write between the check and the update fails the swap
+func (d connector) CompareAndSwap(table, resourceID, oldData, newData string) (err error) {
+	c, err := getClient()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrorServiceUnavailable, err)
+	}
+	key := generateKey(table, resourceID)
+	err = c.pool.Watch(func(tx *redis.Tx) error {
+		val, err := tx.Get(key).Result()
+		switch {
+		case err == redis.Nil:
+			return fmt.Errorf(
+				"%w: %s",
+				ErrorKeyNotFound,
+				fmt.Sprintf("Data with resource ID %s not found in table %s", resourceID, table),
+			)
+		case err != nil:
+			return err
+		case val != oldData:
+			return redis.TxFailedErr
+		}
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, newData, 0)
+			return nil
+		})
+		return err
+	}, key)
+	switch {
+	case err == redis.TxFailedErr:
+		return fmt.Errorf(
+			"%w: %s",
+			ErrorDataChanged,
+			fmt.Sprintf("Data with resource ID %s in table %s was updated by another request", resourceID, table),
+		)
+	case errors.Is(err, ErrorKeyNotFound):
+		return err
+	case err != nil:
+		return fmt.Errorf(
+			"Updating entry for resource id %s in table %s failed: %v",
+			resourceID, table, err,
+		)
+	}
+	return nil
+}
+
+// releaseLockScript deletes the lock only if it is still held with the data it was acquired with,
+// so that a lock which expired and was taken over by another request is left alone
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// refreshLockScript extends the expiry of the lock only if it is still held with the data it was acquired with
+var refreshLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// AcquireLock will create the lock entry with the given data if nobody holds it,
+// the entry expires after the given duration so that a crashed holder can't keep it forever
+func (d connector) AcquireLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	c, err := getClient()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorServiceUnavailable, err)
+	}
+	ok, err := c.pool.SetNX(generateKey(table, resourceID), data, expiry).Result()
+	if err != nil {
+		return false, fmt.Errorf("Acquiring lock %s in table %s failed: %v", resourceID, table, err)
+	}
+	return ok, nil
+}
+
+// ReleaseLock will delete the lock entry if it still holds the given data,
+// it returns false when the lock had already expired or was taken over
+func (d connector) ReleaseLock(table, resourceID, data string) (bool, error) {
+	c, err := getClient()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorServiceUnavailable, err)
+	}
+	deleted, err := releaseLockScript.Run(c.pool, []string{generateKey(table, resourceID)}, data).Int64()
+	if err != nil {
+		return false, fmt.Errorf("Releasing lock %s in table %s failed: %v", resourceID, table, err)
+	}
+	return deleted == 1, nil
+}
+
+// RefreshLock will reset the expiry of the lock entry to the given duration if it still holds the given data,
+// it returns false when the lock had already expired or was taken over
+func (d connector) RefreshLock(table, resourceID, data string, expiry time.Duration) (bool, error) {
+	c, err := getClient()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrorServiceUnavailable, err)
+	}
+	refreshed, err := refreshLockScript.Run(c.pool, []string{generateKey(table, resourceID)}, data, expiry.Milliseconds()).Int64()
+	if err != nil {
+		return false, fmt.Errorf("Refreshing lock %s in table %s failed: %v", resourceID, table, err)
+	}
+	return refreshed == 1, nil
+}
+
 // GetAllMatchingKeys will collect all the keys of provided table and pattern
 func (d connector) GetAllMatchingKeys(table, pattern string) ([]string, error) {
 	var allKeys []string
@@ -0,0 +1,273 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+var getODIMEthernetInterfaces = caputilities.GetODIMEthernetInterfaces
+
+func getHostCorrelationConf() *config.HostCorrelationConf {
+	if config.Data.HostCorrelationConf == nil {
+		return config.NewHostCorrelationConf()
+	}
+	return config.Data.HostCorrelationConf
+}
+
+// watchHostPorts matches the neighbors of the host-facing ports with the ODIM systems
+// at the configured interval until lost is closed
+func watchHostPorts(lost <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(getHostCorrelationConf().IntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := correlateHostPorts(); err != nil {
+			log.Error("failed to match the host-facing ports with the ODIM systems: " + err.Error())
+		}
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// correlateHostPorts reads the LLDP and CDP neighbors of the leaf ports and looks for the ethernet interfaces
+// of the ODIM systems having their MAC address, or their host name and interface name when the neighbor
+// advertises no MAC address. The neighbors and the matching interfaces are reported in the Oem of the ports,
+// in auto mode the single matching interface is also set as the connected port of the ports having none
+func correlateHostPorts() error {
+	nodePorts, err := getFabricNodePorts()
+	if err != nil {
+		return err
+	}
+	lldpAdjacencies, err := caputilities.GetLLDPAdjacencies()
+	if err != nil {
+		return err
+	}
+	cdpAdjacencies, err := caputilities.GetCDPAdjacencies()
+	if err != nil {
+		return err
+	}
+	// the neighbors which are fabric nodes are the fabric links
+	neighbors := make(map[string][]capmodel.PortNeighbor)
+	for _, adjacency := range lldpAdjacencies {
+		if _, ok := nodePorts.nodesByName[adjacency.SysName]; ok {
+			continue
+		}
+		_, nodeID, portName := adjacency.LocalPort()
+		if uri := nodePorts.portURI(nodeID, portName); uri != "" {
+			neighbors[uri] = append(neighbors[uri], capmodel.PortNeighbor{
+				Protocol:   capmodel.NeighborProtocolLLDP,
+				ChassisID:  adjacency.ChassisIDV,
+				PortID:     adjacency.PortIDV,
+				SystemName: adjacency.SysName,
+			})
+		}
+	}
+	for _, adjacency := range cdpAdjacencies {
+		if _, ok := nodePorts.nodesByName[adjacency.SystemName()]; ok {
+			continue
+		}
+		_, nodeID, portName := adjacency.LocalPort()
+		if uri := nodePorts.portURI(nodeID, portName); uri != "" {
+			neighbors[uri] = append(neighbors[uri], capmodel.PortNeighbor{
+				Protocol:   capmodel.NeighborProtocolCDP,
+				PortID:     adjacency.PortID,
+				SystemName: adjacency.SystemName(),
+			})
+		}
+	}
+
+	ethernetInterfaces, err := getODIMEthernetInterfaces()
+	if err != nil {
+		return fmt.Errorf("unable to read the ethernet interfaces from ODIM: %v", err)
+	}
+	matcher := newHostInterfaceMatcher(ethernetInterfaces)
+
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		return err
+	}
+	autoConnect := getHostCorrelationConf().Mode == config.HostCorrelationAuto
+	for fabricID, fabricData := range allFabric {
+		for _, switchID := range fabricData.SwitchData {
+			if _, absent := fabricData.AbsentSwitches[switchID]; absent {
+				continue
+			}
+			if err := correlateSwitchPorts(fabricID, switchID, neighbors, matcher, autoConnect); err != nil {
+				return fmt.Errorf("matching the ports of switch %s failed: %v", switchID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// correlateSwitchPorts updates the ports of the switch whose neighbors or matching interfaces changed,
+// the servers are attached to the leaves only
+func correlateSwitchPorts(fabricID, switchID string, neighbors map[string][]capmodel.PortNeighbor, matcher *hostInterfaceMatcher, autoConnect bool) error {
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		return err
+	}
+	if capmodel.GetSwitchRole(&switchData) == capmodel.NodeRoleSpine {
+		return nil
+	}
+	portIDs, err := capmodel.GetSwitchPort(switchID)
+	if err != nil {
+		return err
+	}
+	switchURI := "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + switchID
+	for _, portID := range portIDs {
+		uri := switchURI + "/Ports/" + portID
+		var oem capmodel.CiscoPortOem
+		oem.Neighbors = neighbors[uri]
+		for _, interfaceURI := range matcher.match(oem.Neighbors) {
+			oem.SuggestedConnectedPorts = append(oem.SuggestedConnectedPorts, dmtfmodel.Link{Oid: interfaceURI})
+		}
+		port, err := capmodel.GetPort(uri)
+		if err != nil {
+			return err
+		}
+		connect := autoConnect && len(oem.SuggestedConnectedPorts) == 1
+		if connect && port.Links != nil && len(port.Links.ConnectedPorts) > 0 {
+			// the connected port set by the user is kept
+			if port.Links.ConnectedPorts[0].Oid != oem.SuggestedConnectedPorts[0].Oid {
+				log.Warn(fmt.Sprintf("port %s is connected to %s while %s is seen on it", uri,
+					port.Links.ConnectedPorts[0].Oid, oem.SuggestedConnectedPorts[0].Oid))
+			}
+			connect = false
+		}
+		storedOem := capmodel.GetPortOem(port).Cisco
+		if storedOem == nil {
+			storedOem = &capmodel.CiscoPortOem{}
+		}
+		if !connect && reflect.DeepEqual(*storedOem, oem) {
+			continue
+		}
+		err = capmodel.ModifyPort(uri, func(port *dmtfmodel.Port) {
+			port.Oem = nil
+			if len(oem.Neighbors) > 0 {
+				port.Oem = &capmodel.PortOem{Cisco: &oem}
+			}
+			if connect {
+				log.Info(fmt.Sprintf("port %s is connected to %s seen on it", uri, oem.SuggestedConnectedPorts[0].Oid))
+				if port.Links == nil {
+					port.Links = &dmtfmodel.PortLinks{}
+				}
+				port.Links.ConnectedPorts = []dmtfmodel.Link{oem.SuggestedConnectedPorts[0]}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostInterfaceMatcher finds the ODIM ethernet interfaces of the neighbors of the ports
+type hostInterfaceMatcher struct {
+	byMAC map[string][]string
+	// hostsByMAC holds the host names of the systems by the MAC addresses of their interfaces
+	hostsByMAC map[string][]string
+	// byHostPort holds the interfaces by the host name of their system and their id or name
+	byHostPort map[string][]string
+}
+
+func newHostInterfaceMatcher(ethernetInterfaces []capmodel.ODIMEthernetInterface) *hostInterfaceMatcher {
+	matcher := &hostInterfaceMatcher{
+		byMAC:      make(map[string][]string),
+		hostsByMAC: make(map[string][]string),
+		byHostPort: make(map[string][]string),
+	}
+	for _, ethernetInterface := range ethernetInterfaces {
+		for _, mac := range []string{ethernetInterface.MACAddress, ethernetInterface.PermanentMACAddress} {
+			if mac = normalizeMAC(mac); mac != "" {
+				matcher.byMAC[mac] = append(matcher.byMAC[mac], ethernetInterface.URI)
+				if ethernetInterface.HostName != "" {
+					matcher.hostsByMAC[mac] = append(matcher.hostsByMAC[mac], ethernetInterface.HostName)
+				}
+			}
+		}
+		if ethernetInterface.HostName == "" {
+			continue
+		}
+		for _, name := range []string{ethernetInterface.ID, ethernetInterface.Name} {
+			if name != "" {
+				key := hostPortKey(ethernetInterface.HostName, name)
+				matcher.byHostPort[key] = append(matcher.byHostPort[key], ethernetInterface.URI)
+			}
+		}
+	}
+	return matcher
+}
+
+// match returns the sorted uris of the interfaces of the neighbors
+func (m *hostInterfaceMatcher) match(neighbors []capmodel.PortNeighbor) []string {
+	matches := make(map[string]bool)
+	for _, neighbor := range neighbors {
+		var uris []string
+		// LLDP may give the MAC address of the interface as port id, the chassis id is the MAC address
+		// of any interface of the system so it only tells the system when the system name isn't given
+		if mac := normalizeMAC(neighbor.PortID); mac != "" {
+			uris = m.byMAC[mac]
+		}
+		if len(uris) == 0 && neighbor.PortID != "" {
+			hostNames := []string{neighbor.SystemName}
+			if neighbor.SystemName == "" {
+				hostNames = m.hostsByMAC[normalizeMAC(neighbor.ChassisID)]
+			}
+			for _, hostName := range hostNames {
+				uris = append(uris, m.byHostPort[hostPortKey(hostName, neighbor.PortID)]...)
+			}
+		}
+		for _, uri := range uris {
+			matches[uri] = true
+		}
+	}
+	var uris []string
+	for uri := range matches {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+func hostPortKey(hostName, portName string) string {
+	return strings.ToLower(hostName) + "/" + strings.ToLower(portName)
+}
+
+// normalizeMAC returns the MAC address as 12 lower case hex digits, it is empty when mac is not a MAC address
+func normalizeMAC(mac string) string {
+	normalized := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+	if len(normalized) != 12 {
+		return ""
+	}
+	for _, c := range normalized {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return ""
+		}
+	}
+	return normalized
+}
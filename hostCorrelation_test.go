@@ -0,0 +1,147 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	for mac, want := range map[string]string{
+		"00:50:56:AA:BB:01": "005056aabb01",
+		"00-50-56-aa-bb-01": "005056aabb01",
+		"0050.56aa.bb01":    "005056aabb01",
+		"ens1f0":            "",
+		"00:50:56:aa:bb":    "",
+		"server1.lab":       "",
+	} {
+		if got := normalizeMAC(mac); got != want {
+			t.Errorf("normalizeMAC(%q) = %q, want %q", mac, got, want)
+		}
+	}
+}
+
+func TestCorrelateHostPorts(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+	defer func() {
+		getODIMEthernetInterfaces = caputilities.GetODIMEthernetInterfaces
+	}()
+	getODIMEthernetInterfaces = func() ([]capmodel.ODIMEthernetInterface, error) {
+		return []capmodel.ODIMEthernetInterface{
+			{URI: "/ODIM/v1/Systems/s1.1/EthernetInterfaces/1", ID: "1", Name: "ens1f0", MACAddress: "00-50-56-AA-BB-01", HostName: "server1"},
+			{URI: "/ODIM/v1/Systems/s2.1/EthernetInterfaces/1", ID: "1", Name: "eno1", MACAddress: "00:50:56:aa:bb:02", HostName: "server2.example.com"},
+		}, nil
+	}
+
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	portURI := func(nodeID, portName string) string {
+		return fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL"+nodeID, nodeID) + "/Ports/" + capmodel.PortID("1", nodeID, portName)
+	}
+	// server1 advertises its MAC address with LLDP, server2 only its name with CDP, the other leaf isn't a host
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-101/sys/lldp/inst/if-[eth1/1]/adj-1", "sysName": "server1", "chassisIdV": "00:50:56:aa:bb:01", "portIdV": "ens1f0",
+	})
+	apic.AddObject("cdpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-102/sys/cdp/inst/if-[eth1/2]/adj-1", "devId": "SERVER2.example.com", "portId": "eno1",
+	})
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-101/sys/lldp/inst/if-[eth1/2]/adj-1", "sysName": "leaf-102", "portIdV": "Eth1/2",
+	})
+
+	config.Data.HostCorrelationConf = &config.HostCorrelationConf{Mode: config.HostCorrelationSuggest, IntervalInSeconds: 600}
+	if err := correlateHostPorts(); err != nil {
+		t.Fatalf("correlateHostPorts failed: %v", err)
+	}
+	port, err := capmodel.GetPort(portURI("101", "eth1/1"))
+	if err != nil {
+		t.Fatalf("port is not stored: %v", err)
+	}
+	oem := capmodel.GetPortOem(port).Cisco
+	wantNeighbor := capmodel.PortNeighbor{Protocol: "LLDP", ChassisID: "00:50:56:aa:bb:01", PortID: "ens1f0", SystemName: "server1"}
+	if oem == nil || len(oem.Neighbors) != 1 || oem.Neighbors[0] != wantNeighbor ||
+		len(oem.SuggestedConnectedPorts) != 1 || oem.SuggestedConnectedPorts[0].Oid != "/ODIM/v1/Systems/s1.1/EthernetInterfaces/1" {
+		t.Errorf("unexpected Oem %+v of the port of server1", oem)
+	}
+	if port.Links != nil && len(port.Links.ConnectedPorts) != 0 {
+		t.Errorf("suggested interface is set as the connected port: %+v", port.Links.ConnectedPorts)
+	}
+	if port, _ = capmodel.GetPort(portURI("101", "eth1/2")); port.Oem != nil {
+		t.Errorf("the neighbors which are fabric nodes are reported: %+v", port.Oem)
+	}
+
+	config.Data.HostCorrelationConf.Mode = config.HostCorrelationAuto
+	if err := correlateHostPorts(); err != nil {
+		t.Fatalf("correlateHostPorts failed: %v", err)
+	}
+	for uri, want := range map[string]string{
+		portURI("101", "eth1/1"): "/ODIM/v1/Systems/s1.1/EthernetInterfaces/1",
+		portURI("102", "eth1/2"): "/ODIM/v1/Systems/s2.1/EthernetInterfaces/1",
+	} {
+		port, err := capmodel.GetPort(uri)
+		if err != nil {
+			t.Fatalf("port %s is not stored: %v", uri, err)
+		}
+		if port.Links == nil || len(port.Links.ConnectedPorts) != 1 || port.Links.ConnectedPorts[0].Oid != want {
+			t.Errorf("port %s: unexpected links %+v, want connected port %s", uri, port.Links, want)
+		}
+	}
+}
+
+func TestCorrelateHostPortsSharingChassisID(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+	defer func() {
+		getODIMEthernetInterfaces = caputilities.GetODIMEthernetInterfaces
+	}()
+	getODIMEthernetInterfaces = func() ([]capmodel.ODIMEthernetInterface, error) {
+		return []capmodel.ODIMEthernetInterface{
+			{URI: "/ODIM/v1/Systems/s3.1/EthernetInterfaces/1", ID: "1", Name: "ens1f0", MACAddress: "00:50:56:aa:bb:31", HostName: "server3"},
+			{URI: "/ODIM/v1/Systems/s3.1/EthernetInterfaces/2", ID: "2", Name: "ens1f1", MACAddress: "00:50:56:aa:bb:32", HostName: "server3"},
+		}, nil
+	}
+
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	portURI := func(nodeID, portName string) string {
+		return fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL"+nodeID, nodeID) + "/Ports/" + capmodel.PortID("1", nodeID, portName)
+	}
+	// both the interfaces of server3 advertise the same chassis id, the port id is the name of one and the MAC address of the other
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-101/sys/lldp/inst/if-[eth1/1]/adj-1", "chassisIdV": "00:50:56:aa:bb:31", "portIdV": "ens1f0",
+	})
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-102/sys/lldp/inst/if-[eth1/1]/adj-1", "chassisIdV": "00:50:56:aa:bb:31", "portIdV": "00:50:56:aa:bb:32",
+	})
+
+	config.Data.HostCorrelationConf = &config.HostCorrelationConf{Mode: config.HostCorrelationSuggest, IntervalInSeconds: 600}
+	if err := correlateHostPorts(); err != nil {
+		t.Fatalf("correlateHostPorts failed: %v", err)
+	}
+	for uri, want := range map[string]string{
+		portURI("101", "eth1/1"): "/ODIM/v1/Systems/s3.1/EthernetInterfaces/1",
+		portURI("102", "eth1/1"): "/ODIM/v1/Systems/s3.1/EthernetInterfaces/2",
+	} {
+		port, err := capmodel.GetPort(uri)
+		if err != nil {
+			t.Fatalf("port %s is not stored: %v", uri, err)
+		}
+		oem := capmodel.GetPortOem(port).Cisco
+		if oem == nil || len(oem.SuggestedConnectedPorts) != 1 || oem.SuggestedConnectedPorts[0].Oid != want {
+			t.Errorf("port %s: unexpected Oem %+v, want suggested port %s", uri, oem, want)
+		}
+	}
+}
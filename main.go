@@ -39,7 +39,6 @@ import (
 
 	"github.com/ciscoecosystem/aci-go-client/models"
 	iris "github.com/kataras/iris/v12"
-	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -78,7 +77,10 @@ func main() {
 	// which is passed to it as Publish method after reading the data from the channel.
 	go common.RunReadWorkers(caphandler.Out, capmessagebus.Publish, 1)
 
-	intializeACIData()
+	// every instance drops its own cached APIC objects as soon as APIC notifies their changes
+	if caputilities.APICCacheEnabled() {
+		go caputilities.WatchAPICChanges(nil)
+	}
 
 	configFilePath := os.Getenv("PLUGIN_CONFIG_FILE_PATH")
 	if configFilePath == "" {
@@ -134,7 +136,7 @@ func routers() *iris.Application {
 	pluginRoutes.Get("/Chassis/{id}", capmiddleware.BasicAuth, caphandler.GetChassis)
 	pluginRoutes.Patch("/Chassis/{id}", capmiddleware.BasicAuth, caphandler.ChassisMethodNotAllowed)
 	pluginRoutes.Delete("/Chassis/{id}", capmiddleware.BasicAuth, caphandler.ChassisMethodNotAllowed)
-	fabricRoutes := pluginRoutes.Party("/Fabrics", capmiddleware.BasicAuth)
+	fabricRoutes := pluginRoutes.Party("/Fabrics", capmiddleware.BasicAuth, capmiddleware.APICAvailability, capmiddleware.FabricLock)
 	fabricRoutes.Get("/", caphandler.GetFabricResource)
 	fabricRoutes.Get("/{id}", caphandler.GetFabricData)
 	fabricRoutes.Get("/{id}/Switches", caphandler.GetSwitchCollection)
@@ -142,6 +144,7 @@ func routers() *iris.Application {
 	fabricRoutes.Get("/{id}/Switches/{switchID}/Ports", caphandler.GetPortCollection)
 	fabricRoutes.Get("/{id}/Switches/{switchID}/Ports/{portID}", caphandler.GetPortInfo)
 	fabricRoutes.Patch("/{id}/Switches/{switchID}/Ports/{portID}", caphandler.PatchPort)
+	fabricRoutes.Get("/{id}/Switches/{switchID}/Ports/{portID}/Metrics", caphandler.GetPortMetrics)
 	fabricRoutes.Get("/{id}/Zones", caphandler.GetZones)
 	fabricRoutes.Post("/{id}/Zones", caphandler.CreateZone)
 	fabricRoutes.Get("/{id}/Zones/{rid}", caphandler.GetZone)
@@ -154,11 +157,18 @@ func routers() *iris.Application {
 	fabricRoutes.Get("/{id}/Endpoints", caphandler.GetEndpointCollection)
 	fabricRoutes.Post("/{id}/Endpoints", caphandler.CreateEndpoint)
 	fabricRoutes.Get("/{id}/Endpoints/{rid}", caphandler.GetEndpointInfo)
+	fabricRoutes.Get("/{id}/Oem/Topology", caphandler.GetFabricTopology)
 	fabricRoutes.Delete("/{id}/Endpoints/{rid}", caphandler.DeleteEndpointInfo)
 
-	managers := pluginRoutes.Party("/Managers")
+	managers := pluginRoutes.Party("/Managers", capmiddleware.BasicAuth)
 	managers.Get("/", caphandler.GetManagersCollection)
 	managers.Get("/{id}", caphandler.GetManagersInfo)
+	telemetry := pluginRoutes.Party("/TelemetryService", capmiddleware.BasicAuth)
+	telemetry.Get("/", caphandler.GetTelemetryService)
+	telemetry.Get("/MetricReportDefinitions", caphandler.GetMetricReportDefinitionCollection)
+	telemetry.Get("/MetricReportDefinitions/{id}", caphandler.GetMetricReportDefinition)
+	telemetry.Get("/MetricReports", caphandler.GetMetricReportCollection)
+	telemetry.Get("/MetricReports/{id}", caphandler.GetMetricReport)
 	taskmon := pluginRoutes.Party("/taskmon")
 	taskmon.Get("/{TaskID}", caphandler.GetTaskMonitor)
 
@@ -194,17 +204,78 @@ func eventsrouters() {
 func intializePluginStatus() {
 	caputilities.Status.Available = "yes"
 
-	go sendStartupEvent()
+	// only the leader among the plugin instances discovers the inventory and sends the startup event,
+	// the others serve the requests from the stored data and take over when the leader goes away
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		caputilities.RunLeaderElection(stop, runLeaderTasks)
+		close(stopped)
+	}()
+	// the leadership is resigned on shutdown, so that another instance takes over without waiting for the lease to expire
+	iris.RegisterOnInterrupt(func() {
+		close(stop)
+		<-stopped
+	})
+}
+
+// runLeaderTasks runs the background tasks of the plugin instance elected as leader
+func runLeaderTasks(lost <-chan struct{}) {
+	// the ACI data must not be stored under the new IDs before the records stored under the old ones are migrated
+	if !retryLeaderTask(lost, "migrate the resource IDs", migrateResourceIDs) {
+		return
+	}
+	if !retryLeaderTask(lost, "initialize the ACI data", intializeACIData) {
+		return
+	}
+	select {
+	case <-lost:
+		return
+	default:
+	}
+	sendStartupEvent()
+	go watchFabricLinks(lost)
+	go watchHostPorts(lost)
+	go watchMetricReports(lost)
+	watchRemovedNodes(lost)
 }
 
-// intializeACIData reads required fabric,switch and port data from aci and stored it in the data store
-func intializeACIData() {
+// retryLeaderTask runs the task until it succeeds, a failed task is run again after migrationRetryInterval.
+// It returns false when the leadership is lost before the task succeeds
+func retryLeaderTask(lost <-chan struct{}, description string, task func() error) bool {
+	for {
+		err := task()
+		if err == nil {
+			return true
+		}
+		log.Error("failed to " + description + ", retrying: " + err.Error())
+		select {
+		case <-lost:
+			return false
+		case <-time.After(migrationRetryInterval):
+		}
+	}
+}
+
+// intializeACIData reads required fabric,switch and port data from aci and stored it in the data store,
+// the data stored before a failure is kept and completed when it is run again
+func intializeACIData() error {
 	aciNodesData, err := caputilities.GetFabricNodeData()
 	if err != nil {
-		log.Fatal("while intializing ACI Data  PluginCiscoACI got: " + err.Error())
+		return fmt.Errorf("while intializing ACI Data  PluginCiscoACI got: %v", err)
+	}
+	// fabricNode tells the role of the registered nodes and lists the controllers
+	fabricNodes, err := caputilities.GetFabricNodes()
+	if err != nil {
+		return fmt.Errorf("while intializing ACI fabric node Data  PluginCiscoACI got: %v", err)
+	}
+	nodesByDN := make(map[string]capmodel.FabricNodeAttributes, len(fabricNodes))
+	for _, fabricNode := range fabricNodes {
+		nodesByDN[fabricNode.Attributes.DN] = fabricNode.Attributes
 	}
 	for _, aciNodeData := range aciNodesData {
-		switchID := uuid.NewV4().String() + ":" + aciNodeData.NodeId
+		switchID := capmodel.SwitchID(aciNodeData.PodId, aciNodeData.Serial, aciNodeData.NodeId)
+		switchOem := getSwitchOem(aciNodeData, nodesByDN[fmt.Sprintf("topology/pod-%s/node-%s", aciNodeData.PodId, aciNodeData.NodeId)])
 		fabricID := config.Data.RootServiceUUID + ":" + aciNodeData.FabricId
 		fabricExists := true
 		fabricData, err := capmodel.GetFabric(fabricID)
@@ -216,79 +287,195 @@ func intializeACIData() {
 						switchID,
 					},
 					PodID: aciNodeData.PodId,
+					SwitchPods: map[string]string{
+						switchID: aciNodeData.PodId,
+					},
 				}
 				if err := capmodel.SaveFabric(fabricID, data); err != nil {
-					log.Fatal("storing " + fabricID + " fabric failed with " + err.Error())
+					return fmt.Errorf("storing %s fabric failed with %v", fabricID, err)
 				}
 			} else {
-				log.Fatal("fetching " + fabricID + " fabric failed with " + err.Error())
+				return fmt.Errorf("fetching %s fabric failed with %v", fabricID, err)
 			}
 		}
-		if !checkSwitchIDExists(fabricData.SwitchData, aciNodeData.NodeId) {
-			if fabricExists {
+		switchExists := checkSwitchIDExists(fabricData.SwitchData, switchID)
+		// the nodes of a multi-pod fabric are spread across the pods, each switch keeps its own
+		if fabricExists && (!switchExists || fabricData.SwitchPods[switchID] != aciNodeData.PodId) {
+			if !switchExists {
 				fabricData.SwitchData = append(fabricData.SwitchData, switchID)
-				fabricData.PodID = aciNodeData.PodId
-				if err := capmodel.UpdateFabric(fabricID, &fabricData); err != nil {
-					log.Fatal("updating " + fabricID + " fabric failed with " + err.Error())
-				}
 			}
-			switchData, chassisData := getSwitchData(fabricID, aciNodeData, switchID)
-			if err := capmodel.SaveSwitchChassis(chassisData.ID, chassisData); err != nil {
-				log.Fatal("storing " + chassisData.ID + " chassis failed with " + err.Error())
+			if fabricData.SwitchPods == nil {
+				fabricData.SwitchPods = make(map[string]string)
+			}
+			fabricData.SwitchPods[switchID] = aciNodeData.PodId
+			if err := capmodel.UpdateFabric(fabricID, &fabricData); err != nil {
+				return fmt.Errorf("updating %s fabric failed with %v", fabricID, err)
 			}
-			if err := capmodel.SaveSwitch(switchID, switchData); err != nil {
-				log.Fatal("storing " + switchID + " switch failed with " + err.Error())
+		}
+		// the switch is stored after its chassis and ports, a switch whose discovery failed is discovered again
+		_, err = capmodel.GetSwitch(switchID)
+		switch {
+		case err == nil:
+			if err := updateSwitchOem(switchID, switchOem); err != nil {
+				return err
 			}
-			// adding logic to collect the ports data
-			portData, err := caputilities.GetPortData(aciNodeData.PodId, aciNodeData.NodeId)
-			if err != nil {
-				log.Fatal("while intializing ACI Port  Data  PluginCiscoACI got: " + err.Error())
+		case errors.Is(err, db.ErrorKeyNotFound):
+			if err := saveSwitchData(fabricID, aciNodeData, switchID, switchOem); err != nil {
+				return err
 			}
-			parsePortData(portData, switchID, fabricID)
+		default:
+			return fmt.Errorf("fetching %s switch failed with %v", switchID, err)
 		}
 	}
 
 	// TODO:
 	// registering the for the aci events
 
-	return
+	return saveControllers(fabricNodes)
+}
+
+// getSwitchOem returns the Oem property of the switch of the registered node telling its role,
+// the role given by fabricNode is used as the role given at the registration may be unspecified
+func getSwitchOem(aciNodeData *models.FabricNodeMember, fabricNode capmodel.FabricNodeAttributes) *capmodel.SwitchOem {
+	cisco := &capmodel.CiscoSwitchOem{
+		Role: fabricNode.Role,
+	}
+	if cisco.Role == "" {
+		cisco.Role = aciNodeData.Role
+	}
+	if fabricNode.NodeType != "unspecified" {
+		cisco.NodeType = fabricNode.NodeType
+	}
+	return &capmodel.SwitchOem{Cisco: cisco}
+}
+
+// saveSwitchData stores the switch of the node with its chassis and ports,
+// the records stored by an earlier failed attempt are kept
+func saveSwitchData(fabricID string, aciNodeData *models.FabricNodeMember, switchID string, switchOem *capmodel.SwitchOem) error {
+	switchData, chassisData, err := getSwitchData(fabricID, aciNodeData, switchID)
+	if err != nil {
+		return err
+	}
+	switchData.Oem = switchOem
+	if err := capmodel.SaveSwitchChassis(chassisData.ID, chassisData); err != nil && !errors.Is(err, db.ErrorKeyAlreadyExist) {
+		return fmt.Errorf("storing %s chassis failed with %v", chassisData.ID, err)
+	}
+	// adding logic to collect the ports data
+	portData, err := caputilities.GetPortData(aciNodeData.PodId, aciNodeData.NodeId)
+	if err != nil {
+		return fmt.Errorf("while intializing ACI Port  Data  PluginCiscoACI got: %v", err)
+	}
+	if err := parsePortData(portData, switchID, fabricID, aciNodeData.PodId, aciNodeData.NodeId); err != nil {
+		return err
+	}
+	if err := capmodel.SaveSwitch(switchID, switchData); err != nil {
+		return fmt.Errorf("storing %s switch failed with %v", switchID, err)
+	}
+	return nil
+}
+
+// updateSwitchOem updates the Oem property of the stored switch when the role of its node changed
+func updateSwitchOem(switchID string, switchOem *capmodel.SwitchOem) error {
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		return fmt.Errorf("fetching %s switch failed with %v", switchID, err)
+	}
+	if storedOem := capmodel.GetSwitchOem(&switchData); storedOem.Cisco != nil && *storedOem.Cisco == *switchOem.Cisco {
+		return nil
+	}
+	switchData.Oem = switchOem
+	if err := capmodel.UpdateSwitch(switchID, &switchData); err != nil {
+		return fmt.Errorf("updating %s switch failed with %v", switchID, err)
+	}
+	return nil
+}
+
+// saveControllers stores a manager for each APIC controller of the fabric,
+// the managers of the controllers which are no longer in the fabric are deleted
+func saveControllers(fabricNodes []capmodel.FabricNode) error {
+	controllerIDs := make(map[string]bool)
+	for _, fabricNode := range fabricNodes {
+		attributes := fabricNode.Attributes
+		if attributes.Role != capmodel.NodeRoleController {
+			continue
+		}
+		controllerID := capmodel.ControllerID(attributes.PodID(), attributes.Serial, attributes.ID)
+		controllerIDs[controllerID] = true
+		controller := capdata.Controller{
+			PodID:   attributes.PodID(),
+			NodeID:  attributes.ID,
+			Manager: getControllerData(&attributes, controllerID),
+		}
+		if err := capmodel.UpdateController(controllerID, &controller); err != nil {
+			return fmt.Errorf("storing %s controller failed with %v", controllerID, err)
+		}
+	}
+	storedIDs, err := capmodel.GetControllerIDs()
+	if err != nil {
+		return fmt.Errorf("fetching the stored controllers failed with %v", err)
+	}
+	for _, controllerID := range storedIDs {
+		if !controllerIDs[controllerID] {
+			log.Info("controller " + controllerID + " is no longer in the fabric, its manager is deleted")
+			if err := capmodel.DeleteController(controllerID); err != nil {
+				log.Error("deleting " + controllerID + " controller failed with " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func getControllerData(controller *capmodel.FabricNodeAttributes, controllerID string) *dmtfmodel.Manager {
+	return &dmtfmodel.Manager{
+		ODataContext:    "/ODIM/v1/$metadata#Manager.Manager",
+		ODataID:         "/ODIM/v1/Managers/" + controllerID,
+		ODataType:       "#Manager.v1_10_0.Manager",
+		ID:              controllerID,
+		Name:            controller.Name,
+		Description:     "APIC controller " + controller.ID,
+		ManagerType:     "ManagementController",
+		UUID:            strings.Split(controllerID, ":")[0],
+		FirmwareVersion: controller.Version,
+		Manufacturer:    controller.Vendor,
+		Model:           controller.Model,
+		SerialNumber:    controller.Serial,
+		Status: &dmtfmodel.Status{
+			State: "Enabled",
+		},
+	}
 }
 
 // parsePortData parses the portData and stores it  in the inmemory
-func parsePortData(portResponseData *capmodel.PortCollectionResponse, switchID, fabricID string) {
+func parsePortData(ports []capmodel.PhysicalInterface, switchID, fabricID, podID, nodeID string) error {
 	var portData []string
-	for _, imdata := range portResponseData.IMData {
-		portAttributes := imdata.PhysicalInterface.Attributes
-		id := portAttributes["id"].(string)
-		id = strings.Replace(id, "/", "-", -1)
-		portID := uuid.NewV4().String() + ":" + id
+	for _, port := range ports {
+		portAttributes := port.Attributes
+		portID := capmodel.PortID(podID, nodeID, portAttributes.ID)
 		portData = append(portData, portID)
 		portInfo := dmtfmodel.Port{
 			ODataContext:          "/ODIM/v1/$metadata#Port.Port",
 			ODataType:             "#Port.v1_3_0.Port",
 			ODataID:               fmt.Sprintf("/ODIM/v1/Fabrics/%s/Switches/%s/Ports/%s", fabricID, switchID, portID),
 			ID:                    portID,
-			Name:                  "Port-" + portAttributes["id"].(string),
-			PortID:                portAttributes["id"].(string),
+			Name:                  "Port-" + portAttributes.ID,
+			PortID:                portAttributes.ID,
 			PortProtocol:          "Ethernet",
 			PortType:              "BidirectionalPort",
 			LinkNetworkTechnology: "Ethernet",
 		}
-		mtu, err := strconv.Atoi(portAttributes["mtu"].(string))
-		if err != nil {
-			log.Error("Unable to get mtu for the port" + portID)
-		}
-		portInfo.MaxFrameSize = mtu
-		if err = capmodel.SavePort(portInfo.ODataID, &portInfo); err != nil {
-			log.Fatal("storing " + portInfo.ODataID + " port failed with " + err.Error())
+		// the ports with a non numeric mtu are skipped when they are read from APIC
+		portInfo.MaxFrameSize, _ = strconv.Atoi(portAttributes.Mtu)
+		if err := capmodel.SavePort(portInfo.ODataID, &portInfo); err != nil && !errors.Is(err, db.ErrorKeyAlreadyExist) {
+			return fmt.Errorf("storing %s port failed with %v", portInfo.ODataID, err)
 		}
 	}
-	if err := capmodel.SaveSwitchPort(switchID, portData); err != nil {
-		log.Fatal("storing port data of switch " + switchID + " failed with " + err.Error())
+	if err := capmodel.SaveSwitchPort(switchID, portData); err != nil && !errors.Is(err, db.ErrorKeyAlreadyExist) {
+		return fmt.Errorf("storing port data of switch %s failed with %v", switchID, err)
 	}
+	return nil
 }
 
-func getSwitchData(fabricID string, fabricNodeData *models.FabricNodeMember, switchID string) (*dmtfmodel.Switch, *dmtfmodel.Chassis) {
+func getSwitchData(fabricID string, fabricNodeData *models.FabricNodeMember, switchID string) (*dmtfmodel.Switch, *dmtfmodel.Chassis, error) {
 	switchUUIDData := strings.Split(switchID, ":")
 	var switchData = dmtfmodel.Switch{
 		ODataContext: "/ODIM/v1/$metadata#Switch.Switch",
@@ -302,59 +489,41 @@ func getSwitchData(fabricID string, fabricNodeData *models.FabricNodeMember, swi
 	}
 	podID, err := strconv.Atoi(fabricNodeData.PodId)
 	if err != nil {
-		log.Fatal("Converstion of PODID" + fabricNodeData.PodId + " failed")
+		return nil, nil, fmt.Errorf("Converstion of PODID %s failed", fabricNodeData.PodId)
 	}
 	nodeID, err := strconv.Atoi(fabricNodeData.NodeId)
 	if err != nil {
-		log.Fatal("Converstion of NodeID" + fabricNodeData.NodeId + " failed")
+		return nil, nil, fmt.Errorf("Converstion of NodeID %s failed", fabricNodeData.NodeId)
 	}
 	log.Info("Getting the switchData for NodeID" + fabricNodeData.NodeId)
 	switchRespData, err := caputilities.GetSwitchInfo(podID, nodeID)
 	if err != nil {
-		log.Fatal("Unable to get the Switch info:" + err.Error())
+		return nil, nil, fmt.Errorf("Unable to get the Switch info:%v", err)
 	}
 	switchData.FirmwareVersion = switchRespData.SystemAttributes.Version
-	switchChassisData, healthChassisData, err := caputilities.GetSwitchChassisInfo(fabricNodeData.PodId, fabricNodeData.NodeId)
-	if err != nil {
-		log.Fatal("Unable to get the Switch Chassis info for node " + fabricNodeData.NodeId + " :" + err.Error())
-	}
-	switchData.Manufacturer = switchChassisData.IMData[0].SwitchChassisData.Attributes["vendor"].(string)
-	switchData.Model = switchChassisData.IMData[0].SwitchChassisData.Attributes["model"].(string)
-	chassisID := switchChassisData.IMData[0].SwitchChassisData.Attributes["id"].(string)
-	chassisUUID := uuid.NewV4().String()
-	var chassisHealth string
-
-	//take health value
-	data := healthChassisData.IMData[0].HealthData.Attributes
-	currentHealthValue := data["cur"].(string)
-	healthValue, err := strconv.Atoi(currentHealthValue)
+	switchChassisData, err := caputilities.GetSwitchChassisInfo(fabricNodeData.PodId, fabricNodeData.NodeId)
 	if err != nil {
-		log.Fatal("Unable to convert current Health value:" + currentHealthValue + " go the error" + err.Error())
-	}
-
-	if healthValue > 90 {
-		chassisHealth = "OK"
-	} else if healthValue <= 90 && healthValue < 30 {
-		chassisHealth = "Warning"
-	} else {
-		chassisHealth = "Critical"
+		return nil, nil, fmt.Errorf("Unable to get the Switch Chassis info for node %s :%v", fabricNodeData.NodeId, err)
 	}
+	chassisAttributes := switchChassisData.Attributes
+	switchData.Manufacturer = chassisAttributes.Vendor
+	switchData.Model = chassisAttributes.Model
+	chassisID := capmodel.ChassisID(fabricNodeData.PodId, fabricNodeData.Serial, chassisAttributes.ID)
+	chassisUUID := strings.Split(chassisID, ":")[0]
 	var chassisData = dmtfmodel.Chassis{
 		Ocontext:     "/ODIM/v1/$metadata#Chassis.Chassis",
 		Otype:        "#Chassis.v1_4_0.Chassis",
-		Oid:          "/ODIM/v1/Chassis/" + chassisUUID + ":" + chassisID,
-		ID:           chassisUUID + ":" + chassisID,
+		Oid:          "/ODIM/v1/Chassis/" + chassisID,
+		ID:           chassisID,
 		Name:         fabricNodeData.Name + "_chassis",
 		ChassisType:  "RackMount",
 		UUID:         chassisUUID,
-		SerialNumber: switchChassisData.IMData[0].SwitchChassisData.Attributes["ser"].(string),
-		Manufacturer: switchChassisData.IMData[0].SwitchChassisData.Attributes["vendor"].(string),
-		Model:        switchChassisData.IMData[0].SwitchChassisData.Attributes["model"].(string),
-		PowerState:   switchChassisData.IMData[0].SwitchChassisData.Attributes["operSt"].(string),
-		Status: &dmtfmodel.Status{
-			State:  "Enabled",
-			Health: chassisHealth,
-		},
+		SerialNumber: chassisAttributes.Ser,
+		Manufacturer: chassisAttributes.Vendor,
+		Model:        chassisAttributes.Model,
+		PowerState:   chassisAttributes.OperSt,
+		// the health is read from APIC when the chassis is read
+		Status: &dmtfmodel.Status{State: "Enabled"},
 		Links: &dmtfmodel.Links{
 			Switches: []*dmtfmodel.Link{
 				&dmtfmodel.Link{
@@ -369,12 +538,13 @@ func getSwitchData(fabricID string, fabricNodeData *models.FabricNodeMember, swi
 		},
 	}
 
-	return &switchData, &chassisData
+	return &switchData, &chassisData, nil
 }
 
-func checkSwitchIDExists(switchIDs []string, nodeID string) (exists bool) {
+// checkSwitchIDExists reports whether the switch is stored, a node replaced with a new serial number is a new switch
+func checkSwitchIDExists(switchIDs []string, switchID string) (exists bool) {
 	for _, switchid := range switchIDs {
-		if strings.HasSuffix(switchid, ":"+nodeID) {
+		if switchid == switchID {
 			return true
 		}
 	}
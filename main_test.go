@@ -0,0 +1,1034 @@
+// (C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package main
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+
+	"github.com/kataras/iris/v12/httptest"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	mockPluginUserName = "admin"
+	mockPluginPassword = "password"
+)
+
+// loadMockFabric adds the fabric nodes, switches and ports of a two leaf pod to the MockAPIC
+func loadMockFabric(apic *caputilities.MockAPIC) {
+	apic.AddObject("fvTenant", map[string]interface{}{"dn": "uni/tn-common", "name": "common"})
+	apic.AddObject("fabricHealthTotal", map[string]interface{}{"dn": "topology/pod-1/health", "cur": "98"})
+	for _, nodeID := range []string{"101", "102"} {
+		loadMockLeaf(apic, "1", nodeID)
+	}
+}
+
+// loadMockLeaf adds the fabric node, switch and ports of a leaf of the pod to the MockAPIC
+func loadMockLeaf(apic *caputilities.MockAPIC, podID, nodeID string) {
+	nodeDN := "topology/pod-" + podID + "/node-" + nodeID
+	apic.AddObject("fabricNodeIdentP", map[string]interface{}{
+		"dn":       "uni/controller/nodeidentpol/nodep-SAL" + nodeID,
+		"serial":   "SAL" + nodeID,
+		"nodeId":   nodeID,
+		"podId":    podID,
+		"fabricId": "1",
+		"name":     "leaf-" + nodeID,
+		"role":     "leaf",
+	})
+	apic.AddObject("topSystem", map[string]interface{}{
+		"dn":      nodeDN + "/sys",
+		"id":      nodeID,
+		"name":    "leaf-" + nodeID,
+		"role":    "leaf",
+		"version": "n9000-15.2(1g)",
+	})
+	apic.AddObject("eqptCh", map[string]interface{}{
+		"dn":     nodeDN + "/sys/ch",
+		"id":     "1",
+		"model":  "N9K-C93180YC-FX",
+		"operSt": "online",
+		"ser":    "FDO" + nodeID,
+		"vendor": "Cisco Systems, Inc",
+	})
+	apic.AddObject("healthInst", map[string]interface{}{"dn": nodeDN + "/sys/ch/health", "cur": "100"})
+	apic.AddObject("healthInst", map[string]interface{}{"dn": nodeDN + "/sys/health", "cur": "95"})
+	for _, portID := range []string{"eth1/1", "eth1/2"} {
+		portDN := fmt.Sprintf("%s/sys/phys-[%s]", nodeDN, portID)
+		apic.AddObject("l1PhysIf", map[string]interface{}{
+			"dn":      portDN,
+			"id":      portID,
+			"adminSt": "up",
+			"mtu":     "9000",
+		})
+		apic.AddObject("ethpmPhysIf", map[string]interface{}{
+			"dn":        portDN + "/phys",
+			"operSt":    "up",
+			"operSpeed": "10G",
+		})
+		apic.AddObject("healthInst", map[string]interface{}{"dn": portDN + "/phys/health", "cur": "100"})
+	}
+}
+
+// setUpMockPlugin sets up the plugin with an in-memory DB and a MockAPIC loaded with the objects added by loads,
+// the ACI data is read from the MockAPIC as on startup
+func setUpMockPlugin(t *testing.T, loads ...func(apic *caputilities.MockAPIC)) *caputilities.MockAPIC {
+	t.Helper()
+	config.SetUpMockConfig(t)
+	hash := sha3.New512()
+	hash.Write([]byte(mockPluginPassword))
+	config.Data.PluginConf.Password = base64.URLEncoding.EncodeToString(hash.Sum(nil))
+	db.Connector = db.NewInMemoryConnector()
+	apic, err := caputilities.StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	t.Cleanup(apic.Close)
+	for _, load := range loads {
+		load(apic)
+	}
+	if err := intializeACIData(); err != nil {
+		t.Fatalf("failed to initialize the ACI data: %v", err)
+	}
+	return apic
+}
+
+func TestZoneEndpointWorkflow(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+
+	e := httptest.New(t, routers())
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+
+	fabric := e.GET(fabricURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	fabric.Value("Status").Object().Value("Health").Equal("OK")
+
+	// collect the ports of both the leaves
+	ports := make(map[string]string)
+	switches := e.GET(fabricURI+"/Switches").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	switches.Value("Members@odata.count").Equal(2)
+	for _, member := range switches.Value("Members").Array().Iter() {
+		switchURI := member.Object().Value("@odata.id").String().Raw()
+		nodeID := switchURI[strings.LastIndex(switchURI, ":")+1:]
+		switchData := e.GET(switchURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+		switchData.Value("FirmwareVersion").Equal("n9000-15.2(1g)")
+		switchData.Value("Status").Object().Value("Health").Equal("OK")
+		// the chassis health is read from APIC, a score of 90 is no longer OK
+		chassisURI := switchData.Value("Links").Object().Value("Chassis").Object().Value("@odata.id").String().Raw()
+		e.GET(chassisURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object().
+			Value("Status").Object().Value("Health").Equal("OK")
+		apic.AddObject("healthInst", map[string]interface{}{"dn": "topology/pod-1/node-" + nodeID + "/sys/ch/health", "cur": "90"})
+		e.GET(chassisURI).WithHeader("Cache-Control", "no-cache").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object().
+			Value("Status").Object().Value("Health").Equal("Warning")
+		portCollection := e.GET(switchURI+"/Ports").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+		for _, port := range portCollection.Value("Members").Array().Iter() {
+			portURI := port.Object().Value("@odata.id").String().Raw()
+			ports[nodeID+":"+portURI[strings.LastIndex(portURI, ":")+1:]] = portURI
+		}
+	}
+	if len(ports) != 4 {
+		t.Fatalf("expected 4 ports, got %v", ports)
+	}
+	port := e.GET(ports["101:eth1-1"]).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	port.Value("LinkStatus").Equal("LinkUp")
+	port.Value("CurrentSpeedGbps").Equal(10)
+	port.Value("MaxFrameSize").Equal(9000)
+
+	// the port collection can be expanded, filtered and projected in a single request
+	portsURI := ports["101:eth1-1"][:strings.LastIndex(ports["101:eth1-1"], "/")]
+	expandedPorts := e.GET(portsURI).WithQuery("$expand", ".").WithQuery("$filter", "LinkStatus eq 'LinkUp' and CurrentSpeedGbps eq 10").
+		WithQuery("$select", "LinkStatus,Status/Health").WithQuery("$top", 1).
+		WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	expandedPorts.Value("Members@odata.count").Equal(2)
+	expandedPorts.Value("Members").Array().Length().Equal(1)
+	expandedPorts.Value("Members@odata.nextLink").String().Contains("$skip=1")
+	expandedPort := expandedPorts.Value("Members").Array().Element(0).Object()
+	expandedPort.Value("@odata.id").String().Contains(portsURI + "/")
+	expandedPort.Value("LinkStatus").Equal("LinkUp")
+	expandedPort.NotContainsKey("MaxFrameSize")
+	expandedPort.Value("Status").Object().Keys().ContainsOnly("Health")
+
+	// address pools
+	zoneOfZonesPool := e.POST(fabricURI+"/AddressPools").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"Name": "zoz-pool",
+		"Ethernet": map[string]interface{}{
+			"IPv4": map[string]interface{}{
+				"VLANIdentifierAddressRange": map[string]interface{}{"Lower": 100, "Upper": 200},
+			},
+		},
+	}).Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+	zoneOfEndpointsPool := e.POST(fabricURI+"/AddressPools").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"Name": "zoe-pool",
+		"Ethernet": map[string]interface{}{
+			"IPv4": map[string]interface{}{
+				"GatewayIPAddress":           "10.0.0.1/24",
+				"VLANIdentifierAddressRange": map[string]interface{}{"Lower": 150, "Upper": 150},
+			},
+		},
+	}).Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+
+	// default zone
+	defaultZone := e.POST(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"Name":     "tenantA",
+		"ZoneType": "Default",
+	}).Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+	assertACIObject(t, apic, "uni/tn-tenantA", "fvTenant")
+
+	// zone of zones
+	zoneOfZones := e.POST(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"Name":     "appA",
+		"ZoneType": "ZoneOfZones",
+		"Links": map[string]interface{}{
+			"ContainedByZones": []map[string]string{{"@odata.id": defaultZone}},
+			"AddressPools":     []map[string]string{{"@odata.id": zoneOfZonesPool}},
+		},
+	}).Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+	assertACIObject(t, apic, "uni/tn-tenantA/ap-appA", "fvAp")
+	assertACIObject(t, apic, "uni/tn-tenantA/ctx-appA-VRF", "fvCtx")
+	assertACIObject(t, apic, "uni/tn-tenantA/brc-appA-VRF-Con", "vzBrCP")
+	assertACIObject(t, apic, "uni/phys-appA-DOM", "physDomP")
+	assertACIObject(t, apic, "uni/infra/vlanns-[appA-DOM-VLAN]-static", "fvnsVlanInstP")
+	assertACIObject(t, apic, "uni/infra/attentp-appA-DOM-EntityProfile", "infraAttEntityP")
+	e.GET(defaultZone).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Links").Object().Value("ContainsZones").Array().Element(0).Object().Value("@odata.id").Equal(zoneOfZones)
+
+	// endpoints
+	endpoint1 := createMockEndpoint(e, fabricURI, "ep1", ports["101:eth1-1"], ports["102:eth1-1"])
+	endpoint2 := createMockEndpoint(e, fabricURI, "ep2", ports["101:eth1-2"], ports["102:eth1-2"])
+	policyGroup1 := "uni/infra/funcprof/accbundle-Switch-101-102_1-ports-1_PolGrp"
+	policyGroup2 := "uni/infra/funcprof/accbundle-Switch-101-102_1-ports-2_PolGrp"
+	assertACIObject(t, apic, "uni/infra/accportprof-Switch-101-102_Profile_ifselector", "infraAccPortP")
+	assertACIObject(t, apic, "uni/infra/accportprof-Switch-101-102_Profile_ifselector/hports-Switch-101-102_1-ports-1-typ-range", "infraHPortS")
+	assertACIObject(t, apic, "uni/infra/lacplagp-ODIM-PORT-VPCPolicy", "lacpLagPol")
+	assertACIObject(t, apic, policyGroup1, "infraAccBndlGrp")
+	assertACIObject(t, apic, policyGroup2, "infraAccBndlGrp")
+	assertACIObject(t, apic, "uni/infra/nprof-Switch-101-102_Profile", "infraNodeP")
+	e.POST(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(mockEndpointRequest("ep3", ports["101:eth1-1"])).
+		Expect().Status(http.StatusConflict)
+
+	// zone of endpoints
+	epgDN := "uni/tn-tenantA/ap-appA/epg-webA-EPG"
+	staticPath1 := epgDN + "/rspathAtt-[topology/pod-1/protpaths-101-102/pathep-[Switch-101-102_1-ports-1_PolGrp]]"
+	staticPath2 := epgDN + "/rspathAtt-[topology/pod-1/protpaths-101-102/pathep-[Switch-101-102_1-ports-2_PolGrp]]"
+	zoneOfEndpoints := e.POST(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"Name":     "webA",
+		"ZoneType": "ZoneOfEndpoints",
+		"Links": map[string]interface{}{
+			"ContainedByZones": []map[string]string{{"@odata.id": zoneOfZones}},
+			"AddressPools":     []map[string]string{{"@odata.id": zoneOfEndpointsPool}},
+			"Endpoints":        []map[string]string{{"@odata.id": endpoint1}},
+		},
+	}).Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+	assertACIObject(t, apic, "uni/tn-tenantA/BD-webA", "fvBD")
+	assertACIObject(t, apic, "uni/tn-tenantA/BD-webA/subnet-[10.0.0.1/24]", "fvSubnet")
+	assertACIObject(t, apic, epgDN, "fvAEPg")
+	staticPath := assertACIObject(t, apic, staticPath1, "fvRsPathAtt")
+	if staticPath.Attributes["encap"] != "vlan-150" {
+		t.Errorf("expected static path encap vlan-150, got %v", staticPath.Attributes["encap"])
+	}
+	assertACIObject(t, apic, policyGroup1+"/rsattEntP", "infraRsAttEntP")
+
+	// topology of the fabric
+	topologyURI := fabricURI + "/Oem/Topology"
+	fabricTopology := e.GET(topologyURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	fabricTopology.Value("Nodes").Array().Contains(
+		map[string]interface{}{"Id": fabricURI + "#pod-1", "Type": "Pod", "Name": "pod-1"},
+		map[string]interface{}{"Id": zoneOfEndpoints, "Type": "Zone", "Name": "webA", "Role": "ZoneOfEndpoints", "State": "Enabled"},
+	)
+	fabricTopology.Value("Edges").Array().Contains(
+		map[string]interface{}{"Source": fabricURI, "Target": fabricURI + "#pod-1", "Type": "Contains"},
+		map[string]interface{}{"Source": zoneOfZones, "Target": zoneOfEndpoints, "Type": "Contains"},
+		map[string]interface{}{"Source": zoneOfEndpoints, "Target": endpoint1, "Type": "Contains"},
+		map[string]interface{}{"Source": endpoint1, "Target": ports["101:eth1-1"], "Type": "Uses"},
+	)
+	dot := e.GET(topologyURI).WithQuery("format", "dot").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).ContentType("text/vnd.graphviz").Body()
+	dot.Contains(fmt.Sprintf(`"%s" -> "%s" [type="Uses"];`, endpoint1, ports["101:eth1-1"]))
+	e.GET(topologyURI).WithHeader("Accept", "text/vnd.graphviz").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).Body().Equal(dot.Raw())
+	e.GET(topologyURI).WithQuery("format", "svg").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusBadRequest)
+
+	// move the zone of endpoints from ep1 to ep2, a stale ETag is refused before touching ACI
+	zoneETag := e.GET(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		Header("ETag").NotEmpty().Raw()
+	zonePatch := map[string]interface{}{
+		"Links": map[string]interface{}{
+			"Endpoints": []map[string]string{{"@odata.id": endpoint2}},
+		},
+	}
+	e.PATCH(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithHeader("If-Match", `W/"0000000000000000"`).
+		WithJSON(zonePatch).Expect().Status(http.StatusPreconditionFailed)
+	assertNoACIObject(t, apic, staticPath2)
+	patchResp := e.PATCH(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithHeader("If-Match", zoneETag).
+		WithJSON(zonePatch).Expect().Status(http.StatusOK)
+	patchResp.Header("ETag").NotEqual(zoneETag)
+	patchedZone := patchResp.JSON().Object()
+	patchedZone.Value("Links").Object().Value("Endpoints").Array().Length().Equal(1)
+	patchedZone.Value("Links").Object().Value("Endpoints").Array().Element(0).Object().Value("@odata.id").Equal(endpoint2)
+	assertACIObject(t, apic, staticPath2, "fvRsPathAtt")
+	assertACIObject(t, apic, policyGroup2+"/rsattEntP", "infraRsAttEntP")
+	assertNoACIObject(t, apic, staticPath1)
+	assertNoACIObject(t, apic, policyGroup1+"/rsattEntP")
+
+	// dependent resources can't be deleted
+	e.DELETE(zoneOfZones).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotAcceptable)
+	e.DELETE(zoneOfEndpointsPool).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotAcceptable)
+
+	// delete everything in the reverse order
+	e.DELETE(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithHeader("If-Match", zoneETag).
+		Expect().Status(http.StatusPreconditionFailed)
+	e.DELETE(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNoContent)
+	assertNoACIObject(t, apic, "uni/tn-tenantA/BD-webA")
+	assertNoACIObject(t, apic, epgDN)
+	assertNoACIObject(t, apic, policyGroup2+"/rsattEntP")
+	e.GET(zoneOfEndpoints).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotFound)
+
+	for _, endpoint := range []string{endpoint1, endpoint2} {
+		e.DELETE(endpoint).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNoContent)
+		e.GET(endpoint).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotFound)
+	}
+	assertNoACIObject(t, apic, policyGroup1)
+	assertNoACIObject(t, apic, policyGroup2)
+	assertNoACIObject(t, apic, "uni/infra/accportprof-Switch-101-102_Profile_ifselector/hports-Switch-101-102_1-ports-1-typ-range")
+
+	e.DELETE(zoneOfZones).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNoContent)
+	assertNoACIObject(t, apic, "uni/tn-tenantA/ap-appA")
+	assertNoACIObject(t, apic, "uni/tn-tenantA/ctx-appA-VRF")
+	assertNoACIObject(t, apic, "uni/tn-tenantA/brc-appA-VRF-Con")
+	assertNoACIObject(t, apic, "uni/phys-appA-DOM")
+	assertNoACIObject(t, apic, "uni/infra/vlanns-[appA-DOM-VLAN]-static")
+	assertNoACIObject(t, apic, "uni/infra/attentp-appA-DOM-EntityProfile")
+
+	e.DELETE(defaultZone).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNoContent)
+	for _, dn := range apic.DNs() {
+		if strings.HasPrefix(dn, "uni/tn-tenantA") {
+			t.Errorf("object %s of the deleted default zone is still present in ACI", dn)
+		}
+	}
+
+	for _, pool := range []string{zoneOfEndpointsPool, zoneOfZonesPool} {
+		e.DELETE(pool).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNoContent)
+	}
+	e.GET(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Members").Array().Empty()
+	e.GET(fabricURI+"/AddressPools").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Members").Array().Empty()
+	e.GET(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Members").Array().Empty()
+
+	// every request released the locks it took
+	if locks, err := capmodel.GetAllLocks(); err != nil || len(locks) != 0 {
+		t.Errorf("locks %v are still held after the workflow: %v", locks, err)
+	}
+	// modifications wait for the fabric lock held by another plugin instance and give up after the wait time
+	config.Data.LockConf = &config.LockConf{LeaseTimeInSeconds: 10, WaitTimeInSeconds: 1}
+	held, err := capmodel.AcquireLocks("test", capmodel.FabricLockName(config.Data.RootServiceUUID+":1"))
+	if err != nil {
+		t.Fatalf("failed to lock the fabric: %v", err)
+	}
+	e.POST(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"ZoneType": "Default",
+	}).Expect().Status(http.StatusServiceUnavailable).Header("Retry-After").Equal("1")
+	e.GET(fabricURI+"/Zones").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK)
+	held.Release()
+	config.Data.LockConf = nil
+}
+
+func TestMultiPodFabric(t *testing.T) {
+	apic := setUpMockPlugin(t, func(apic *caputilities.MockAPIC) {
+		apic.AddObject("fvTenant", map[string]interface{}{"dn": "uni/tn-common", "name": "common"})
+		apic.AddObject("fabricHealthTotal", map[string]interface{}{"dn": "topology/pod-1/health", "cur": "98"})
+		apic.AddObject("fabricHealthTotal", map[string]interface{}{"dn": "topology/pod-2/health", "cur": "50"})
+		loadMockLeaf(apic, "1", "101")
+		loadMockLeaf(apic, "2", "201")
+		loadMockLeaf(apic, "2", "202")
+	})
+
+	fabricID := config.Data.RootServiceUUID + ":1"
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		t.Fatalf("fabric %s is not stored: %v", fabricID, err)
+	}
+	if pods := fabricData.Pods(); len(pods) != 2 || pods[0] != "1" || pods[1] != "2" {
+		t.Fatalf("expected the fabric to span pods 1 and 2, got %v", pods)
+	}
+	leaves := map[string]string{
+		"101": capmodel.SwitchID("1", "SAL101", "101"),
+		"201": capmodel.SwitchID("2", "SAL201", "201"),
+		"202": capmodel.SwitchID("2", "SAL202", "202"),
+	}
+	for nodeID, switchID := range leaves {
+		if podID := fabricData.SwitchPod(switchID); podID != string(nodeID[0]) {
+			t.Errorf("switch of node %s: got pod %s", nodeID, podID)
+		}
+	}
+
+	// the fabric is as healthy as its least healthy pod
+	e := httptest.New(t, routers())
+	fabricURI := "/ODIM/v1/Fabrics/" + fabricID
+	e.GET(fabricURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Status").Object().Value("Health").Equal("Warning")
+
+	portURI := func(nodeID, portID string) string {
+		return fabricURI + "/Switches/" + leaves[nodeID] + "/Ports/" + capmodel.PortID(string(nodeID[0]), nodeID, portID)
+	}
+	e.GET(portURI("201", "eth1/1")).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("LinkStatus").Equal("LinkUp")
+
+	// the path of an endpoint is in the pod of its leaves
+	endpointURI := createMockEndpoint(e, fabricURI, "ep1", portURI("201", "eth1/1"), portURI("202", "eth1/1"))
+	endpointData, err := capmodel.GetEndpoints(fabricID, endpointURI)
+	if err != nil {
+		t.Fatalf("endpoint %s is not stored: %v", endpointURI, err)
+	}
+	if wantDN := "topology/pod-2/protpaths-201-202/pathep-[Switch-201-202_1-ports-1_PolGrp]"; endpointData.ACIPolicyGroupData.PolicyGroupDN != wantDN {
+		t.Errorf("got policy group DN %s, want %s", endpointData.ACIPolicyGroupData.PolicyGroupDN, wantDN)
+	}
+	e.POST(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		WithJSON(mockEndpointRequest("ep2", portURI("101", "eth1/1"), portURI("201", "eth1/2"))).Expect().Status(http.StatusBadRequest)
+	assertNoACIObject(t, apic, "uni/infra/nprof-Switch-101-201_Profile")
+}
+
+func TestNodeRoles(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		loadMockLeaf(apic, "1", "201")
+		apic.AddObject("fabricNode", map[string]interface{}{"dn": "topology/pod-1/node-101", "id": "101", "role": "leaf", "nodeType": "unspecified"})
+		apic.AddObject("fabricNode", map[string]interface{}{"dn": "topology/pod-1/node-102", "id": "102", "role": "leaf", "nodeType": "remote-leaf-wan"})
+		apic.AddObject("fabricNode", map[string]interface{}{"dn": "topology/pod-1/node-201", "id": "201", "role": "spine", "nodeType": "unspecified"})
+		apic.AddObject("fabricNode", map[string]interface{}{
+			"dn":       "topology/pod-1/node-1",
+			"id":       "1",
+			"name":     "apic1",
+			"role":     "controller",
+			"nodeType": "unspecified",
+			"serial":   "FCH2219V1X0",
+			"model":    "APIC-SERVER-M3",
+			"vendor":   "Cisco Systems, Inc",
+			"version":  "5.2(1g)",
+		})
+	})
+
+	e := httptest.New(t, routers())
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	switchURI := func(nodeID string) string {
+		return fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL"+nodeID, nodeID)
+	}
+	for nodeID, want := range map[string]map[string]interface{}{
+		"101": {"Role": "leaf"},
+		"102": {"Role": "leaf", "NodeType": "remote-leaf-wan"},
+		"201": {"Role": "spine"},
+	} {
+		switchData := e.GET(switchURI(nodeID)).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+		switchData.Value("SwitchType").Equal("Ethernet")
+		switchData.Value("Oem").Object().Value("Cisco").Object().Equal(want)
+	}
+
+	// the controller is a manager along with the plugin
+	controllerURI := "/ODIM/v1/Managers/" + capmodel.ControllerID("1", "FCH2219V1X0", "1")
+	managers := e.GET("/ODIM/v1/Managers").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	managers.Value("Members@odata.count").Equal(2)
+	managers.Value("Members").Array().Contains(map[string]string{"@odata.id": controllerURI})
+	controller := e.GET(controllerURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	controller.Value("Name").Equal("apic1")
+	controller.Value("FirmwareVersion").Equal("5.2(1g)")
+	controller.Value("SerialNumber").Equal("FCH2219V1X0")
+	controller.Value("Status").Object().NotContainsKey("Health")
+
+	// the details read from the controller are reported once it answers
+	apic.AddObject("topSystem", map[string]interface{}{
+		"dn":             "topology/pod-1/node-1/sys",
+		"id":             "1",
+		"name":           "apic1",
+		"role":           "controller",
+		"serial":         "FCH2219V1X0",
+		"version":        "5.2(1h)",
+		"address":        "10.0.0.1",
+		"inbMgmtAddr":    "192.168.10.1",
+		"oobMgmtAddr":    "172.16.0.11",
+		"systemUpTime":   "12:03:04:05.000",
+		"lastRebootTime": "2022-08-01T10:00:00.000+00:00",
+		"currentTime":    "2022-08-13T13:04:05.000+00:00",
+	})
+	for _, member := range []map[string]interface{}{
+		{"id": "1", "nodeName": "apic1", "addr": "10.0.0.1", "health": "fully-fit"},
+		{"id": "2", "nodeName": "apic2", "addr": "10.0.0.2", "health": "fully-fit"},
+	} {
+		member["dn"] = "topology/pod-1/node-1/av/node-" + member["id"].(string)
+		member["operSt"] = "available"
+		member["adminSt"] = "in-service"
+		apic.AddObject("infraWiNode", member)
+	}
+	controller = e.GET(controllerURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).JSON().Object()
+	controller.Value("FirmwareVersion").Equal("5.2(1h)")
+	controller.Value("LastResetTime").Equal("2022-08-01T10:00:00.000+00:00")
+	controller.Value("Status").Object().Value("Health").Equal("OK")
+	controller.Value("Links").Object().Value("ManagerForSwitches@odata.count").Equal(3)
+	controllerOem := controller.Value("Oem").Object().Value("Cisco").Object()
+	controllerOem.Value("InBandManagementAddress").Equal("192.168.10.1")
+	controllerOem.Value("OutOfBandManagementAddress").Equal("172.16.0.11")
+	controllerOem.Value("UpTime").Equal("12:03:04:05.000")
+	controllerOem.Value("ClusterMembers").Array().Length().Equal(2)
+	apic.AddObject("infraWiNode", map[string]interface{}{"dn": "topology/pod-1/node-1/av/node-2", "health": "data-layer-partially-diverged"})
+	e.GET(controllerURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Status").Object().Value("Health").Equal("Warning")
+	e.GET("/ODIM/v1/Managers/unknown").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotFound)
+	e.GET("/ODIM/v1/Managers").WithBasicAuth(mockPluginUserName, "wrong").Expect().Status(http.StatusUnauthorized)
+	e.GET(controllerURI).WithBasicAuth(mockPluginUserName, "wrong").Expect().Status(http.StatusUnauthorized)
+
+	// the servers can't be attached to the spine ports
+	spinePort := switchURI("201") + "/Ports/" + capmodel.PortID("1", "201", "eth1/1")
+	leafPort := switchURI("101") + "/Ports/" + capmodel.PortID("1", "101", "eth1/1")
+	e.POST(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		WithJSON(mockEndpointRequest("ep1", leafPort, spinePort)).Expect().Status(http.StatusBadRequest)
+	assertNoACIObject(t, apic, "uni/infra/nprof-Switch-101-201_Profile")
+}
+
+func mockEndpointRequest(name string, ports ...string) map[string]interface{} {
+	var redundancySet []map[string]string
+	for _, port := range ports {
+		redundancySet = append(redundancySet, map[string]string{"@odata.id": port})
+	}
+	return map[string]interface{}{
+		"Name": name,
+		"Redundancy": []map[string]interface{}{
+			{
+				"Mode":          "Sharing",
+				"RedundancySet": redundancySet,
+			},
+		},
+	}
+}
+
+func createMockEndpoint(e *httptest.Expect, fabricURI, name string, ports ...string) string {
+	return e.POST(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(mockEndpointRequest(name, ports...)).
+		Expect().Status(http.StatusCreated).JSON().Object().Value("@odata.id").String().Raw()
+}
+
+func assertACIObject(t *testing.T, apic *caputilities.MockAPIC, dn, className string) caputilities.MockACIObject {
+	t.Helper()
+	object, ok := apic.GetObject(dn)
+	if !ok {
+		t.Fatalf("expected ACI object %s is not present, objects present are %v", dn, apic.DNs())
+	}
+	if object.ClassName != className {
+		t.Errorf("expected ACI object %s to be of class %s, got %s", dn, className, object.ClassName)
+	}
+	return object
+}
+
+func assertNoACIObject(t *testing.T, apic *caputilities.MockAPIC, dn string) {
+	t.Helper()
+	if _, ok := apic.GetObject(dn); ok {
+		t.Errorf("ACI object %s is expected to be deleted", dn)
+	}
+}
+
+// setUpAPICReplay makes the APIC requests to be answered with the exchanges recorded from the given APIC release
+func setUpAPICReplay(t *testing.T, release string) {
+	replay, err := caputilities.NewReplayTransport(filepath.Join("caputilities", "testdata", "apic", release))
+	if err != nil {
+		t.Fatalf("failed to load recorded APIC exchanges of release %s: %v", release, err)
+	}
+	caputilities.APICTransport = replay
+	caputilities.ClearAPICCache()
+	config.Data.APICConf = &config.APICConf{
+		APICHost: "apic.example.com",
+		UserName: "admin",
+		Password: "password",
+	}
+}
+
+func TestIntializeACIDataAcrossAPICReleases(t *testing.T) {
+	type switchData struct {
+		firmwareVersion string
+		model           string
+		serialNumber    string
+	}
+	tests := []struct {
+		release           string
+		switches          map[string]switchData
+		ports             []string
+		controllerVersion string
+	}{
+		{
+			release:           "4.2.7f",
+			controllerVersion: "4.2(7f)",
+			switches: map[string]switchData{
+				"101": {"n9000-14.2(7f)", "N9K-C93180YC-EX", "FDO213407K"},
+				"102": {"n9000-14.2(7f)", "N9K-C93180YC-EX", "FDO223507K"},
+			},
+			ports: []string{"eth1/1", "eth1/2"},
+		},
+		{
+			release:           "5.2.1g",
+			controllerVersion: "5.2(1g)",
+			switches: map[string]switchData{
+				"101": {"n9000-15.2(1g)", "N9K-C93180YC-FX", "FDO213407K"},
+				"102": {"n9000-15.2(1g)", "N9K-C93180YC-FX", "FDO223507K"},
+			},
+			ports: []string{"eth1/1", "eth1/49/1"},
+		},
+		{
+			release:           "6.0.2h",
+			controllerVersion: "6.0(2h)",
+			switches: map[string]switchData{
+				"101": {"n9000-16.0(2h)", "N9K-C9336C-FX2", "FDO213407K"},
+				"102": {"n9000-16.0(2h)", "N9K-C9336C-FX2", "FDO223507K"},
+			},
+			ports: []string{"eth1/1", "eth1/2", "eth1/3"},
+		},
+	}
+	defer func() { caputilities.APICTransport = nil }()
+	for _, tt := range tests {
+		t.Run(tt.release, func(t *testing.T) {
+			config.SetUpMockConfig(t)
+			db.Connector = db.NewInMemoryConnector()
+			setUpAPICReplay(t, tt.release)
+			if err := intializeACIData(); err != nil {
+				t.Fatalf("failed to initialize the ACI data: %v", err)
+			}
+
+			fabricID := config.Data.RootServiceUUID + ":1"
+			fabric, err := capmodel.GetFabric(fabricID)
+			if err != nil {
+				t.Fatalf("fabric %s is not stored: %v", fabricID, err)
+			}
+			if fabric.PodID != "1" || len(fabric.SwitchData) != len(tt.switches) {
+				t.Fatalf("unexpected fabric data %+v", fabric)
+			}
+			for _, switchID := range fabric.SwitchData {
+				nodeID := switchID[strings.LastIndex(switchID, ":")+1:]
+				want, ok := tt.switches[nodeID]
+				if !ok {
+					t.Fatalf("unexpected switch %s", switchID)
+				}
+				switchInfo, err := capmodel.GetSwitch(switchID)
+				if err != nil {
+					t.Fatalf("switch %s is not stored: %v", switchID, err)
+				}
+				if switchInfo.FirmwareVersion != want.firmwareVersion || switchInfo.Model != want.model || switchInfo.SerialNumber != want.serialNumber {
+					t.Errorf("switch %s: got firmware %s, model %s, serial %s, want %+v", nodeID, switchInfo.FirmwareVersion, switchInfo.Model, switchInfo.SerialNumber, want)
+				}
+				if role := capmodel.GetSwitchRole(&switchInfo); role != capmodel.NodeRoleLeaf {
+					t.Errorf("switch %s: got role %s, want leaf", nodeID, role)
+				}
+				chassis, err := capmodel.GetSwitchChassis(strings.TrimPrefix(switchInfo.Links.Chassis.Oid, "/ODIM/v1/Chassis/"))
+				if err != nil {
+					t.Fatalf("chassis of switch %s is not stored: %v", switchID, err)
+				}
+				if chassis.SerialNumber != want.serialNumber || chassis.Status.State != "Enabled" || chassis.PowerState != "online" {
+					t.Errorf("switch %s: unexpected chassis data %+v", nodeID, chassis)
+				}
+				if health, err := caputilities.GetSwitchChassisHealth("1", nodeID, caputilities.BypassCache); err != nil || health.Attributes.Cur == "" {
+					t.Errorf("switch %s: chassis health is not read: %+v, %v", nodeID, health, err)
+				}
+				portIDs, err := capmodel.GetSwitchPort(switchID)
+				if err != nil {
+					t.Fatalf("ports of switch %s are not stored: %v", switchID, err)
+				}
+				if len(portIDs) != len(tt.ports) {
+					t.Fatalf("switch %s: got %d ports, want %d", nodeID, len(portIDs), len(tt.ports))
+				}
+				for i, portID := range portIDs {
+					port, err := capmodel.GetPort(fmt.Sprintf("/ODIM/v1/Fabrics/%s/Switches/%s/Ports/%s", fabricID, switchID, portID))
+					if err != nil {
+						t.Fatalf("port %s is not stored: %v", portID, err)
+					}
+					if port.PortID != tt.ports[i] || port.MaxFrameSize != 9000 {
+						t.Errorf("switch %s: got port %s with MaxFrameSize %d, want %s", nodeID, port.PortID, port.MaxFrameSize, tt.ports[i])
+					}
+				}
+			}
+
+			controllerIDs, err := capmodel.GetControllerIDs()
+			if err != nil || len(controllerIDs) != 1 {
+				t.Fatalf("expected the manager of one controller, got %v, %v", controllerIDs, err)
+			}
+			controller, err := capmodel.GetController(controllerIDs[0])
+			if err != nil {
+				t.Fatalf("controller %s is not stored: %v", controllerIDs[0], err)
+			}
+			if controller.Manager.Name != "apic1" || controller.Manager.SerialNumber != "FCH2219V1X0" || controller.Manager.FirmwareVersion != tt.controllerVersion {
+				t.Errorf("unexpected controller data %+v", controller)
+			}
+		})
+	}
+}
+
+// failingClassTransport fails the given number of the queries of the class sent to APIC
+type failingClassTransport struct {
+	className string
+	failures  int
+}
+
+func (f *failingClassTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/"+f.className+".json") && f.failures > 0 {
+		f.failures--
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"imdata":[{"error":{"attributes":{"code":"400","text":"mock failure"}}}]}`)),
+			Request:    req,
+		}, nil
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	return transport.RoundTrip(req)
+}
+
+func TestIntializeACIDataAfterFailure(t *testing.T) {
+	config.SetUpMockConfig(t)
+	db.Connector = db.NewInMemoryConnector()
+	apic, err := caputilities.StartMockAPIC()
+	if err != nil {
+		t.Fatalf("failed to start mock APIC: %v", err)
+	}
+	defer apic.Close()
+	loadMockFabric(apic)
+	caputilities.APICTransport = &failingClassTransport{className: "l1PhysIf", failures: 1}
+	defer func() { caputilities.APICTransport = nil }()
+
+	if err := intializeACIData(); err == nil {
+		t.Fatal("expected the ACI data initialization to fail when the ports can't be read")
+	}
+	// the switch whose ports couldn't be read is discovered again on the next attempt
+	if err := intializeACIData(); err != nil {
+		t.Fatalf("failed to initialize the ACI data: %v", err)
+	}
+	fabric, err := capmodel.GetFabric(config.Data.RootServiceUUID + ":1")
+	if err != nil || len(fabric.SwitchData) != 2 {
+		t.Fatalf("expected a fabric of two switches, got %+v, %v", fabric, err)
+	}
+	for _, switchID := range fabric.SwitchData {
+		if _, err := capmodel.GetSwitch(switchID); err != nil {
+			t.Errorf("switch %s is not stored: %v", switchID, err)
+		}
+		if portIDs, err := capmodel.GetSwitchPort(switchID); err != nil || len(portIDs) != 2 {
+			t.Errorf("expected two ports of switch %s, got %v, %v", switchID, portIDs, err)
+		}
+	}
+}
+
+func TestPortTransceiver(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		physDN := "topology/pod-1/node-101/sys/phys-[eth1/1]/phys"
+		apic.AddObject("ethpmFcot", map[string]interface{}{
+			"dn":            physDN + "/fcot",
+			"isFcotPresent": "yes",
+			"state":         "inserted",
+			"typeName":      "10Gbase-SR",
+			"guiName":       "CISCO-FINISAR",
+			"guiPN":         "SFP-10G-SR",
+			"guiSN":         "FNS1234ABCD",
+			"vendorName":    "FINISAR CORP.",
+			"vendorPn":      "FTLX8571D3BCL-C2",
+			"vendorSn":      "ABC1234",
+		})
+		apic.AddObject("ethpmPortCap", map[string]interface{}{"dn": physDN + "/portcap", "speed": "10G,25G,auto"})
+		apic.AddObject(capmodel.DOMTxPowerSensorClass, map[string]interface{}{"dn": physDN + "/domstats/txpower", "value": "-2.5"})
+		apic.AddObject(capmodel.DOMTemperatureSensorClass, map[string]interface{}{"dn": physDN + "/domstats/temp", "value": "31.2"})
+		apic.AddObject("ethpmFcot", map[string]interface{}{
+			"dn":            "topology/pod-1/node-101/sys/phys-[eth1/2]/phys/fcot",
+			"isFcotPresent": "no",
+			"state":         "unknown",
+		})
+	})
+
+	e := httptest.New(t, routers())
+	switchURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1/Switches/" + capmodel.SwitchID("1", "SAL101", "101")
+	port := e.GET(switchURI+"/Ports/"+capmodel.PortID("1", "101", "eth1/1")).WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object()
+	port.Value("SFP").Object().ContainsMap(map[string]interface{}{
+		"Type":                "SFPPlus",
+		"SupportedSFPTypes":   []string{"SFPPlus"},
+		"MediumType":          "Optical",
+		"FiberConnectionType": "MultiMode",
+		"Manufacturer":        "CISCO-FINISAR",
+		"PartNumber":          "SFP-10G-SR",
+		"SerialNumber":        "FNS1234ABCD",
+	})
+	port.Value("SFP").Object().Value("Status").Object().Value("State").Equal("Enabled")
+	cisco := port.Value("Oem").Object().Value("Cisco").Object()
+	cisco.Value("SupportedSpeeds").Equal([]string{"10G", "25G"})
+	cisco.Value("TransceiverDOM").Equal(map[string]interface{}{"TXOutputPowerDBm": -2.5, "TemperatureCelsius": 31.2})
+
+	// the DOM readings aren't cached
+	apic.AddObject(capmodel.DOMTemperatureSensorClass, map[string]interface{}{"dn": "topology/pod-1/node-101/sys/phys-[eth1/1]/phys/domstats/temp", "value": "35.5"})
+	e.GET(switchURI+"/Ports/"+capmodel.PortID("1", "101", "eth1/1")).WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object().Value("Oem").Object().Value("Cisco").Object().
+		Value("TransceiverDOM").Equal(map[string]interface{}{"TXOutputPowerDBm": -2.5, "TemperatureCelsius": 35.5})
+
+	port = e.GET(switchURI+"/Ports/"+capmodel.PortID("1", "101", "eth1/2")).WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object()
+	port.Value("SFP").Object().Value("Status").Object().Value("State").Equal("Absent")
+	port.Value("SFP").Object().NotContainsKey("Manufacturer")
+}
+
+func TestPortMetrics(t *testing.T) {
+	setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		portDN := "topology/pod-1/node-101/sys/phys-[eth1/1]"
+		apic.AddObject("rmonIfIn", map[string]interface{}{
+			"dn":            portDN + "/dbgIfIn",
+			"octets":        "18446744073709551000",
+			"ucastPkts":     "1000",
+			"multicastPkts": "20",
+			"broadcastPkts": "3",
+			"discards":      "4",
+			"errors":        "5",
+		})
+		apic.AddObject("rmonIfOut", map[string]interface{}{
+			"dn":            portDN + "/dbgIfOut",
+			"octets":        "2048",
+			"ucastPkts":     "900",
+			"multicastPkts": "10",
+			"broadcastPkts": "1",
+			"discards":      "0",
+			"errors":        "2",
+		})
+		apic.AddObject("rmonEtherStats", map[string]interface{}{"dn": portDN + "/dbgEtherStats", "cRCAlignErrors": "7", "dropEvents": "0"})
+		apic.AddObject("eqptIngrTotal5min", map[string]interface{}{"dn": portDN + "/CDeqptIngrTotal5min", "utilAvg": "12.5"})
+		apic.AddObject("eqptEgrTotal5min", map[string]interface{}{"dn": portDN + "/CDeqptEgrTotal5min", "utilAvg": "3"})
+	})
+
+	e := httptest.New(t, routers())
+	switchURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1/Switches/" + capmodel.SwitchID("1", "SAL101", "101")
+	portURI := switchURI + "/Ports/" + capmodel.PortID("1", "101", "eth1/1")
+	e.GET(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Metrics").Object().Value("@odata.id").Equal(portURI + "/Metrics")
+
+	metrics := e.GET(portURI+"/Metrics").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object()
+	metrics.Value("@odata.id").Equal(portURI + "/Metrics")
+	metrics.Value("@odata.type").Equal("#PortMetrics.v1_1_0.PortMetrics")
+	metrics.Value("TXBytes").Equal(2048)
+	metrics.Value("RXErrors").Equal(5)
+	metrics.Value("TXErrors").Equal(2)
+	metrics.Value("Networking").Object().ContainsMap(map[string]interface{}{
+		"RXFrames":    1023,
+		"TXFrames":    911,
+		"RXDiscards":  4,
+		"TXDiscards":  0,
+		"RXCRCErrors": 7,
+	})
+	metrics.Value("Oem").Object().Value("Cisco").Equal(map[string]interface{}{"RXUtilizationPercent": 12.5, "TXUtilizationPercent": 3})
+	// the byte counters are 64 bit
+	if body := e.GET(portURI+"/Metrics").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).Body().Raw(); !strings.Contains(body, `"RXBytes": 18446744073709551000`) {
+		t.Errorf("RXBytes should hold the 64 bit counter, got %s", body)
+	}
+
+	e.GET(switchURI+"/Ports/"+capmodel.PortID("1", "101", "eth1/9")+"/Metrics").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusNotFound)
+}
+
+func TestPortInterfaceEnabled(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+
+	e := httptest.New(t, routers())
+	switchURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1/Switches/" + capmodel.SwitchID("1", "SAL101", "101")
+	setPortState := func(portID, operSt, operStQual string) {
+		apic.AddObject("ethpmPhysIf", map[string]interface{}{
+			"dn":         "topology/pod-1/node-101/sys/phys-[" + portID + "]/phys",
+			"operSt":     operSt,
+			"operStQual": operStQual,
+			"operSpeed":  "10G",
+		})
+	}
+
+	portURI := switchURI + "/Ports/" + capmodel.PortID("1", "101", "eth1/1")
+	oosPathDN := "uni/fabric/outofsvc/rsoosPath-[topology/pod-1/paths-101/pathep-[eth1/1]]"
+	setPortState("eth1/1", "down", "admin-down")
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"InterfaceEnabled": false}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("InterfaceEnabled").Equal(false)
+	oosPath := assertACIObject(t, apic, oosPathDN, "fabricRsOosPath")
+	if tDn := oosPath.Attributes["tDn"]; tDn != "topology/pod-1/paths-101/pathep-[eth1/1]" {
+		t.Errorf("out of service path should target the port, got %v", tDn)
+	}
+
+	// the port has no known connection, so it is enabled without waiting for a link which may never come up
+	setPortState("eth1/1", "down", "link-failure")
+	start := time.Now()
+	port := e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"InterfaceEnabled": true}).
+		Expect().Status(http.StatusOK).JSON().Object()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("enabling a port without a link should not wait for the link, took %v", elapsed)
+	}
+	port.Value("InterfaceEnabled").Equal(true)
+	port.Value("LinkStatus").Equal("LinkDown")
+	assertNoACIObject(t, apic, oosPathDN)
+
+	// the fabric uplinks are only taken out of service when forced
+	uplinkURI := switchURI + "/Ports/" + capmodel.PortID("1", "101", "eth1/2")
+	uplinkOOSPathDN := "uni/fabric/outofsvc/rsoosPath-[topology/pod-1/paths-101/pathep-[eth1/2]]"
+	if err := capmodel.ModifyPort(uplinkURI, func(port *dmtfmodel.Port) {
+		setConnectedSwitchPorts(port, []dmtfmodel.Link{{Oid: switchURI + "/Ports/" + capmodel.PortID("1", "102", "eth1/2")}})
+	}); err != nil {
+		t.Fatalf("failed to link the uplink port: %v", err)
+	}
+	setPortState("eth1/2", "down", "admin-down")
+	e.PATCH(uplinkURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"InterfaceEnabled": false}).
+		Expect().Status(http.StatusConflict)
+	assertNoACIObject(t, apic, uplinkOOSPathDN)
+	// nothing of a rejected request is applied on APIC
+	e.PATCH(uplinkURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"InterfaceEnabled": false,
+		"Description":      "uplink",
+	}).Expect().Status(http.StatusConflict)
+	assertNoACIObject(t, apic, uplinkOOSPathDN)
+	assertNoACIObject(t, apic, "uni/infra/hpaths-"+capmodel.PortPolicyName("1", "101", "eth1/2"))
+	e.PATCH(uplinkURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"InterfaceEnabled": false,
+		"Oem":              map[string]interface{}{"Cisco": map[string]interface{}{"Force": true}},
+	}).Expect().Status(http.StatusOK).JSON().Object().Value("InterfaceEnabled").Equal(false)
+	assertACIObject(t, apic, uplinkOOSPathDN, "fabricRsOosPath")
+
+	setPortState("eth1/2", "up", "none")
+	e.PATCH(uplinkURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"InterfaceEnabled": true}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("LinkStatus").Equal("LinkUp")
+	assertNoACIObject(t, apic, uplinkOOSPathDN)
+}
+
+func TestPortSettings(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		apic.AddObject("ethpmPortCap", map[string]interface{}{"dn": "topology/pod-1/node-101/sys/phys-[eth1/1]/phys/portcap", "speed": "10G,25G,auto"})
+		// the ports 1 to 10 of the leaves take the policy group of the servers
+		apic.AddObject("l2InstPol", map[string]interface{}{"dn": "uni/fabric/l2pol-default", "fabricMtu": "9000"})
+		apic.AddObject("infraNodeBlk", map[string]interface{}{"dn": "uni/infra/nprof-leaves/leaves-all-typ-range/nodeblk-all", "from_": "101", "to_": "102"})
+		apic.AddObject("infraRsAccPortP", map[string]interface{}{"dn": "uni/infra/nprof-leaves/rsaccPortP-[uni/infra/accportprof-servers]", "tDn": "uni/infra/accportprof-servers"})
+		apic.AddObject("infraPortBlk", map[string]interface{}{
+			"dn":       "uni/infra/accportprof-servers/hports-servers-typ-range/portblk-servers",
+			"fromCard": "1", "toCard": "1", "fromPort": "1", "toPort": "10",
+		})
+		apic.AddObject("infraRsAccBaseGrp", map[string]interface{}{
+			"dn":  "uni/infra/accportprof-servers/hports-servers-typ-range/rsaccBaseGrp",
+			"tDn": "uni/infra/funcprof/accportgrp-servers",
+		})
+		apic.AddObject("infraAccPortGrp", map[string]interface{}{"dn": "uni/infra/funcprof/accportgrp-servers", "name": "servers"})
+		apic.AddObject("infraRsAttEntP", map[string]interface{}{"dn": "uni/infra/funcprof/accportgrp-servers/rsattEntP", "tDn": "uni/infra/attentp-servers"})
+		apic.AddObject("infraRsCdpIfPol", map[string]interface{}{"dn": "uni/infra/funcprof/accportgrp-servers/rscdpIfPol", "tnCdpIfPolName": "cdp-on"})
+		apic.AddObject("infraRsHIfPol", map[string]interface{}{"dn": "uni/infra/funcprof/accportgrp-servers/rshIfPol", "tnFabricHIfPolName": "10G"})
+	})
+
+	e := httptest.New(t, routers())
+	switchURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1/Switches/" + capmodel.SwitchID("1", "SAL101", "101")
+	portURI := switchURI + "/Ports/" + capmodel.PortID("1", "101", "eth1/1")
+	linkConfiguration := func(autoNeg bool, speedGbps float64) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"AutoSpeedNegotiationEnabled": autoNeg,
+			"CapableLinkSpeedGbps":        []interface{}{map[string]interface{}{"ConfiguredLinkSpeedGbps": []float64{speedGbps}}},
+		}}
+	}
+	port := e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"MaxFrameSize":      1500,
+		"Description":       "server-1 eth0",
+		"LinkConfiguration": linkConfiguration(false, 25),
+	}).Expect().Status(http.StatusOK).JSON().Object()
+	port.Value("MaxFrameSize").Equal(1500)
+	port.Value("Description").Equal("server-1 eth0")
+	port.Value("LinkConfiguration").Array().Element(0).Object().Value("CapableLinkSpeedGbps").Array().Element(0).Object().
+		Value("ConfiguredLinkSpeedGbps").Array().Element(0).Equal(25)
+
+	overrideDN := "uni/infra/hpaths-ODIM-Pod1-Node101-eth1-1"
+	policyGroupDN := "uni/infra/funcprof/accportgrp-ODIM-Pod1-Node101-eth1-1"
+	if descr := assertACIObject(t, apic, overrideDN, "infraHPathS").Attributes["descr"]; descr != "server-1 eth0" {
+		t.Errorf("interface override should hold the description of the port, got %v", descr)
+	}
+	assertACIObject(t, apic, overrideDN+"/rsHPathAtt-[topology/pod-1/paths-101/pathep-[eth1/1]]", "infraRsHPathAtt")
+	if tDn := assertACIObject(t, apic, overrideDN+"/rspathToAccBaseGrp", "infraRsPathToAccBaseGrp").Attributes["tDn"]; tDn != policyGroupDN {
+		t.Errorf("interface override should relate to the policy group of the port, got %v", tDn)
+	}
+	assertACIObject(t, apic, policyGroupDN, "infraAccPortGrp")
+	if name := assertACIObject(t, apic, policyGroupDN+"/rshIfPol", "infraRsHIfPol").Attributes["tnFabricHIfPolName"]; name != "ODIM-LinkLevel-25G-AutoNeg-off" {
+		t.Errorf("policy group should relate to the link level policy of the port, got %v", name)
+	}
+	linkLevelPolicy := assertACIObject(t, apic, "uni/infra/hintfpol-ODIM-LinkLevel-25G-AutoNeg-off", "fabricHIfPol")
+	if linkLevelPolicy.Attributes["speed"] != "25G" || linkLevelPolicy.Attributes["autoNeg"] != "off" {
+		t.Errorf("link level policy should set the speed and the autonegotiation, got %v", linkLevelPolicy.Attributes)
+	}
+	if name := assertACIObject(t, apic, policyGroupDN+"/rsl2InstPol", "infraRsL2InstPol").Attributes["tnL2InstPolName"]; name != "ODIM-MTU-1500" {
+		t.Errorf("policy group should relate to the MTU policy of the port, got %v", name)
+	}
+	if mtu := assertACIObject(t, apic, "uni/fabric/l2pol-ODIM-MTU-1500", "l2InstPol").Attributes["fabricMtu"]; mtu != "1500" {
+		t.Errorf("MTU policy should set the MTU, got %v", mtu)
+	}
+	// the policy group of the port keeps the AEP and the other policies of the policy group of its access port selector
+	if tDn := assertACIObject(t, apic, policyGroupDN+"/rsattEntP", "infraRsAttEntP").Attributes["tDn"]; tDn != "uni/infra/attentp-servers" {
+		t.Errorf("policy group should relate to the AEP of the port, got %v", tDn)
+	}
+	if name := assertACIObject(t, apic, policyGroupDN+"/rscdpIfPol", "infraRsCdpIfPol").Attributes["tnCdpIfPolName"]; name != "cdp-on" {
+		t.Errorf("policy group should relate to the CDP policy of the port, got %v", name)
+	}
+
+	// the ports whose policy group is overridden can't join an endpoint
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	e.POST(fabricURI+"/Endpoints").WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(mockEndpointRequest("ep1", portURI)).
+		Expect().Status(http.StatusConflict)
+
+	// the speed is kept when only the autonegotiation is changed
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"LinkConfiguration": []interface{}{map[string]interface{}{"AutoSpeedNegotiationEnabled": true}},
+	}).Expect().Status(http.StatusOK)
+	if name := assertACIObject(t, apic, policyGroupDN+"/rshIfPol", "infraRsHIfPol").Attributes["tnFabricHIfPolName"]; name != "ODIM-LinkLevel-25G-AutoNeg-on" {
+		t.Errorf("policy group should relate to the link level policy with autonegotiation, got %v", name)
+	}
+
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"LinkConfiguration": linkConfiguration(false, 40),
+	}).Expect().Status(http.StatusBadRequest)
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"MaxFrameSize": 10000}).
+		Expect().Status(http.StatusBadRequest)
+	e.GET(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("MaxFrameSize").Equal(1500)
+
+	// the port takes back the policy group of its access port selector once its settings are back to the ones of the group
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{
+		"MaxFrameSize":      9000,
+		"LinkConfiguration": linkConfiguration(true, 0),
+	}).Expect().Status(http.StatusOK)
+	assertACIObject(t, apic, overrideDN, "infraHPathS")
+	assertNoACIObject(t, apic, overrideDN+"/rspathToAccBaseGrp")
+	assertNoACIObject(t, apic, policyGroupDN)
+	e.PATCH(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"Description": ""}).
+		Expect().Status(http.StatusOK)
+	assertNoACIObject(t, apic, overrideDN)
+
+	// the description alone doesn't override the policies of the port
+	otherPortURI := switchURI + "/Ports/" + capmodel.PortID("1", "101", "eth1/2")
+	otherOverrideDN := "uni/infra/hpaths-ODIM-Pod1-Node101-eth1-2"
+	e.PATCH(otherPortURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"Description": "spare"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("Description").Equal("spare")
+	assertACIObject(t, apic, otherOverrideDN, "infraHPathS")
+	assertNoACIObject(t, apic, otherOverrideDN+"/rspathToAccBaseGrp")
+
+	// the speed and MTU of the fabric uplinks are not configurable
+	if err := capmodel.ModifyPort(otherPortURI, func(port *dmtfmodel.Port) {
+		setConnectedSwitchPorts(port, []dmtfmodel.Link{{Oid: switchURI + "/Ports/" + capmodel.PortID("1", "102", "eth1/2")}})
+	}); err != nil {
+		t.Fatalf("failed to link the uplink port: %v", err)
+	}
+	e.PATCH(otherPortURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).WithJSON(map[string]interface{}{"MaxFrameSize": 9000}).
+		Expect().Status(http.StatusConflict)
+	assertNoACIObject(t, apic, "uni/fabric/l2pol-ODIM-MTU-9000")
+}
@@ -0,0 +1,268 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/caphandler"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+// resourceIDsMigration is the migration giving the switches, chassis and ports the IDs derived from their ACI identity
+const resourceIDsMigration = "DeterministicResourceIDs"
+
+// migrationRetryInterval is the time waited before a failed migration is run again
+var migrationRetryInterval = 10 * time.Second
+
+// publishEvents sends the events to ODIM
+var publishEvents = caphandler.PublishEvents
+
+// migrateResourceIDs rewrites the switches, chassis and ports stored with the random IDs given by the earlier versions
+// under the IDs derived from their ACI identity, the links to them are rewritten as well and ODIM is told about the new URIs.
+// The migration is done once, its completion is recorded in the DB
+func migrateResourceIDs() error {
+	done, err := capmodel.IsMigrationDone(resourceIDsMigration)
+	if err != nil || done {
+		return err
+	}
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		return err
+	}
+	for fabricID := range allFabric {
+		if err := migrateFabricResourceIDs(fabricID); err != nil {
+			return fmt.Errorf("migrating the resource IDs of fabric %s failed: %v", fabricID, err)
+		}
+	}
+	return capmodel.SetMigrationDone(resourceIDsMigration)
+}
+
+// switchNodeID returns the ID of the node the switch ID ends with
+func switchNodeID(switchID string) string {
+	return switchID[strings.LastIndex(switchID, ":")+1:]
+}
+
+// isMigratedSwitch tells if the switch is stored under the ID derived from its ACI identity
+func isMigratedSwitch(fabricData *capdata.Fabric, switchID string, switchData *dmtfmodel.Switch) bool {
+	return capmodel.SwitchID(fabricData.SwitchPod(switchID), switchData.SerialNumber, switchNodeID(switchID)) == switchID
+}
+
+// uriChange is the URI of a resource before and after the migration
+type uriChange struct {
+	oldURI, newURI string
+}
+
+// migrateFabricResourceIDs migrates the switches of the fabric, the records are stored under the new IDs
+// before the fabric is switched over to them and the old records are deleted last, so that an interrupted
+// migration is completed when it is run again
+func migrateFabricResourceIDs(fabricID string) error {
+	locks, err := capmodel.AcquireLocks("migrate resource IDs", capmodel.FabricLockName(fabricID))
+	if err != nil {
+		return err
+	}
+	defer locks.Release()
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		return err
+	}
+
+	var changes []uriChange
+	var switchIDs []string
+	var cleanups []func() error
+	for _, oldSwitchID := range fabricData.SwitchData {
+		switchData, err := capmodel.GetSwitch(oldSwitchID)
+		if err != nil {
+			if errors.Is(err, db.ErrorKeyNotFound) {
+				switchIDs = append(switchIDs, oldSwitchID)
+				continue
+			}
+			return err
+		}
+		nodeID := switchNodeID(oldSwitchID)
+		podID := fabricData.SwitchPod(oldSwitchID)
+		newSwitchID := capmodel.SwitchID(podID, switchData.SerialNumber, nodeID)
+		switchIDs = append(switchIDs, newSwitchID)
+		if newSwitchID == oldSwitchID {
+			continue
+		}
+		switchChanges, cleanup, err := migrateSwitch(fabricID, podID, nodeID, oldSwitchID, newSwitchID, &switchData)
+		if err != nil {
+			return fmt.Errorf("migrating switch %s failed: %v", oldSwitchID, err)
+		}
+		if fabricData.SwitchPods == nil {
+			fabricData.SwitchPods = make(map[string]string)
+		}
+		delete(fabricData.SwitchPods, oldSwitchID)
+		fabricData.SwitchPods[newSwitchID] = podID
+		changes = append(changes, switchChanges...)
+		cleanups = append(cleanups, cleanup)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// the endpoints link the ports they are made of
+	newURIs := make(map[string]string, len(changes))
+	for _, change := range changes {
+		newURIs[change.oldURI] = change.newURI
+	}
+	endpoints, err := capmodel.GetAllEndpoints(fabricID)
+	if err != nil {
+		return err
+	}
+	for endpointOID, endpointData := range endpoints {
+		if endpointData.Endpoint == nil {
+			continue
+		}
+		changed := false
+		for _, redundancy := range endpointData.Endpoint.Redundancy {
+			for i := range redundancy.RedundancySet {
+				if newURI, ok := newURIs[redundancy.RedundancySet[i].Oid]; ok {
+					redundancy.RedundancySet[i].Oid = newURI
+					changed = true
+				}
+			}
+		}
+		if changed {
+			if err := capmodel.UpdateEndpoint(fabricID, endpointOID, &endpointData); err != nil {
+				return err
+			}
+		}
+	}
+
+	fabricData.SwitchData = switchIDs
+	if err := capmodel.UpdateFabric(fabricID, &fabricData); err != nil {
+		return err
+	}
+	for _, cleanup := range cleanups {
+		if err := cleanup(); err != nil {
+			log.Error("failed to delete the records migrated to the new resource IDs: " + err.Error())
+		}
+	}
+	log.Info(fmt.Sprintf("migrated %d resources of fabric %s to the IDs derived from their ACI identity", len(changes), fabricID))
+
+	// ODIM drops the resources at the old URIs and learns the new ones
+	var events []common.Event
+	for _, change := range changes {
+		events = append(events,
+			newResourceEvent(constants.ResourceRemovedMessageID, "ResourceRemoved", change.oldURI),
+			newResourceEvent(constants.ResourceCreatedMessageID, "ResourceAdded", change.newURI))
+	}
+	if err := publishEvents("Resource URI changed event", events); err != nil {
+		log.Error("failed to publish the URI changes of the resources of fabric " + fabricID + ": " + err.Error())
+	}
+	return nil
+}
+
+// newResourceEvent builds the event telling ODIM about the resource at the uri
+func newResourceEvent(messageID, eventType, uri string) common.Event {
+	return common.Event{
+		EventID:           uuid.NewV4().String(),
+		MessageID:         messageID,
+		EventType:         eventType,
+		OriginOfCondition: &common.Link{Oid: uri},
+	}
+}
+
+// migrateSwitch stores the switch along with its chassis and ports under their new IDs, the URI changes are returned
+// along with the cleanup deleting the old records once nothing refers to them
+func migrateSwitch(fabricID, podID, nodeID, oldSwitchID, newSwitchID string, switchData *dmtfmodel.Switch) ([]uriChange, func() error, error) {
+	var changes []uriChange
+	var oldPortURIs []string
+	portIDs, err := capmodel.GetSwitchPort(oldSwitchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	newSwitchURI := "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + newSwitchID
+	var newPortIDs []string
+	for _, oldPortID := range portIDs {
+		oldPortURI := switchData.ODataID + "/Ports/" + oldPortID
+		port, err := capmodel.GetPort(oldPortURI)
+		if err != nil {
+			return nil, nil, err
+		}
+		port.ID = capmodel.PortID(podID, nodeID, port.PortID)
+		port.ODataID = newSwitchURI + "/Ports/" + port.ID
+		if err := capmodel.UpdatePort(port.ODataID, port); err != nil {
+			return nil, nil, err
+		}
+		newPortIDs = append(newPortIDs, port.ID)
+		oldPortURIs = append(oldPortURIs, oldPortURI)
+		changes = append(changes, uriChange{oldURI: oldPortURI, newURI: port.ODataID})
+	}
+	if err := capmodel.UpdateSwitchPort(newSwitchID, newPortIDs); err != nil {
+		return nil, nil, err
+	}
+
+	oldChassisID := ""
+	if switchData.Links != nil && switchData.Links.Chassis != nil {
+		oldChassisID = path.Base(switchData.Links.Chassis.Oid)
+		chassis, err := capmodel.GetSwitchChassis(oldChassisID)
+		if err != nil {
+			return nil, nil, err
+		}
+		chassis.ID = capmodel.ChassisID(podID, switchData.SerialNumber, oldChassisID[strings.LastIndex(oldChassisID, ":")+1:])
+		chassis.UUID = strings.Split(chassis.ID, ":")[0]
+		chassis.Oid = "/ODIM/v1/Chassis/" + chassis.ID
+		chassis.Links = &dmtfmodel.Links{
+			Switches: []*dmtfmodel.Link{
+				&dmtfmodel.Link{Oid: newSwitchURI},
+			},
+		}
+		if err := capmodel.UpdateSwitchChassis(chassis.ID, &chassis); err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, uriChange{oldURI: switchData.Links.Chassis.Oid, newURI: chassis.Oid})
+		switchData.Links.Chassis.Oid = chassis.Oid
+	}
+
+	oldSwitchURI := switchData.ODataID
+	switchData.ID = newSwitchID
+	switchData.ODataID = newSwitchURI
+	switchData.UUID = strings.Split(newSwitchID, ":")[0]
+	if err := capmodel.UpdateSwitch(newSwitchID, switchData); err != nil {
+		return nil, nil, err
+	}
+	changes = append(changes, uriChange{oldURI: oldSwitchURI, newURI: newSwitchURI})
+
+	cleanup := func() error {
+		for _, oldPortURI := range oldPortURIs {
+			if err := capmodel.DeletePort(oldPortURI); err != nil {
+				return err
+			}
+		}
+		if err := capmodel.DeleteSwitchPort(oldSwitchID); err != nil {
+			return err
+		}
+		if oldChassisID != "" {
+			if err := capmodel.DeleteSwitchChassis(oldChassisID); err != nil {
+				return err
+			}
+		}
+		return capmodel.DeleteSwitch(oldSwitchID)
+	}
+	return changes, cleanup, nil
+}
@@ -0,0 +1,136 @@
+// (C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package main
+
+import (
+	"testing"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/caphandler"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
+	"github.com/ODIM-Project/PluginCiscoACI/db"
+)
+
+func TestMigrateResourceIDs(t *testing.T) {
+	config.SetUpMockConfig(t)
+	db.Connector = db.NewInMemoryConnector()
+	var published []common.Event
+	publishEvents = func(name string, events []common.Event) error {
+		published = append(published, events...)
+		return nil
+	}
+	defer func() { publishEvents = caphandler.PublishEvents }()
+
+	// the records as they were stored with the random IDs
+	fabricID := config.Data.RootServiceUUID + ":1"
+	fabricURI := "/ODIM/v1/Fabrics/" + fabricID
+	oldSwitchID := "6f1e0cf4-4d6b-4b1d-9d67-2a6e4a3b2c11:101"
+	oldChassisID := "0b5e7d0c-7a8e-4f0a-8c7e-8a1f2b3c4d55:1"
+	oldPortID := "9a8b7c6d-1e2f-4a3b-8c4d-5e6f7a8b9c0d:eth1-1"
+	oldSwitchURI := fabricURI + "/Switches/" + oldSwitchID
+	oldPortURI := oldSwitchURI + "/Ports/" + oldPortID
+	capmodel.SaveFabric(fabricID, &capdata.Fabric{SwitchData: []string{oldSwitchID}, PodID: "1"})
+	capmodel.SaveSwitch(oldSwitchID, &dmtfmodel.Switch{
+		ID:           oldSwitchID,
+		ODataID:      oldSwitchURI,
+		SerialNumber: "FDO213407K",
+		Links:        &dmtfmodel.SwitchLinks{Chassis: &dmtfmodel.Link{Oid: "/ODIM/v1/Chassis/" + oldChassisID}},
+	})
+	capmodel.SaveSwitchChassis(oldChassisID, &dmtfmodel.Chassis{ID: oldChassisID, Oid: "/ODIM/v1/Chassis/" + oldChassisID})
+	capmodel.SaveSwitchPort(oldSwitchID, []string{oldPortID})
+	capmodel.SavePort(oldPortURI, &dmtfmodel.Port{ID: oldPortID, ODataID: oldPortURI, PortID: "eth1/1"})
+	capmodel.SaveEndpoint(fabricID, fabricURI+"/Endpoints/1", &capdata.EndpointData{
+		Endpoint: &dmtfmodel.Endpoint{
+			Redundancy: []dmtfmodel.Redundancy{{RedundancySet: []*dmtfmodel.Link{{Oid: oldPortURI}}}},
+		},
+	})
+
+	if err := migrateResourceIDs(); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+
+	newSwitchID := capmodel.SwitchID("1", "FDO213407K", "101")
+	newChassisID := capmodel.ChassisID("1", "FDO213407K", "1")
+	newPortID := capmodel.PortID("1", "101", "eth1/1")
+	newSwitchURI := fabricURI + "/Switches/" + newSwitchID
+	newPortURI := newSwitchURI + "/Ports/" + newPortID
+	fabric, err := capmodel.GetFabric(fabricID)
+	if err != nil || len(fabric.SwitchData) != 1 || fabric.SwitchData[0] != newSwitchID {
+		t.Fatalf("fabric is not switched over to the new switch ID: %+v, %v", fabric, err)
+	}
+	switchData, err := capmodel.GetSwitch(newSwitchID)
+	if err != nil || switchData.ODataID != newSwitchURI || switchData.Links.Chassis.Oid != "/ODIM/v1/Chassis/"+newChassisID {
+		t.Fatalf("unexpected switch data %+v, %v", switchData, err)
+	}
+	chassis, err := capmodel.GetSwitchChassis(newChassisID)
+	if err != nil || len(chassis.Links.Switches) != 1 || chassis.Links.Switches[0].Oid != newSwitchURI {
+		t.Fatalf("unexpected chassis data %+v, %v", chassis, err)
+	}
+	if portIDs, err := capmodel.GetSwitchPort(newSwitchID); err != nil || len(portIDs) != 1 || portIDs[0] != newPortID {
+		t.Fatalf("unexpected switch ports %v, %v", portIDs, err)
+	}
+	if port, err := capmodel.GetPort(newPortURI); err != nil || port.ID != newPortID || port.ODataID != newPortURI {
+		t.Fatalf("unexpected port data %+v, %v", port, err)
+	}
+	endpoint, err := capmodel.GetEndpoints(fabricID, fabricURI+"/Endpoints/1")
+	if err != nil || endpoint.Endpoint.Redundancy[0].RedundancySet[0].Oid != newPortURI {
+		t.Fatalf("endpoint still links the old port: %+v, %v", endpoint.Endpoint, err)
+	}
+
+	// the old records are gone
+	if _, err := capmodel.GetSwitch(oldSwitchID); err == nil {
+		t.Error("old switch record is not deleted")
+	}
+	if _, err := capmodel.GetSwitchChassis(oldChassisID); err == nil {
+		t.Error("old chassis record is not deleted")
+	}
+	if _, err := capmodel.GetSwitchPort(oldSwitchID); err == nil {
+		t.Error("old switch ports record is not deleted")
+	}
+	if _, err := capmodel.GetPort(oldPortURI); err == nil {
+		t.Error("old port record is not deleted")
+	}
+
+	// ODIM is told to drop every old URI and learn the new one
+	removed := make(map[string]bool)
+	added := make(map[string]bool)
+	for _, event := range published {
+		switch event.MessageID {
+		case constants.ResourceRemovedMessageID:
+			removed[event.OriginOfCondition.Oid] = true
+		case constants.ResourceCreatedMessageID:
+			added[event.OriginOfCondition.Oid] = true
+		}
+	}
+	for _, uri := range []string{oldSwitchURI, oldPortURI, "/ODIM/v1/Chassis/" + oldChassisID} {
+		if !removed[uri] {
+			t.Errorf("no ResourceRemoved event for %s", uri)
+		}
+	}
+	for _, uri := range []string{newSwitchURI, newPortURI, "/ODIM/v1/Chassis/" + newChassisID} {
+		if !added[uri] {
+			t.Errorf("no ResourceAdded event for %s", uri)
+		}
+	}
+
+	// the migration is done once
+	published = nil
+	if err := migrateResourceIDs(); err != nil || len(published) != 0 {
+		t.Errorf("second migration run: got %d events, error %v", len(published), err)
+	}
+}
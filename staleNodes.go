@@ -0,0 +1,309 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
+)
+
+// removedFabricStates are the fabricNode states of the nodes taken out of the fabric
+var removedFabricStates = map[string]bool{
+	"decommissioned": true,
+	"disabled":       true,
+}
+
+func getInventoryConf() *config.InventoryConf {
+	if config.Data.InventoryConf == nil {
+		return config.NewInventoryConf()
+	}
+	return config.Data.InventoryConf
+}
+
+// watchRemovedNodes looks for the nodes removed from the fabric at the configured interval until lost is closed
+func watchRemovedNodes(lost <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(getInventoryConf().NodeCheckIntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := reconcileFabricNodes(time.Now()); err != nil {
+			log.Error("failed to look for the nodes removed from the fabric: " + err.Error())
+		}
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcileFabricNodes compares the stored switches with the nodes registered in the fabric.
+// The switch of a node which is decommissioned, or no longer registered with the serial number it was discovered with,
+// is marked Absent along with its chassis and ports, they are deleted once the grace period is over
+func reconcileFabricNodes(now time.Time) error {
+	registeredNodes, err := caputilities.GetFabricNodeData()
+	if err != nil {
+		return err
+	}
+	fabricNodes, err := caputilities.GetFabricNodes()
+	if err != nil {
+		return err
+	}
+	removedNodes := make(map[string]bool)
+	for _, node := range fabricNodes {
+		if removedFabricStates[node.Attributes.FabricSt] {
+			removedNodes[node.Attributes.DN] = true
+		}
+	}
+	presentSwitches := make(map[string]bool)
+	for _, node := range registeredNodes {
+		if !removedNodes[fmt.Sprintf("topology/pod-%s/node-%s", node.PodId, node.NodeId)] {
+			presentSwitches[capmodel.SwitchID(node.PodId, node.Serial, node.NodeId)] = true
+		}
+	}
+
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		return err
+	}
+	gracePeriod := time.Duration(getInventoryConf().PurgeGracePeriodInSeconds) * time.Second
+	for fabricID := range allFabric {
+		if err := reconcileFabricSwitches(fabricID, presentSwitches, now, gracePeriod); err != nil {
+			return fmt.Errorf("reconciling the switches of fabric %s failed: %v", fabricID, err)
+		}
+	}
+	return nil
+}
+
+// reconcileFabricSwitches marks the switches of the fabric which are not present as absent, restores the ones
+// which came back and purges the ones absent for longer than the grace period
+func reconcileFabricSwitches(fabricID string, presentSwitches map[string]bool, now time.Time, gracePeriod time.Duration) error {
+	locks, err := capmodel.AcquireLocks("reconcile fabric nodes", capmodel.FabricLockName(fabricID))
+	if err != nil {
+		return err
+	}
+	defer locks.Release()
+	fabricData, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		return err
+	}
+
+	var switchIDs, purgedSwitchIDs []string
+	changed := false
+	for _, switchID := range fabricData.SwitchData {
+		absentSince, absent := fabricData.AbsentSwitches[switchID]
+		switch {
+		case presentSwitches[switchID] && absent:
+			log.Info("switch " + switchID + " is back in fabric " + fabricID)
+			if err := setSwitchState(switchID, "Enabled"); err != nil {
+				return err
+			}
+			delete(fabricData.AbsentSwitches, switchID)
+			changed = true
+		case presentSwitches[switchID]:
+		case !absent:
+			switchData, err := capmodel.GetSwitch(switchID)
+			if err != nil {
+				return err
+			}
+			if !isMigratedSwitch(&fabricData, switchID, &switchData) {
+				// the switch is not found under its ID before the resource ID migration is completed
+				log.Warn("switch " + switchID + " of fabric " + fabricID + " is not migrated to the deterministic ID, it is not reconciled")
+				break
+			}
+			log.Warn("switch " + switchID + " is no longer in fabric " + fabricID + ", it is marked absent")
+			if err := setSwitchState(switchID, constants.AbsentState); err != nil {
+				return err
+			}
+			if fabricData.AbsentSwitches == nil {
+				fabricData.AbsentSwitches = make(map[string]time.Time)
+			}
+			fabricData.AbsentSwitches[switchID] = now
+			changed = true
+		case now.Sub(absentSince) >= gracePeriod:
+			delete(fabricData.AbsentSwitches, switchID)
+			delete(fabricData.SwitchPods, switchID)
+			purgedSwitchIDs = append(purgedSwitchIDs, switchID)
+			changed = true
+			continue
+		}
+		switchIDs = append(switchIDs, switchID)
+	}
+	if !changed {
+		return nil
+	}
+	fabricData.SwitchData = switchIDs
+	if err := capmodel.UpdateFabric(fabricID, &fabricData); err != nil {
+		return err
+	}
+	if len(purgedSwitchIDs) == 0 {
+		return nil
+	}
+
+	// the fabric no longer lists the purged switches, their records are deleted after it
+	var removedURIs []string
+	purgedPorts := make(map[string]bool)
+	for _, switchID := range purgedSwitchIDs {
+		log.Warn("switch " + switchID + " is absent for longer than the grace period, it is deleted from fabric " + fabricID)
+		uris, portURIs, err := purgeSwitch(switchID)
+		if err != nil {
+			log.Error("failed to delete the records of switch " + switchID + ": " + err.Error())
+		}
+		removedURIs = append(removedURIs, uris...)
+		for _, portURI := range portURIs {
+			purgedPorts[portURI] = true
+		}
+	}
+	if err := flagEndpointsOfPurgedPorts(fabricID, purgedPorts); err != nil {
+		log.Error("failed to flag the endpoints of the deleted ports of fabric " + fabricID + ": " + err.Error())
+	}
+
+	var events []common.Event
+	for _, uri := range removedURIs {
+		events = append(events, newResourceEvent(constants.ResourceRemovedMessageID, "ResourceRemoved", uri))
+	}
+	if err := publishEvents("Resource removed event", events); err != nil {
+		log.Error("failed to publish the removal of the switches of fabric " + fabricID + ": " + err.Error())
+	}
+	return nil
+}
+
+// setSwitchState sets the state of the switch along with its chassis and ports, the switch and the ports
+// which are not absent report the state read from APIC
+func setSwitchState(switchID, state string) error {
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		return err
+	}
+	var status *dmtfmodel.Status
+	if state == constants.AbsentState {
+		status = &dmtfmodel.Status{State: state}
+	}
+	portIDs, err := capmodel.GetSwitchPort(switchID)
+	if err != nil {
+		return err
+	}
+	for _, portID := range portIDs {
+		portURI := switchData.ODataID + "/Ports/" + portID
+		port, err := capmodel.GetPort(portURI)
+		if err != nil {
+			return err
+		}
+		port.Status = status
+		if err := capmodel.UpdatePort(portURI, port); err != nil {
+			return err
+		}
+	}
+	if chassisID := switchChassisID(&switchData); chassisID != "" {
+		chassis, err := capmodel.GetSwitchChassis(chassisID)
+		if err != nil {
+			return err
+		}
+		if chassis.Status == nil {
+			chassis.Status = &dmtfmodel.Status{}
+		}
+		chassis.Status.State = state
+		if err := capmodel.UpdateSwitchChassis(chassisID, &chassis); err != nil {
+			return err
+		}
+	}
+	switchData.Status = status
+	return capmodel.UpdateSwitch(switchID, &switchData)
+}
+
+// purgeSwitch deletes the switch along with its chassis and ports, the URIs of the deleted resources
+// are returned along with the URIs of the deleted ports
+func purgeSwitch(switchID string) ([]string, []string, error) {
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var removedURIs, portURIs []string
+	portIDs, err := capmodel.GetSwitchPort(switchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, portID := range portIDs {
+		portURI := switchData.ODataID + "/Ports/" + portID
+		if err := capmodel.DeletePort(portURI); err != nil {
+			return removedURIs, portURIs, err
+		}
+		portURIs = append(portURIs, portURI)
+		removedURIs = append(removedURIs, portURI)
+	}
+	if err := capmodel.DeleteSwitchPort(switchID); err != nil {
+		return removedURIs, portURIs, err
+	}
+	if chassisID := switchChassisID(&switchData); chassisID != "" {
+		if err := capmodel.DeleteSwitchChassis(chassisID); err != nil {
+			return removedURIs, portURIs, err
+		}
+		removedURIs = append(removedURIs, switchData.Links.Chassis.Oid)
+	}
+	if err := capmodel.DeleteSwitch(switchID); err != nil {
+		return removedURIs, portURIs, err
+	}
+	return append(removedURIs, switchData.ODataID), portURIs, nil
+}
+
+// switchChassisID returns the ID of the chassis linked to the switch
+func switchChassisID(switchData *dmtfmodel.Switch) string {
+	if switchData.Links == nil || switchData.Links.Chassis == nil {
+		return ""
+	}
+	return path.Base(switchData.Links.Chassis.Oid)
+}
+
+// flagEndpointsOfPurgedPorts reports the endpoints made of the deleted ports as offline,
+// they are left for the user to delete as they may still be part of zones
+func flagEndpointsOfPurgedPorts(fabricID string, purgedPorts map[string]bool) error {
+	endpoints, err := capmodel.GetAllEndpoints(fabricID)
+	if err != nil {
+		return err
+	}
+	for endpointOID, endpointData := range endpoints {
+		if endpointData.Endpoint == nil || !endpointUsesPorts(&endpointData, purgedPorts) {
+			continue
+		}
+		log.Warn("endpoint " + endpointOID + " is made of ports which are deleted, it is reported as offline")
+		endpointData.Endpoint.Status = &dmtfmodel.Status{
+			State:  "UnavailableOffline",
+			Health: "Critical",
+		}
+		if err := capmodel.UpdateEndpoint(fabricID, endpointOID, &endpointData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func endpointUsesPorts(endpointData *capdata.EndpointData, ports map[string]bool) bool {
+	for _, redundancy := range endpointData.Endpoint.Redundancy {
+		for _, link := range redundancy.RedundancySet {
+			if link != nil && ports[link.Oid] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// (C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capdata"
+	"github.com/ODIM-Project/PluginCiscoACI/caphandler"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+	"github.com/ODIM-Project/PluginCiscoACI/constants"
+
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func setFabricNodeState(apic *caputilities.MockAPIC, nodeID, fabricState string) {
+	apic.AddObject("fabricNode", map[string]interface{}{
+		"dn":       "topology/pod-1/node-" + nodeID,
+		"id":       nodeID,
+		"role":     "leaf",
+		"serial":   "SAL" + nodeID,
+		"fabricSt": fabricState,
+	})
+}
+
+func assertSwitchState(t *testing.T, switchID, state string) {
+	t.Helper()
+	switchData, err := capmodel.GetSwitch(switchID)
+	if err != nil {
+		t.Fatalf("switch %s is not stored: %v", switchID, err)
+	}
+	if (state == constants.AbsentState) != (switchData.Status != nil && switchData.Status.State == state) {
+		t.Errorf("switch %s: unexpected status %+v, want state %s", switchID, switchData.Status, state)
+	}
+	chassis, err := capmodel.GetSwitchChassis(switchChassisID(&switchData))
+	if err != nil {
+		t.Fatalf("chassis of switch %s is not stored: %v", switchID, err)
+	}
+	if chassis.Status == nil || chassis.Status.State != state {
+		t.Errorf("chassis of switch %s: unexpected status %+v, want state %s", switchID, chassis.Status, state)
+	}
+	portIDs, _ := capmodel.GetSwitchPort(switchID)
+	for _, portID := range portIDs {
+		port, err := capmodel.GetPort(switchData.ODataID + "/Ports/" + portID)
+		if err != nil {
+			t.Fatalf("port %s is not stored: %v", portID, err)
+		}
+		if (state == constants.AbsentState) != (port.Status != nil && port.Status.State == state) {
+			t.Errorf("port %s: unexpected status %+v, want state %s", portID, port.Status, state)
+		}
+	}
+}
+
+func TestReconcileFabricNodes(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+	config.Data.InventoryConf = &config.InventoryConf{NodeCheckIntervalInSeconds: 300, PurgeGracePeriodInSeconds: 3600}
+	var published []common.Event
+	publishEvents = func(name string, events []common.Event) error {
+		published = append(published, events...)
+		return nil
+	}
+	defer func() { publishEvents = caphandler.PublishEvents }()
+
+	fabricID := config.Data.RootServiceUUID + ":1"
+	leaf101 := capmodel.SwitchID("1", "SAL101", "101")
+	leaf102 := capmodel.SwitchID("1", "SAL102", "102")
+	leaf102Data, err := capmodel.GetSwitch(leaf102)
+	if err != nil {
+		t.Fatalf("switch %s is not stored: %v", leaf102, err)
+	}
+	leaf102Chassis := leaf102Data.Links.Chassis.Oid
+	leaf102Ports, _ := capmodel.GetSwitchPort(leaf102)
+	portURI := leaf102Data.ODataID + "/Ports/" + leaf102Ports[0]
+	endpointURI := "/ODIM/v1/Fabrics/" + fabricID + "/Endpoints/1"
+	capmodel.SaveEndpoint(fabricID, endpointURI, &capdata.EndpointData{
+		Endpoint: &dmtfmodel.Endpoint{
+			Redundancy: []dmtfmodel.Redundancy{{RedundancySet: []*dmtfmodel.Link{{Oid: portURI}}}},
+		},
+	})
+
+	// both the leaves are taken out of the fabric
+	setFabricNodeState(apic, "101", "disabled")
+	setFabricNodeState(apic, "102", "decommissioned")
+	now := time.Now()
+	if err := reconcileFabricNodes(now); err != nil {
+		t.Fatalf("reconciling fabric nodes failed: %v", err)
+	}
+	assertSwitchState(t, leaf101, constants.AbsentState)
+	assertSwitchState(t, leaf102, constants.AbsentState)
+
+	e := httptest.New(t, routers())
+	switchResp := e.GET("/ODIM/v1/Fabrics/"+fabricID+"/Switches/"+leaf102).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect()
+	switchResp.Status(http.StatusOK).JSON().Object().Value("Status").Object().Value("State").Equal(constants.AbsentState)
+	e.GET(portURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Status").Object().Value("State").Equal(constants.AbsentState)
+
+	// leaf 101 is back before the grace period is over
+	setFabricNodeState(apic, "101", "active")
+	if err := reconcileFabricNodes(now.Add(30 * time.Minute)); err != nil {
+		t.Fatalf("reconciling fabric nodes failed: %v", err)
+	}
+	assertSwitchState(t, leaf101, "Enabled")
+	assertSwitchState(t, leaf102, constants.AbsentState)
+	if len(published) != 0 {
+		t.Fatalf("no resource is removed within the grace period, got events %+v", published)
+	}
+
+	// leaf 102 is purged once the grace period is over
+	if err := reconcileFabricNodes(now.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("reconciling fabric nodes failed: %v", err)
+	}
+	fabric, err := capmodel.GetFabric(fabricID)
+	if err != nil || len(fabric.SwitchData) != 1 || fabric.SwitchData[0] != leaf101 || len(fabric.AbsentSwitches) != 0 {
+		t.Fatalf("unexpected fabric data after purge %+v, %v", fabric, err)
+	}
+	if _, err := capmodel.GetSwitch(leaf102); err == nil {
+		t.Error("switch record of the purged node is not deleted")
+	}
+	if _, err := capmodel.GetSwitchChassis(switchChassisID(&leaf102Data)); err == nil {
+		t.Error("chassis record of the purged node is not deleted")
+	}
+	if _, err := capmodel.GetPort(portURI); err == nil {
+		t.Error("port record of the purged node is not deleted")
+	}
+	endpoint, err := capmodel.GetEndpoints(fabricID, endpointURI)
+	if err != nil || endpoint.Endpoint.Status == nil || endpoint.Endpoint.Status.State != "UnavailableOffline" {
+		t.Errorf("endpoint made of a purged port is not flagged: %+v, %v", endpoint.Endpoint, err)
+	}
+	removed := make(map[string]bool)
+	for _, event := range published {
+		if event.MessageID == constants.ResourceRemovedMessageID {
+			removed[event.OriginOfCondition.Oid] = true
+		}
+	}
+	for _, uri := range []string{leaf102Data.ODataID, leaf102Chassis, portURI} {
+		if !removed[uri] {
+			t.Errorf("no ResourceRemoved event for %s", uri)
+		}
+	}
+	if len(removed) != len(leaf102Ports)+2 {
+		t.Errorf("got %d ResourceRemoved events, want %d", len(removed), len(leaf102Ports)+2)
+	}
+}
+
+func TestReconcileSkipsUnmigratedSwitches(t *testing.T) {
+	setUpMockPlugin(t, loadMockFabric)
+	config.Data.InventoryConf = &config.InventoryConf{NodeCheckIntervalInSeconds: 300, PurgeGracePeriodInSeconds: 3600}
+
+	// a switch still stored under the random ID given by the earlier versions
+	fabricID := config.Data.RootServiceUUID + ":1"
+	legacySwitchID := "6f1e0cf4-4d6b-4b1d-9d67-2a6e4a3b2c11:101"
+	capmodel.SaveSwitch(legacySwitchID, &dmtfmodel.Switch{
+		ID:           legacySwitchID,
+		ODataID:      "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + legacySwitchID,
+		SerialNumber: "SAL101",
+	})
+	fabric, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		t.Fatalf("fabric %s is not stored: %v", fabricID, err)
+	}
+	fabric.SwitchData = append(fabric.SwitchData, legacySwitchID)
+	capmodel.UpdateFabric(fabricID, &fabric)
+
+	if err := reconcileFabricNodes(time.Now().Add(2 * time.Hour)); err != nil {
+		t.Fatalf("reconciling fabric nodes failed: %v", err)
+	}
+	fabric, err = capmodel.GetFabric(fabricID)
+	if err != nil || fabric.SwitchData[len(fabric.SwitchData)-1] != legacySwitchID || len(fabric.AbsentSwitches) != 0 {
+		t.Fatalf("the unmigrated switch is reconciled: %+v, %v", fabric, err)
+	}
+	switchData, err := capmodel.GetSwitch(legacySwitchID)
+	if err != nil || switchData.Status != nil {
+		t.Errorf("the unmigrated switch is marked absent: %+v, %v", switchData.Status, err)
+	}
+}
@@ -0,0 +1,198 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capmessagebus"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+// metricReportEventType is the event type ODIM expects for the metric reports
+const metricReportEventType = "MetricReport"
+
+// publishMetricReport sends the metric report event on the message bus
+var publishMetricReport = capmessagebus.Publish
+
+func getTelemetryConf() *config.TelemetryConf {
+	if config.Data.TelemetryConf == nil {
+		return config.NewTelemetryConf()
+	}
+	return config.Data.TelemetryConf
+}
+
+// watchMetricReports sends the metric reports of the fabric counters at the configured interval until lost is closed
+func watchMetricReports(lost <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(getTelemetryConf().ReportIntervalInSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := sendMetricReports(); err != nil {
+			log.Error("failed to send the metric reports: " + err.Error())
+		}
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendMetricReports reads the counters from APIC, stores the report of each metric report definition
+// and sends it as a MetricReport event to ODIM
+func sendMetricReports() error {
+	reports, err := collectMetricReports(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		if previous, err := capmodel.GetMetricReport(report.ID); err == nil {
+			sequence, _ := strconv.Atoi(previous.ReportSequence)
+			report.ReportSequence = strconv.Itoa(sequence + 1)
+		}
+		if err := capmodel.UpdateMetricReport(report); err != nil {
+			return err
+		}
+		request, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if !publishMetricReport(common.Events{
+			IP:        config.Data.LoadBalancerConf.Host,
+			Request:   request,
+			EventType: metricReportEventType,
+		}) {
+			return errors.New("the metric report " + report.ID + " couldn't be published on the message bus")
+		}
+	}
+	return nil
+}
+
+// collectMetricReports builds the reports of all the metric report definitions, the metrics of the ports
+// and the switches which aren't registered in the fabric are left out. The reports whose counters
+// couldn't be read from APIC are left out as well, they don't keep the other reports from being built
+func collectMetricReports(now time.Time) ([]*dmtfmodel.MetricReports, error) {
+	nodePorts, err := getFabricNodePorts()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := now.Format(time.RFC3339)
+	reports := make(map[string]*dmtfmodel.MetricReports, len(capmodel.MetricReportDefinitionIDs))
+	addReports := func(definitionIDs ...string) {
+		for _, definitionID := range definitionIDs {
+			definition, _ := capmodel.GetMetricReportDefinition(definitionID, getTelemetryConf().ReportIntervalInSeconds)
+			reports[definitionID] = &dmtfmodel.MetricReports{
+				ODataContext:           "/ODIM/v1/$metadata#MetricReport.MetricReport",
+				ODataID:                capmodel.TelemetryServiceURI + "/MetricReports/" + definitionID,
+				ODataType:              "#MetricReport.v1_4_2.MetricReport",
+				ID:                     definitionID,
+				Name:                   definition.Name,
+				MetricReportDefinition: dmtfmodel.Oid{ODataID: definition.ODataID},
+				ReportSequence:         "1",
+				Timestamp:              timestamp,
+			}
+		}
+	}
+	addValue := func(reportID, metricID, property, value string) {
+		reports[reportID].MetricValues = append(reports[reportID].MetricValues, dmtfmodel.MetricValue{
+			MetricID:       metricID,
+			MetricProperty: property,
+			MetricValue:    value,
+			Timestamp:      timestamp,
+		})
+	}
+
+	if allStats, err := caputilities.GetAllPortStats(); err != nil {
+		log.Error("failed to read the port stats, the interface metric reports are left out: " + err.Error())
+	} else {
+		addReports(capmodel.InterfaceUtilizationReport, capmodel.InterfaceErrorsReport)
+		physIfDNs := make([]string, 0, len(allStats))
+		for physIfDN := range allStats {
+			physIfDNs = append(physIfDNs, physIfDN)
+		}
+		sort.Strings(physIfDNs)
+		for _, physIfDN := range physIfDNs {
+			_, nodeID, portName := capmodel.PhysIfPort(physIfDN)
+			portURI := nodePorts.portURI(nodeID, portName)
+			if portURI == "" {
+				continue
+			}
+			stats := allStats[physIfDN]
+			metricsProperty := portURI + "/Metrics#"
+			if stats.Ingress != nil {
+				addValue(capmodel.InterfaceUtilizationReport, capmodel.RXUtilizationMetric, metricsProperty+"/Oem/Cisco/RXUtilizationPercent", stats.Ingress.UtilAvg)
+			}
+			if stats.Egress != nil {
+				addValue(capmodel.InterfaceUtilizationReport, capmodel.TXUtilizationMetric, metricsProperty+"/Oem/Cisco/TXUtilizationPercent", stats.Egress.UtilAvg)
+			}
+			if stats.IfIn != nil {
+				addValue(capmodel.InterfaceErrorsReport, capmodel.RXErrorsMetric, metricsProperty+"/RXErrors", stats.IfIn.Errors)
+			}
+			if stats.IfOut != nil {
+				addValue(capmodel.InterfaceErrorsReport, capmodel.TXErrorsMetric, metricsProperty+"/TXErrors", stats.IfOut.Errors)
+			}
+			if stats.EtherStats != nil {
+				addValue(capmodel.InterfaceErrorsReport, capmodel.RXCRCErrorsMetric, metricsProperty+"/Networking/RXCRCErrors", stats.EtherStats.CRCAlignErrors)
+			}
+		}
+	}
+
+	if cpuUsages, err := caputilities.GetSwitchCPUUsages(); err != nil {
+		log.Error("failed to read the switch CPU usages, the CPU metric report is left out: " + err.Error())
+	} else {
+		addReports(capmodel.SwitchCPUUtilizationReport)
+		sort.Slice(cpuUsages, func(i, j int) bool { return cpuUsages[i].DN < cpuUsages[j].DN })
+		for _, usage := range cpuUsages {
+			_, nodeID := capmodel.NodeOfDN(usage.DN)
+			if switchURI := nodePorts.switchURI(nodeID); switchURI != "" {
+				addValue(capmodel.SwitchCPUUtilizationReport, capmodel.CPUUtilizationMetric, switchURI, formatPercent(usage.CPUUtilization()))
+			}
+		}
+	}
+
+	if memoryUsages, err := caputilities.GetSwitchMemoryUsages(); err != nil {
+		log.Error("failed to read the switch memory usages, the memory metric report is left out: " + err.Error())
+	} else {
+		addReports(capmodel.SwitchMemoryUtilizationReport)
+		sort.Slice(memoryUsages, func(i, j int) bool { return memoryUsages[i].DN < memoryUsages[j].DN })
+		for _, usage := range memoryUsages {
+			_, nodeID := capmodel.NodeOfDN(usage.DN)
+			if switchURI := nodePorts.switchURI(nodeID); switchURI != "" {
+				addValue(capmodel.SwitchMemoryUtilizationReport, capmodel.MemoryUtilizationMetric, switchURI, formatPercent(usage.MemoryUtilization()))
+			}
+		}
+	}
+
+	orderedReports := make([]*dmtfmodel.MetricReports, 0, len(reports))
+	for _, definitionID := range capmodel.MetricReportDefinitionIDs {
+		if report, ok := reports[definitionID]; ok {
+			orderedReports = append(orderedReports, report)
+		}
+	}
+	return orderedReports, nil
+}
+
+// formatPercent formats the percentage with at most 2 decimals
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', 2, 64)
+}
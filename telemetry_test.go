@@ -0,0 +1,143 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/ODIM/lib-utilities/common"
+	"github.com/ODIM-Project/PluginCiscoACI/capmessagebus"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func TestSendMetricReports(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric)
+
+	portDN := "topology/pod-1/node-101/sys/phys-[eth1/1]"
+	apic.AddObject("eqptIngrTotal5min", map[string]interface{}{"dn": portDN + "/CDeqptIngrTotal5min", "utilAvg": "12.5"})
+	apic.AddObject("eqptEgrTotal5min", map[string]interface{}{"dn": portDN + "/CDeqptEgrTotal5min", "utilAvg": "3"})
+	apic.AddObject("rmonIfIn", map[string]interface{}{
+		"dn": portDN + "/dbgIfIn", "octets": "100", "ucastPkts": "1", "multicastPkts": "0", "broadcastPkts": "0", "discards": "0", "errors": "5",
+	})
+	apic.AddObject("rmonEtherStats", map[string]interface{}{"dn": portDN + "/dbgEtherStats", "cRCAlignErrors": "7", "dropEvents": "0"})
+	// the stats of the port channels and of the unregistered nodes aren't reported
+	apic.AddObject("eqptIngrTotal5min", map[string]interface{}{"dn": "topology/pod-1/node-101/sys/aggr-[po1]/CDeqptIngrTotal5min", "utilAvg": "50"})
+	apic.AddObject("eqptIngrTotal5min", map[string]interface{}{"dn": "topology/pod-1/node-999/sys/phys-[eth1/1]/CDeqptIngrTotal5min", "utilAvg": "50"})
+	apic.AddObject("procSysCPU5min", map[string]interface{}{"dn": "topology/pod-1/node-102/sys/procsys/CDprocSysCPU5min", "userAvg": "10.5", "kernelAvg": "4"})
+	apic.AddObject("procSysMem5min", map[string]interface{}{"dn": "topology/pod-1/node-102/sys/procsys/CDprocSysMem5min", "usedAvg": "4000", "totalAvg": "16000"})
+	// the usage with a non numeric average is skipped
+	apic.AddObject("procSysCPU5min", map[string]interface{}{"dn": "topology/pod-1/node-101/sys/procsys/CDprocSysCPU5min", "userAvg": "n/a", "kernelAvg": "4"})
+
+	var published []common.Events
+	publishMetricReport = func(data interface{}) bool {
+		published = append(published, data.(common.Events))
+		return true
+	}
+	defer func() { publishMetricReport = capmessagebus.Publish }()
+	if err := sendMetricReports(); err != nil {
+		t.Fatalf("sendMetricReports failed: %v", err)
+	}
+	if len(published) != len(capmodel.MetricReportDefinitionIDs) {
+		t.Fatalf("got %d published metric reports, want %d", len(published), len(capmodel.MetricReportDefinitionIDs))
+	}
+	reports := make(map[string]dmtfmodel.MetricReports)
+	for _, event := range published {
+		if event.EventType != "MetricReport" {
+			t.Errorf("got event type %s, want MetricReport", event.EventType)
+		}
+		var report dmtfmodel.MetricReports
+		if err := json.Unmarshal(event.Request, &report); err != nil {
+			t.Fatalf("published metric report is not valid: %v", err)
+		}
+		reports[report.ID] = report
+	}
+
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	switchURI := func(nodeID string) string {
+		return fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL"+nodeID, nodeID)
+	}
+	metricsURI := switchURI("101") + "/Ports/" + capmodel.PortID("1", "101", "eth1/1") + "/Metrics"
+	assertMetricValues := func(reportID string, want ...[3]string) {
+		t.Helper()
+		values := reports[reportID].MetricValues
+		if len(values) != len(want) {
+			t.Fatalf("report %s: got metric values %+v, want %v", reportID, values, want)
+		}
+		for i, value := range values {
+			if value.MetricID != want[i][0] || value.MetricProperty != want[i][1] || value.MetricValue != want[i][2] {
+				t.Errorf("report %s: got metric value %+v, want %v", reportID, value, want[i])
+			}
+		}
+	}
+	assertMetricValues(capmodel.InterfaceUtilizationReport,
+		[3]string{"RXUtilizationPercent", metricsURI + "#/Oem/Cisco/RXUtilizationPercent", "12.5"},
+		[3]string{"TXUtilizationPercent", metricsURI + "#/Oem/Cisco/TXUtilizationPercent", "3"})
+	assertMetricValues(capmodel.InterfaceErrorsReport,
+		[3]string{"RXErrors", metricsURI + "#/RXErrors", "5"},
+		[3]string{"RXCRCErrors", metricsURI + "#/Networking/RXCRCErrors", "7"})
+	assertMetricValues(capmodel.SwitchCPUUtilizationReport, [3]string{"CPUUtilizationPercent", switchURI("102"), "14.50"})
+	assertMetricValues(capmodel.SwitchMemoryUtilizationReport, [3]string{"MemoryUtilizationPercent", switchURI("102"), "25.00"})
+
+	// the stored reports are served by the telemetry service and numbered in sequence
+	if err := sendMetricReports(); err != nil {
+		t.Fatalf("sendMetricReports failed: %v", err)
+	}
+	e := httptest.New(t, routers())
+	telemetryURI := "/ODIM/v1/TelemetryService"
+	e.GET(telemetryURI).WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("MetricReports").Object().Value("@odata.id").Equal(telemetryURI + "/MetricReports")
+	e.GET(telemetryURI+"/MetricReportDefinitions").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Members@odata.count").Equal(4)
+	definition := e.GET(telemetryURI+"/MetricReportDefinitions/InterfaceErrors").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object()
+	definition.Value("MetricReport").Object().Value("@odata.id").Equal(telemetryURI + "/MetricReports/InterfaceErrors")
+	definition.Value("Schedule").Object().Value("RecurrenceInterval").Equal("PT300S")
+	e.GET(telemetryURI+"/MetricReportDefinitions/Unknown").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotFound)
+	e.GET(telemetryURI+"/MetricReports").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Members@odata.count").Equal(4)
+	report := e.GET(telemetryURI+"/MetricReports/SwitchCPUUtilization").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object()
+	report.Value("ReportSequence").Equal("2")
+	report.Value("MetricValues").Array().Length().Equal(1)
+	e.GET(telemetryURI+"/MetricReports/Unknown").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusNotFound)
+
+	// the other reports are sent when a class query fails
+	caputilities.APICTransport = &failingClassTransport{className: "procSysMem5min", failures: 1}
+	defer func() { caputilities.APICTransport = nil }()
+	published = nil
+	if err := sendMetricReports(); err != nil {
+		t.Fatalf("sendMetricReports failed: %v", err)
+	}
+	if len(published) != len(capmodel.MetricReportDefinitionIDs)-1 {
+		t.Fatalf("got %d published metric reports, want all but the memory report", len(published))
+	}
+	for _, event := range published {
+		if strings.Contains(string(event.Request), capmodel.SwitchMemoryUtilizationReport) {
+			t.Errorf("the memory report is published although its usages couldn't be read: %s", event.Request)
+		}
+	}
+	e.GET(telemetryURI+"/MetricReports/SwitchCPUUtilization").WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object().Value("ReportSequence").Equal("3")
+	e.GET(telemetryURI+"/MetricReports/"+capmodel.SwitchMemoryUtilizationReport).WithBasicAuth(mockPluginUserName, mockPluginPassword).
+		Expect().Status(http.StatusOK).JSON().Object().Value("ReportSequence").Equal("2")
+}
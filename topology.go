@@ -0,0 +1,245 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	dmtfmodel "github.com/ODIM-Project/ODIM/lib-dmtf/model"
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+)
+
+// fabricLinkUp is the fabricLink state of the links which are operational
+const fabricLinkUp = "ok"
+
+// fabricLinkClasses are the classes of the APIC objects telling the links between the fabric ports
+var fabricLinkClasses = []string{"fabricLink", "lldpAdjEp"}
+
+// linkRefreshRetryInterval is the time waited before a failed refresh of the links is run again
+var linkRefreshRetryInterval = 10 * time.Second
+
+// watchFabricLinks refreshes the links between the fabric ports whenever APIC notifies a change
+// of the fabric links or of the LLDP neighbors until lost is closed
+func watchFabricLinks(lost <-chan struct{}) {
+	// the notifications received during a refresh are handled by a single refresh
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	go caputilities.WatchAPICClasses(lost, fabricLinkClasses, notify, func(string) { notify() })
+	for {
+		select {
+		case <-lost:
+			return
+		case <-changed:
+		}
+		if err := refreshFabricLinks(); err != nil {
+			log.Error("failed to refresh the links between the fabric ports, retrying: " + err.Error())
+			time.AfterFunc(linkRefreshRetryInterval, notify)
+		}
+	}
+}
+
+// fabricNodePorts gives the uris of the ports of the nodes registered in the fabric
+type fabricNodePorts struct {
+	portURIPrefixes map[string]string
+	nodePods        map[string]string
+	// nodesByName gives the node IDs by the names the nodes advertise to their neighbors
+	nodesByName map[string]string
+}
+
+// getFabricNodePorts reads the nodes registered in the fabric, the node IDs are unique across the pods
+func getFabricNodePorts() (*fabricNodePorts, error) {
+	registeredNodes, err := caputilities.GetFabricNodeData()
+	if err != nil {
+		return nil, err
+	}
+	nodePorts := &fabricNodePorts{
+		portURIPrefixes: make(map[string]string, len(registeredNodes)),
+		nodePods:        make(map[string]string, len(registeredNodes)),
+		nodesByName:     make(map[string]string, len(registeredNodes)),
+	}
+	for _, node := range registeredNodes {
+		fabricID := config.Data.RootServiceUUID + ":" + node.FabricId
+		nodePorts.portURIPrefixes[node.NodeId] = "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + capmodel.SwitchID(node.PodId, node.Serial, node.NodeId) + "/Ports/"
+		nodePorts.nodePods[node.NodeId] = node.PodId
+		nodePorts.nodesByName[node.Name] = node.NodeId
+	}
+	return nodePorts, nil
+}
+
+// portURI returns the uri of the port of the node, it is empty when the node is not registered
+func (f *fabricNodePorts) portURI(nodeID, portName string) string {
+	prefix, ok := f.portURIPrefixes[nodeID]
+	if !ok || portName == "" {
+		return ""
+	}
+	return prefix + capmodel.PortID(f.nodePods[nodeID], nodeID, portName)
+}
+
+// switchURI returns the uri of the switch of the node, it is empty when the node is not registered
+func (f *fabricNodePorts) switchURI(nodeID string) string {
+	return strings.TrimSuffix(f.portURIPrefixes[nodeID], "/Ports/")
+}
+
+// refreshFabricLinks reads the fabric links and the LLDP neighbors of the switch ports from APIC
+// and links the stored ports to the ports and the switches at the other end of their cables,
+// only the ports whose adjacency changed are updated
+func refreshFabricLinks() error {
+	nodePorts, err := getFabricNodePorts()
+	if err != nil {
+		return err
+	}
+	portURI := nodePorts.portURI
+
+	links, err := caputilities.GetFabricLinks()
+	if err != nil {
+		return err
+	}
+	adjacencies, err := caputilities.GetLLDPAdjacencies()
+	if err != nil {
+		return err
+	}
+	connections := make(map[string]map[string]bool)
+	connect := func(port1, port2 string) {
+		if port1 == "" || port2 == "" {
+			return
+		}
+		for _, ends := range [][2]string{{port1, port2}, {port2, port1}} {
+			if connections[ends[0]] == nil {
+				connections[ends[0]] = make(map[string]bool)
+			}
+			connections[ends[0]][ends[1]] = true
+		}
+	}
+	for _, link := range links {
+		if link.LinkState == fabricLinkUp {
+			connect(portURI(link.N1, link.Port1()), portURI(link.N2, link.Port2()))
+		}
+	}
+	// LLDP tells the neighbors of the links fabricLink doesn't report yet, the ones seen outside
+	// of the fabric like the servers and the external routers aren't switch ports of the plugin
+	for _, adjacency := range adjacencies {
+		remoteNodeID, ok := nodePorts.nodesByName[adjacency.SysName]
+		if !ok {
+			continue
+		}
+		_, nodeID, portName := adjacency.LocalPort()
+		connect(portURI(nodeID, portName), portURI(remoteNodeID, adjacency.RemotePort()))
+	}
+
+	allFabric, err := capmodel.GetAllFabric("")
+	if err != nil {
+		return err
+	}
+	for fabricID, fabricData := range allFabric {
+		for _, switchID := range fabricData.SwitchData {
+			if _, absent := fabricData.AbsentSwitches[switchID]; absent {
+				continue
+			}
+			// the links of the other switches are refreshed when a switch fails
+			if err := refreshSwitchPortLinks(fabricID, switchID, connections); err != nil {
+				log.Error("refreshing the links of switch " + switchID + " failed: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// refreshSwitchPortLinks links the ports of the switch to the connected ports, the links are dropped
+// when the connected port is not stored
+func refreshSwitchPortLinks(fabricID, switchID string, connections map[string]map[string]bool) error {
+	portIDs, err := capmodel.GetSwitchPort(switchID)
+	if err != nil {
+		return err
+	}
+	switchURI := "/ODIM/v1/Fabrics/" + fabricID + "/Switches/" + switchID
+	for _, portID := range portIDs {
+		uri := switchURI + "/Ports/" + portID
+		var connectedPorts []dmtfmodel.Link
+		for connectedPort := range connections[uri] {
+			if _, err := capmodel.GetPort(connectedPort); err != nil {
+				continue
+			}
+			connectedPorts = append(connectedPorts, dmtfmodel.Link{Oid: connectedPort})
+		}
+		sort.Slice(connectedPorts, func(i, j int) bool {
+			return connectedPorts[i].Oid < connectedPorts[j].Oid
+		})
+		port, err := capmodel.GetPort(uri)
+		if err != nil {
+			return err
+		}
+		if sameLinks(getConnectedSwitchPorts(port), connectedPorts) {
+			continue
+		}
+		log.Info(fmt.Sprintf("port %s is connected to %v", uri, connectedPorts))
+		err = capmodel.ModifyPort(uri, func(port *dmtfmodel.Port) {
+			setConnectedSwitchPorts(port, connectedPorts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getConnectedSwitchPorts(port *dmtfmodel.Port) []dmtfmodel.Link {
+	if port.Links == nil {
+		return nil
+	}
+	return port.Links.ConnectedSwitchPorts
+}
+
+// setConnectedSwitchPorts links the port to the connected ports and to their switches
+func setConnectedSwitchPorts(port *dmtfmodel.Port, connectedPorts []dmtfmodel.Link) {
+	if port.Links == nil {
+		port.Links = &dmtfmodel.PortLinks{}
+	}
+	var connectedSwitches []dmtfmodel.Link
+	switches := make(map[string]bool)
+	for _, connectedPort := range connectedPorts {
+		switchURI := path.Dir(path.Dir(connectedPort.Oid))
+		if !switches[switchURI] {
+			switches[switchURI] = true
+			connectedSwitches = append(connectedSwitches, dmtfmodel.Link{Oid: switchURI})
+		}
+	}
+	port.Links.ConnectedSwitchPorts = connectedPorts
+	port.Links.ConnectedSwitchPortsCount = len(connectedPorts)
+	port.Links.ConnectedSwitches = connectedSwitches
+	port.Links.ConnectedSwitchesCount = len(connectedSwitches)
+}
+
+func sameLinks(links1, links2 []dmtfmodel.Link) bool {
+	if len(links1) != len(links2) {
+		return false
+	}
+	for i := range links1 {
+		if links1[i].Oid != links2[i].Oid {
+			return false
+		}
+	}
+	return true
+}
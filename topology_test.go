@@ -0,0 +1,176 @@
+//(C) Copyright [2020] Hewlett Packard Enterprise Development LP
+//
+//Licensed under the Apache License, Version 2.0 (the "License"); you may
+//not use this file except in compliance with the License. You may obtain
+//a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+//WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+//License for the specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"net/http"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/ODIM-Project/PluginCiscoACI/capmodel"
+	"github.com/ODIM-Project/PluginCiscoACI/caputilities"
+	"github.com/ODIM-Project/PluginCiscoACI/config"
+
+	"github.com/kataras/iris/v12/httptest"
+)
+
+func assertConnectedPorts(t *testing.T, portURI string, connectedPorts ...string) {
+	t.Helper()
+	port, err := capmodel.GetPort(portURI)
+	if err != nil {
+		t.Fatalf("port %s is not stored: %v", portURI, err)
+	}
+	var got []string
+	for _, link := range getConnectedSwitchPorts(port) {
+		got = append(got, link.Oid)
+	}
+	if len(got) != len(connectedPorts) {
+		t.Fatalf("port %s: got connected ports %v, want %v", portURI, got, connectedPorts)
+	}
+	for i := range got {
+		if got[i] != connectedPorts[i] {
+			t.Fatalf("port %s: got connected ports %v, want %v", portURI, got, connectedPorts)
+		}
+	}
+	if len(connectedPorts) == 0 {
+		return
+	}
+	switchURI := path.Dir(path.Dir(connectedPorts[0]))
+	if port.Links.ConnectedSwitchPortsCount != len(connectedPorts) || len(port.Links.ConnectedSwitches) != 1 || port.Links.ConnectedSwitches[0].Oid != switchURI {
+		t.Errorf("port %s: unexpected links %+v, want switch %s", portURI, port.Links, switchURI)
+	}
+}
+
+func TestRefreshFabricLinks(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		loadMockLeaf(apic, "1", "201")
+	})
+
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	portURI := func(nodeID, portName string) string {
+		return fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL"+nodeID, nodeID) + "/Ports/" + capmodel.PortID("1", nodeID, portName)
+	}
+	linkDN := "topology/pod-1/lnkcnt-201/lnk-101-1-1-to-201-1-1"
+	apic.AddObject("fabricLink", map[string]interface{}{
+		"dn": linkDN, "n1": "101", "s1": "1", "p1": "1", "n2": "201", "s2": "1", "p2": "1", "linkState": "ok",
+	})
+	// the link between 102 and 201 is only known from LLDP, the server seen by 101 isn't a fabric port
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-102/sys/lldp/inst/if-[eth1/2]/adj-1", "sysName": "leaf-201", "portIdV": "Ethernet1/2",
+	})
+	apic.AddObject("lldpAdjEp", map[string]interface{}{
+		"dn": "topology/pod-1/node-101/sys/lldp/inst/if-[eth1/2]/adj-1", "sysName": "server1", "portIdV": "ens1f0",
+	})
+	if err := refreshFabricLinks(); err != nil {
+		t.Fatalf("refreshFabricLinks failed: %v", err)
+	}
+	assertConnectedPorts(t, portURI("101", "eth1/1"), portURI("201", "eth1/1"))
+	assertConnectedPorts(t, portURI("201", "eth1/1"), portURI("101", "eth1/1"))
+	assertConnectedPorts(t, portURI("102", "eth1/2"), portURI("201", "eth1/2"))
+	assertConnectedPorts(t, portURI("201", "eth1/2"), portURI("102", "eth1/2"))
+	assertConnectedPorts(t, portURI("101", "eth1/2"))
+
+	// the topology gives each cable once
+	e := httptest.New(t, routers())
+	cable := map[string]interface{}{"Source": portURI("101", "eth1/1"), "Target": portURI("201", "eth1/1"), "Type": "Cable"}
+	reverseCable := map[string]interface{}{"Source": portURI("201", "eth1/1"), "Target": portURI("101", "eth1/1"), "Type": "Cable"}
+	if portURI("201", "eth1/1") < portURI("101", "eth1/1") {
+		cable, reverseCable = reverseCable, cable
+	}
+	edges := e.GET(fabricURI+"/Oem/Topology").WithBasicAuth(mockPluginUserName, mockPluginPassword).Expect().Status(http.StatusOK).
+		JSON().Object().Value("Edges").Array()
+	edges.Contains(cable)
+	edges.NotContains(reverseCable)
+
+	// the links are dropped once they go down
+	apic.AddObject("fabricLink", map[string]interface{}{"dn": linkDN, "linkState": "lost-connectivity"})
+	if err := refreshFabricLinks(); err != nil {
+		t.Fatalf("refreshFabricLinks failed: %v", err)
+	}
+	assertConnectedPorts(t, portURI("101", "eth1/1"))
+	assertConnectedPorts(t, portURI("201", "eth1/1"))
+	assertConnectedPorts(t, portURI("102", "eth1/2"), portURI("201", "eth1/2"))
+
+	// a switch which fails to be refreshed doesn't keep the others from being refreshed
+	fabricID := config.Data.RootServiceUUID + ":1"
+	leaf102 := capmodel.SwitchID("1", "SAL102", "102")
+	fabric, err := capmodel.GetFabric(fabricID)
+	if err != nil {
+		t.Fatalf("fabric %s is not stored: %v", fabricID, err)
+	}
+	switchIDs := []string{leaf102}
+	for _, switchID := range fabric.SwitchData {
+		if switchID != leaf102 {
+			switchIDs = append(switchIDs, switchID)
+		}
+	}
+	fabric.SwitchData = switchIDs
+	capmodel.UpdateFabric(fabricID, &fabric)
+	capmodel.DeleteSwitchPort(leaf102)
+	apic.AddObject("fabricLink", map[string]interface{}{"dn": linkDN, "linkState": "ok"})
+	if err := refreshFabricLinks(); err != nil {
+		t.Fatalf("refreshFabricLinks failed: %v", err)
+	}
+	assertConnectedPorts(t, portURI("101", "eth1/1"), portURI("201", "eth1/1"))
+	assertConnectedPorts(t, portURI("201", "eth1/1"), portURI("101", "eth1/1"))
+}
+
+func TestWatchFabricLinks(t *testing.T) {
+	apic := setUpMockPlugin(t, loadMockFabric, func(apic *caputilities.MockAPIC) {
+		loadMockLeaf(apic, "1", "201")
+	})
+	lost := make(chan struct{})
+	watcherDone := make(chan struct{})
+	go func() {
+		watchFabricLinks(lost)
+		close(watcherDone)
+	}()
+	waitFor(t, "subscriptions", func() bool {
+		return len(apic.SubscribedClasses()) == len(fabricLinkClasses)
+	})
+
+	// the links are refreshed as soon as APIC notifies the change
+	fabricURI := "/ODIM/v1/Fabrics/" + config.Data.RootServiceUUID + ":1"
+	port101 := fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL101", "101") + "/Ports/" + capmodel.PortID("1", "101", "eth1/1")
+	port201 := fabricURI + "/Switches/" + capmodel.SwitchID("1", "SAL201", "201") + "/Ports/" + capmodel.PortID("1", "201", "eth1/1")
+	apic.AddObject("fabricLink", map[string]interface{}{
+		"dn": "topology/pod-1/lnkcnt-201/lnk-101-1-1-to-201-1-1", "n1": "101", "s1": "1", "p1": "1", "n2": "201", "s2": "1", "p2": "1", "linkState": "ok",
+	})
+	waitFor(t, "link refresh", func() bool {
+		port, err := capmodel.GetPort(port101)
+		return err == nil && len(getConnectedSwitchPorts(port)) == 1
+	})
+	assertConnectedPorts(t, port101, port201)
+	assertConnectedPorts(t, port201, port101)
+
+	close(lost)
+	select {
+	case <-watcherDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher didn't stop")
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}